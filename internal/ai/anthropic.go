@@ -0,0 +1,310 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	anthropicMessagesURL = "https://api.anthropic.com/v1/messages"
+	anthropicVersion     = "2023-06-01"
+	anthropicReviewTool  = "submit_review"
+	providerAnthropic    = "anthropic"
+)
+
+// Anthropic talks to the Messages API. With FormatJSONSchema the review
+// schema is sent as the input schema of a forced tool call, which is how
+// the API constrains output.
+type Anthropic struct {
+	Key       string
+	Model     string
+	MaxTokens int
+	gen       Generation
+	client    *http.Client
+	endpoint  string
+}
+
+func NewAnthropic(key, model string, maxTokens int, gen Generation) *Anthropic {
+	return &Anthropic{
+		Key:       key,
+		Model:     model,
+		MaxTokens: maxTokens,
+		gen:       gen,
+		endpoint:  anthropicMessagesURL,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// Capabilities reports structured output only for FormatJSONSchema; the
+// API has no plain JSON mode, so FormatJSONObject relies on the prompt.
+func (a *Anthropic) Capabilities() Capabilities {
+	return a.gen.capabilities()
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  map[string]string  `json:"tool_choice,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u anthropicUsage) usage() Usage {
+	return Usage{
+		PromptTokens:     u.InputTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      u.InputTokens + u.OutputTokens,
+	}
+}
+
+func (a *Anthropic) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	return a.complete(ctx, r.call())
+}
+
+func (a *Anthropic) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return a.complete(ctx, r.call())
+}
+
+func (a *Anthropic) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	return a.complete(ctx, r.call())
+}
+
+func (a *Anthropic) SuggestTests(ctx context.Context, r TestRequest) (ReviewResponse, error) {
+	return a.complete(ctx, r.call())
+}
+
+func (a *Anthropic) complete(ctx context.Context, c call) (ReviewResponse, error) {
+
+	req, _, err := a.request(ctx, c, false)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+	defer res.Body.Close()
+
+	if err := checkAnthropicStatus(res); err != nil {
+		return ReviewResponse{}, err
+	}
+
+	var out anthropicResponse
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return ReviewResponse{}, fmt.Errorf("decode anthropic response: %w", err)
+	}
+
+	content, structured := out.review()
+	if content == "" {
+		return ReviewResponse{}, fmt.Errorf("no response")
+	}
+
+	return ReviewResponse{
+		Content:    content,
+		Provider:   providerAnthropic,
+		Model:      out.Model,
+		Usage:      out.Usage.usage(),
+		Structured: structured,
+	}, nil
+}
+
+// ReviewStream streams the message events. With the review tool the
+// deltas are the tool input JSON; text blocks are only streamed when no
+// tool is forced.
+func (a *Anthropic) ReviewStream(ctx context.Context, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+
+	req, model, err := a.request(ctx, r.call(), true)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+
+	res, err := streamClient.Do(req)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+	defer res.Body.Close()
+
+	if err := checkAnthropicStatus(res); err != nil {
+		return ReviewResponse{}, err
+	}
+
+	resp := ReviewResponse{Provider: providerAnthropic, Model: model}
+	var text, input strings.Builder
+	var usage anthropicUsage
+
+	err = readSSE(res.Body, func(data []byte) error {
+		var ev struct {
+			Type    string `json:"type"`
+			Message struct {
+				Model string         `json:"model"`
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+			} `json:"delta"`
+			Usage anthropicUsage `json:"usage"`
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return fmt.Errorf("decode anthropic stream: %w", err)
+		}
+
+		var delta string
+		switch ev.Type {
+		case "message_start":
+			if ev.Message.Model != "" {
+				resp.Model = ev.Message.Model
+			}
+			usage.InputTokens = ev.Message.Usage.InputTokens
+		case "message_delta":
+			usage.OutputTokens = ev.Usage.OutputTokens
+		case "message_stop":
+			return errStreamDone
+		case "error":
+			return fmt.Errorf("anthropic stream: %s", ev.Error.Message)
+		case "content_block_delta":
+			switch ev.Delta.Type {
+			case "input_json_delta":
+				input.WriteString(ev.Delta.PartialJSON)
+				delta = ev.Delta.PartialJSON
+			case "text_delta":
+				text.WriteString(ev.Delta.Text)
+				if !a.Capabilities().StructuredOutput {
+					delta = ev.Delta.Text
+				}
+			}
+		}
+
+		if delta != "" {
+			if err := onDelta(delta); err != nil {
+				return abortStream(err)
+			}
+		}
+		return nil
+	})
+
+	resp.Usage = usage.usage()
+	resp.Content, resp.Structured = text.String(), false
+	if input.Len() > 0 {
+		resp.Content, resp.Structured = input.String(), true
+	}
+	if err != nil {
+		return resp, err
+	}
+	if resp.Content == "" {
+		return resp, fmt.Errorf("no response")
+	}
+	return resp, nil
+}
+
+// request builds the Messages API call and returns the model it asks for.
+func (a *Anthropic) request(ctx context.Context, c call, stream bool) (*http.Request, string, error) {
+
+	msgs, err := c.messages()
+	if err != nil {
+		return nil, "", err
+	}
+
+	model := a.Model
+	if c.model != "" {
+		model = c.model
+	}
+
+	body := anthropicRequest{
+		Model:       model,
+		MaxTokens:   a.MaxTokens,
+		System:      msgs.System,
+		Messages:    []anthropicMessage{{Role: "user", Content: msgs.User}},
+		Temperature: a.gen.Temperature,
+		Stream:      stream,
+	}
+	if a.Capabilities().StructuredOutput {
+		body.Tools = []anthropicTool{{
+			Name:        anthropicReviewTool,
+			Description: "Submit the code review findings.",
+			InputSchema: c.schema,
+		}}
+		body.ToolChoice = map[string]string{"type": "tool", "name": anthropicReviewTool}
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal anthropic request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.endpoint, bytes.NewReader(b))
+	if err != nil {
+		return nil, "", fmt.Errorf("build anthropic request: %w", err)
+	}
+
+	req.Header.Set("x-api-key", a.Key)
+	req.Header.Set("anthropic-version", anthropicVersion)
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, model, nil
+}
+
+func checkAnthropicStatus(res *http.Response) error {
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return &StatusError{Provider: providerAnthropic, StatusCode: res.StatusCode, Body: string(msg)}
+	}
+	return nil
+}
+
+// review returns the tool call input when the model used the review tool,
+// and the concatenated text blocks otherwise.
+func (r anthropicResponse) review() (string, bool) {
+	var text strings.Builder
+	for _, c := range r.Content {
+		switch c.Type {
+		case "tool_use":
+			return string(c.Input), true
+		case "text":
+			text.WriteString(c.Text)
+		}
+	}
+	return text.String(), false
+}
@@ -0,0 +1,174 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"ai-code-reviewer/internal/cache"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/prompt"
+	"ai-code-reviewer/internal/review"
+)
+
+// Cache answers requests whose chunks were already reviewed by the same
+// model under the same prompt version, e.g. after a rebase that left the
+// PR's own code unchanged. Only reviews that parse are stored; corrective
+// re-prompts and the other tasks always reach the model.
+type Cache struct {
+	next  Provider
+	store cache.Store
+	ttl   time.Duration
+	// provider serves requests that model routing did not assign a model.
+	provider string
+	// modelFor returns the model a chain member is configured with.
+	modelFor func(provider string) string
+}
+
+func NewCache(next Provider, store cache.Store, ttl time.Duration, provider string, modelFor func(string) string) *Cache {
+	return &Cache{next: next, store: store, ttl: ttl, provider: provider, modelFor: modelFor}
+}
+
+func (c *Cache) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	return c.cached(ctx, r, func() (ReviewResponse, error) {
+		return c.next.Review(ctx, r)
+	}, nil)
+}
+
+func (c *Cache) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return c.next.Verify(ctx, r)
+}
+
+func (c *Cache) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	return c.next.Summarize(ctx, r)
+}
+
+func (c *Cache) SuggestTests(ctx context.Context, r TestRequest) (ReviewResponse, error) {
+	return c.next.SuggestTests(ctx, r)
+}
+
+// ReviewStream replays a hit as a single delta and streams misses.
+func (c *Cache) ReviewStream(ctx context.Context, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+	return c.cached(ctx, r, func() (ReviewResponse, error) {
+		return Stream(ctx, c.next, r, onDelta)
+	}, onDelta)
+}
+
+func (c *Cache) Capabilities() Capabilities {
+	return CapabilitiesOf(c.next)
+}
+
+func (c *Cache) cached(
+	ctx context.Context,
+	r ReviewRequest,
+	call func() (ReviewResponse, error),
+	onHit func(string) error,
+) (ReviewResponse, error) {
+	if r.Correction != nil {
+		return call()
+	}
+
+	provider, model := c.requested(r)
+	key := c.key(r, model)
+
+	b, ok, err := c.store.Get(ctx, key)
+	switch {
+	case err != nil:
+		observability.AICacheRequests.WithLabelValues("error").Inc()
+	case ok:
+		var resp ReviewResponse
+		if json.Unmarshal(b, &resp) == nil {
+			observability.AICacheRequests.WithLabelValues("hit").Inc()
+			resp.Cached = true
+			resp.Usage = Usage{}
+			if onHit != nil {
+				if err := onHit(resp.Content); err != nil {
+					return resp, abortStream(err)
+				}
+			}
+			return resp, nil
+		}
+		observability.AICacheRequests.WithLabelValues("error").Inc()
+	default:
+		observability.AICacheRequests.WithLabelValues("miss").Inc()
+	}
+
+	resp, err := call()
+	if err != nil || !parses(r, resp) {
+		return resp, err
+	}
+
+	// a fallback answered: the review belongs to its model, not the
+	// requested one
+	if resp.Provider != "" && resp.Provider != provider {
+		key = c.key(r, c.modelFor(resp.Provider))
+	}
+
+	if b, err := json.Marshal(resp); err == nil {
+		if err := c.store.Set(ctx, key, b, c.ttl); err != nil {
+			observability.AICacheRequests.WithLabelValues("error").Inc()
+		}
+	}
+	return resp, nil
+}
+
+// requested returns the chain member and model meant to answer r.
+func (c *Cache) requested(r ReviewRequest) (provider, model string) {
+	if r.Model != "" {
+		return r.Provider, r.Model
+	}
+	return c.provider, c.modelFor(c.provider)
+}
+
+// key hashes what decides the review: model, prompt version, repository
+// instructions, the normalized chunks and where their hunks start. PR
+// context and surrounding code are left out; they change with every rebase
+// while the review rarely does. Hunk positions are kept because the
+// issues' line numbers depend on them. Static analysis hints follow from
+// the code and are left out too.
+func (c *Cache) key(r ReviewRequest, model string) string {
+	h := sha256.New()
+	write := func(s string) {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+
+	write(model)
+	write(prompt.Version)
+	write(strings.TrimSpace(r.Instructions))
+	if r.IsBatch() {
+		for _, f := range r.Files {
+			write(f.File)
+			write(normalize(f.Content))
+			write(fmt.Sprint(f.HunkStarts))
+		}
+	} else {
+		write(r.File)
+		write(normalize(r.Content))
+		write(fmt.Sprint(r.HunkStarts))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalize drops line ending and trailing whitespace differences.
+func normalize(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+func parses(r ReviewRequest, resp ReviewResponse) bool {
+	if r.IsBatch() {
+		_, err := review.ParseBatchResult(resp.Content, r.Paths())
+		return err == nil
+	}
+	_, err := review.ParseResult(resp.Content)
+	return err == nil
+}
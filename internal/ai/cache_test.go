@@ -0,0 +1,135 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"ai-code-reviewer/internal/cache"
+	"ai-code-reviewer/internal/review"
+
+	"github.com/stretchr/testify/require"
+)
+
+type textStub struct {
+	reviewOnly
+	calls   int
+	content string
+}
+
+func (p *textStub) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	p.calls++
+	return ReviewResponse{Content: p.content, Model: "gpt-4o", Usage: Usage{TotalTokens: 100}}, nil
+}
+
+func (p *textStub) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return p.Review(ctx, ReviewRequest{})
+}
+
+var testModels = map[string]string{"openai": "gpt-4o", "ollama": "llama3"}
+
+func newTestCache(next Provider) *Cache {
+	return NewCache(next, cache.NewMemoryStore(), time.Hour, "openai", func(name string) string { return testModels[name] })
+}
+
+func TestCache_ReplaysNormalizedChunk(t *testing.T) {
+	next := &providerStub{}
+	c := newTestCache(next)
+	ctx := context.Background()
+
+	first, err := c.Review(ctx, ReviewRequest{File: "main.go", Content: "Hunk:\n+x := 1\n"})
+	require.NoError(t, err)
+	require.False(t, first.Cached)
+
+	// same code after a rebase, with CRLF endings and trailing spaces
+	second, err := c.Review(ctx, ReviewRequest{File: "main.go", Content: "Hunk:\r\n+x := 1  \r\n"})
+	require.NoError(t, err)
+	require.True(t, second.Cached)
+	require.Equal(t, first.Content, second.Content)
+	require.Zero(t, second.Usage)
+	require.Equal(t, 1, next.calls)
+}
+
+func TestCache_KeysByModel(t *testing.T) {
+	next := &providerStub{}
+	c := newTestCache(next)
+	ctx := context.Background()
+
+	req := ReviewRequest{File: "main.go", Content: "+x"}
+	_, _ = c.Review(ctx, req)
+
+	req.Provider, req.Model = "openai", "gpt-4.1"
+	resp, err := c.Review(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.Equal(t, 2, next.calls)
+}
+
+func TestCache_SkipsUnparsableCorrectionsAndTasks(t *testing.T) {
+	next := &textStub{content: "looks fine to me"}
+	c := newTestCache(next)
+	ctx := context.Background()
+
+	req := ReviewRequest{File: "main.go", Content: "+x"}
+	_, _ = c.Review(ctx, req)
+	_, _ = c.Review(ctx, req)
+	require.Equal(t, 2, next.calls)
+
+	next.content = `{"issues":[]}`
+	req.Correction = &Correction{Previous: "looks fine to me", Problem: "not JSON"}
+	_, _ = c.Review(ctx, req)
+	_, _ = c.Review(ctx, req)
+	require.Equal(t, 4, next.calls)
+
+	next.content = `{"verdict":"no","reason":"handled"}`
+	verify := VerifyRequest{File: "main.go", Content: "+x", Issue: review.Issue{Line: 1, Title: "unused x"}}
+	_, _ = c.Verify(ctx, verify)
+	_, _ = c.Verify(ctx, verify)
+	require.Equal(t, 6, next.calls)
+}
+
+func TestCache_KeysByHunkPosition(t *testing.T) {
+	next := &providerStub{}
+	c := newTestCache(next)
+	ctx := context.Background()
+
+	req := ReviewRequest{File: "main.go", Content: "Hunk:\n+x := 1\n", HunkStarts: []int{10}}
+	_, _ = c.Review(ctx, req)
+
+	// the same hunk moved down by a rebase
+	req.HunkStarts = []int{25}
+	resp, err := c.Review(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.Equal(t, 2, next.calls)
+}
+
+func TestCache_StoresFallbackUnderItsModel(t *testing.T) {
+	primary := &providerStub{err: &StatusError{Provider: "openai", StatusCode: http.StatusServiceUnavailable}}
+	secondary := &providerStub{}
+	c := newTestCache(newTestChain(
+		ChainEntry{Name: "openai", Weight: 1, Provider: primary},
+		ChainEntry{Name: "ollama", Weight: 0, Provider: secondary},
+	))
+	ctx := context.Background()
+
+	req := ReviewRequest{File: "main.go", Content: "+x"}
+	resp, err := c.Review(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, "ollama", resp.Provider)
+
+	// the primary is back: the fallback's review is not replayed for it
+	primary.err = nil
+	resp, err = c.Review(ctx, req)
+	require.NoError(t, err)
+	require.False(t, resp.Cached)
+	require.Equal(t, 2, primary.calls)
+
+	// but it is for requests routed to the fallback's model
+	req.Provider, req.Model = "ollama", "llama3"
+	resp, err = c.Review(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.Cached)
+	require.Equal(t, 1, secondary.calls)
+}
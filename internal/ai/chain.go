@@ -0,0 +1,211 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"ai-code-reviewer/internal/observability"
+
+	"github.com/sony/gobreaker"
+)
+
+// ChainEntry is one provider of a Chain.
+type ChainEntry struct {
+	Name     string
+	Weight   int
+	Provider Provider
+}
+
+// Chain tries providers in turn, each behind its own circuit breaker. The
+// first provider is picked at random by weight among those whose breaker
+// is not open; the rest follow in declared order. Weight 0 makes a
+// provider fallback only. A request routed to a provider (see Router)
+// tries that provider first; the others serve it with their own model.
+type Chain struct {
+	members []chainMember
+	pick    func(n int) int
+}
+
+type chainMember struct {
+	name    string
+	weight  int
+	breaker *CircuitBreakerProvider
+}
+
+func NewChain(entries []ChainEntry, s BreakerSettings) *Chain {
+	c := &Chain{pick: rand.IntN}
+	for _, e := range entries {
+		c.members = append(c.members, chainMember{
+			name:    e.Name,
+			weight:  e.Weight,
+			breaker: NewCircuitBreaker(e.Name, e.Provider, s),
+		})
+	}
+	return c
+}
+
+func (c *Chain) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	return c.try(ctx, r.Target, func(m chainMember, t Target) (ReviewResponse, error) {
+		r.Target = t
+		return m.breaker.Review(ctx, r)
+	}, nil)
+}
+
+func (c *Chain) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return c.try(ctx, r.Target, func(m chainMember, t Target) (ReviewResponse, error) {
+		r.Target = t
+		return m.breaker.Verify(ctx, r)
+	}, nil)
+}
+
+func (c *Chain) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	return c.try(ctx, Target{}, func(m chainMember, _ Target) (ReviewResponse, error) {
+		return m.breaker.Summarize(ctx, r)
+	}, nil)
+}
+
+func (c *Chain) SuggestTests(ctx context.Context, r TestRequest) (ReviewResponse, error) {
+	return c.try(ctx, r.Target, func(m chainMember, t Target) (ReviewResponse, error) {
+		r.Target = t
+		return m.breaker.SuggestTests(ctx, r)
+	}, nil)
+}
+
+// ReviewStream streams from the first member that answers. Text already
+// passed on cannot be taken back, so once a member has streamed anything
+// its failure ends the chain.
+func (c *Chain) ReviewStream(ctx context.Context, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+	started := false
+	forward := func(d string) error {
+		started = true
+		return onDelta(d)
+	}
+	return c.try(ctx, r.Target, func(m chainMember, t Target) (ReviewResponse, error) {
+		r.Target = t
+		return m.breaker.ReviewStream(ctx, r, forward)
+	}, func() bool { return started })
+}
+
+// Capabilities reports structured output when any member can produce it;
+// each response's Structured flag says whether the member that answered
+// did.
+func (c *Chain) Capabilities() Capabilities {
+	var caps Capabilities
+	for _, m := range c.members {
+		caps.StructuredOutput = caps.StructuredOutput || m.breaker.Capabilities().StructuredOutput
+	}
+	return caps
+}
+
+// try calls the members in order until one succeeds. The routed member
+// gets the routed target, the others their own model. committed, if set,
+// reports that a failed attempt must not fall back.
+func (c *Chain) try(
+	ctx context.Context,
+	routed Target,
+	call func(m chainMember, t Target) (ReviewResponse, error),
+	committed func() bool,
+) (ReviewResponse, error) {
+
+	var errs []error
+
+	for _, m := range c.order(routed.Provider) {
+		t := routed
+		if m.name != routed.Provider {
+			t = Target{}
+		}
+
+		resp, err := call(m, t)
+		if resp.Provider == "" {
+			resp.Provider = m.name
+		}
+		if err == nil {
+			observability.AIProviderRequests.WithLabelValues(m.name, "served").Inc()
+			return resp, nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", m.name, err))
+
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			observability.AIProviderRequests.WithLabelValues(m.name, "skipped").Inc()
+			continue
+		}
+
+		switch Classify(err) {
+		case ClassInvalid:
+			observability.AIProviderRequests.WithLabelValues(m.name, "rejected").Inc()
+			return ReviewResponse{}, fmt.Errorf("%s: %w", m.name, err)
+		case ClassCanceled:
+			return resp, fmt.Errorf("%s: %w", m.name, err)
+		}
+
+		observability.AIProviderRequests.WithLabelValues(m.name, "failed").Inc()
+		if ctx.Err() != nil || (committed != nil && committed()) {
+			return resp, errors.Join(errs...)
+		}
+	}
+
+	if len(errs) == 0 {
+		return ReviewResponse{}, errors.New("empty provider chain")
+	}
+	return ReviewResponse{}, errors.Join(errs...)
+}
+
+// Has reports whether a provider is a member of the chain.
+func (c *Chain) Has(name string) bool {
+	for _, m := range c.members {
+		if m.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// order returns the members in the order they are tried for one request.
+// A routed provider goes first, the rest keep their weighted order.
+func (c *Chain) order(routed string) []chainMember {
+	out := c.weighted()
+	for i, m := range out {
+		if m.name == routed && i > 0 {
+			routedFirst := append([]chainMember{m}, out[:i]...)
+			return append(routedFirst, out[i+1:]...)
+		}
+	}
+	return out
+}
+
+func (c *Chain) weighted() []chainMember {
+	total := 0
+	for _, m := range c.members {
+		if m.weight > 0 && !m.breaker.Open() {
+			total += m.weight
+		}
+	}
+	if total == 0 {
+		return c.members
+	}
+
+	first := -1
+	n := c.pick(total)
+	for i, m := range c.members {
+		if m.weight <= 0 || m.breaker.Open() {
+			continue
+		}
+		if n < m.weight {
+			first = i
+			break
+		}
+		n -= m.weight
+	}
+
+	out := make([]chainMember, 0, len(c.members))
+	out = append(out, c.members[first])
+	for i, m := range c.members {
+		if i != first {
+			out = append(out, m)
+		}
+	}
+	return out
+}
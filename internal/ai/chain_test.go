@@ -0,0 +1,270 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// reviewOnly fails the tasks a test provider does not expect.
+type reviewOnly struct{}
+
+var errUnexpectedTask = errors.New("unexpected task")
+
+func (reviewOnly) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return ReviewResponse{}, errUnexpectedTask
+}
+
+func (reviewOnly) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	return ReviewResponse{}, errUnexpectedTask
+}
+
+func (reviewOnly) SuggestTests(ctx context.Context, r TestRequest) (ReviewResponse, error) {
+	return ReviewResponse{}, errUnexpectedTask
+}
+
+type providerStub struct {
+	reviewOnly
+	calls int
+	err   error
+}
+
+func (p *providerStub) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return ReviewResponse{}, p.err
+	}
+	return ReviewResponse{Content: `{"issues":[]}`}, nil
+}
+
+var testBreaker = BreakerSettings{Failures: 2, Timeout: time.Minute, HalfOpenRequests: 1}
+
+func newTestChain(entries ...ChainEntry) *Chain {
+	c := NewChain(entries, testBreaker)
+	c.pick = func(int) int { return 0 }
+	return c
+}
+
+func TestChain_FallsBackOnTransientErrors(t *testing.T) {
+	primary := &providerStub{err: &StatusError{Provider: "openai", StatusCode: http.StatusServiceUnavailable}}
+	secondary := &providerStub{}
+
+	c := newTestChain(
+		ChainEntry{Name: "openai", Weight: 1, Provider: primary},
+		ChainEntry{Name: "ollama", Weight: 0, Provider: secondary},
+	)
+
+	resp, err := c.Review(context.Background(), ReviewRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "ollama", resp.Provider)
+	require.Equal(t, 1, primary.calls)
+	require.Equal(t, 1, secondary.calls)
+}
+
+func TestChain_InvalidRequestDoesNotFallBackOrTrip(t *testing.T) {
+	primary := &providerStub{err: &StatusError{Provider: "openai", StatusCode: http.StatusBadRequest}}
+	secondary := &providerStub{}
+
+	c := newTestChain(
+		ChainEntry{Name: "openai", Weight: 1, Provider: primary},
+		ChainEntry{Name: "ollama", Weight: 0, Provider: secondary},
+	)
+
+	for range 3 {
+		_, err := c.Review(context.Background(), ReviewRequest{})
+		require.Equal(t, ClassInvalid, Classify(err))
+	}
+
+	require.Equal(t, 3, primary.calls)
+	require.Zero(t, secondary.calls)
+	require.False(t, c.members[0].breaker.Open())
+}
+
+func TestChain_UnknownModelFallsBackAndTrips(t *testing.T) {
+	primary := &providerStub{err: &StatusError{Provider: "azure", StatusCode: http.StatusNotFound, Body: "DeploymentNotFound"}}
+	secondary := &providerStub{}
+
+	c := newTestChain(
+		ChainEntry{Name: "azure", Weight: 1, Provider: primary},
+		ChainEntry{Name: "ollama", Weight: 0, Provider: secondary},
+	)
+
+	for range 3 {
+		resp, err := c.Review(context.Background(), ReviewRequest{})
+		require.NoError(t, err)
+		require.Equal(t, "ollama", resp.Provider)
+	}
+
+	require.Equal(t, testBreaker.Failures, primary.calls)
+	require.Equal(t, 3, secondary.calls)
+	require.True(t, c.members[0].breaker.Open())
+}
+
+func TestChain_OpenBreakerIsSkipped(t *testing.T) {
+	primary := &providerStub{err: errors.New("connection refused")}
+	secondary := &providerStub{}
+
+	c := newTestChain(
+		ChainEntry{Name: "openai", Weight: 1, Provider: primary},
+		ChainEntry{Name: "anthropic", Weight: 1, Provider: secondary},
+	)
+
+	for range 2 {
+		_, err := c.Review(context.Background(), ReviewRequest{})
+		require.NoError(t, err)
+	}
+	require.True(t, c.members[0].breaker.Open())
+
+	// the open primary is no longer picked first, nor called as fallback
+	resp, err := c.Review(context.Background(), ReviewRequest{})
+	require.NoError(t, err)
+	require.Equal(t, "anthropic", resp.Provider)
+	require.Equal(t, 2, primary.calls)
+	require.Equal(t, 3, secondary.calls)
+}
+
+func TestChain_WeightedFirstPick(t *testing.T) {
+	a, b, c := &providerStub{}, &providerStub{}, &providerStub{}
+	chain := NewChain([]ChainEntry{
+		{Name: "a", Weight: 3, Provider: a},
+		{Name: "b", Weight: 1, Provider: b},
+		{Name: "c", Weight: 0, Provider: c},
+	}, testBreaker)
+
+	names := func(ms []chainMember) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, m.name)
+		}
+		return out
+	}
+
+	chain.pick = func(int) int { return 2 }
+	require.Equal(t, []string{"a", "b", "c"}, names(chain.order("")))
+
+	chain.pick = func(n int) int {
+		require.Equal(t, 4, n)
+		return 3
+	}
+	require.Equal(t, []string{"b", "a", "c"}, names(chain.order("")))
+}
+
+func TestClassify(t *testing.T) {
+	require.Equal(t, ClassInvalid, Classify(&StatusError{StatusCode: http.StatusUnprocessableEntity}))
+	require.Equal(t, ClassTransient, Classify(&StatusError{StatusCode: http.StatusTooManyRequests}))
+	require.Equal(t, ClassTransient, Classify(&StatusError{StatusCode: http.StatusUnauthorized}))
+	require.Equal(t, ClassTransient, Classify(&StatusError{StatusCode: http.StatusNotFound}))
+	require.Equal(t, ClassTransient, Classify(errors.New("dial tcp: timeout")))
+	require.Equal(t, ClassCanceled, Classify(context.Canceled))
+}
+
+type modelStub struct {
+	reviewOnly
+	got []ReviewRequest
+}
+
+func (p *modelStub) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	p.got = append(p.got, r)
+	return ReviewResponse{Content: `{"issues":[]}`, Model: r.Model}, nil
+}
+
+func (p *modelStub) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return ReviewResponse{Content: `{"verdict":"yes","reason":"real"}`, Model: r.Model}, nil
+}
+
+func (p *modelStub) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	return ReviewResponse{Content: `{"overview":"fine"}`}, nil
+}
+
+func TestChain_RoutedProviderGoesFirst(t *testing.T) {
+	openai, anthropic := &modelStub{}, &modelStub{}
+
+	c := newTestChain(
+		ChainEntry{Name: "openai", Weight: 1, Provider: openai},
+		ChainEntry{Name: "anthropic", Weight: 0, Provider: anthropic},
+	)
+
+	resp, err := c.Review(context.Background(), ReviewRequest{Target: Target{Provider: "anthropic", Model: "claude-opus-4-1"}})
+	require.NoError(t, err)
+	require.Equal(t, "anthropic", resp.Provider)
+	require.Equal(t, "claude-opus-4-1", resp.Model)
+	require.Empty(t, openai.got)
+}
+
+func TestChain_FallbackDropsRoutedModel(t *testing.T) {
+	routed := &providerStub{err: &StatusError{Provider: "anthropic", StatusCode: http.StatusServiceUnavailable}}
+	fallback := &modelStub{}
+
+	c := newTestChain(
+		ChainEntry{Name: "openai", Weight: 1, Provider: fallback},
+		ChainEntry{Name: "anthropic", Weight: 0, Provider: routed},
+	)
+
+	resp, err := c.Review(context.Background(), ReviewRequest{Target: Target{Provider: "anthropic", Model: "claude-opus-4-1"}})
+	require.NoError(t, err)
+	require.Equal(t, "openai", resp.Provider)
+	require.Equal(t, 1, routed.calls)
+	require.Len(t, fallback.got, 1)
+	require.Empty(t, fallback.got[0].Model)
+}
+
+type streamStub struct {
+	reviewOnly
+	deltas []string
+	err    error
+}
+
+func (p *streamStub) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	return ReviewResponse{}, errors.New("not used")
+}
+
+func (p *streamStub) ReviewStream(ctx context.Context, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+	for _, d := range p.deltas {
+		if err := onDelta(d); err != nil {
+			return ReviewResponse{}, abortStream(err)
+		}
+	}
+	return ReviewResponse{Content: strings.Join(p.deltas, "")}, p.err
+}
+
+func TestChain_StreamFallsBackOnlyBeforeText(t *testing.T) {
+	unavailable := &StatusError{Provider: "openai", StatusCode: http.StatusServiceUnavailable}
+
+	c := newTestChain(
+		ChainEntry{Name: "openai", Weight: 1, Provider: &streamStub{err: unavailable}},
+		ChainEntry{Name: "ollama", Weight: 0, Provider: &streamStub{deltas: []string{`{"issues":[]}`}}},
+	)
+	resp, err := Stream(context.Background(), c, ReviewRequest{}, func(string) error { return nil })
+	require.NoError(t, err)
+	require.Equal(t, "ollama", resp.Provider)
+
+	fallback := &providerStub{}
+	c = newTestChain(
+		ChainEntry{Name: "openai", Weight: 1, Provider: &streamStub{deltas: []string{`{"iss`}, err: unavailable}},
+		ChainEntry{Name: "ollama", Weight: 0, Provider: fallback},
+	)
+	_, err = Stream(context.Background(), c, ReviewRequest{}, func(string) error { return nil })
+	require.Error(t, err)
+	require.Zero(t, fallback.calls)
+}
+
+func TestChain_CapabilitiesPassThroughWrappers(t *testing.T) {
+	structured := NewOpenAICompatible("http://localhost", "", "gpt-4o", Generation{Format: FormatJSONSchema})
+	text := NewOpenAICompatible("http://localhost", "", "qwen2.5-coder", Generation{Format: FormatText})
+
+	c := newTestChain(
+		ChainEntry{Name: "ollama", Weight: 1, Provider: text},
+		ChainEntry{Name: "openai", Weight: 0, Provider: structured},
+	)
+	require.True(t, CapabilitiesOf(NewRouter(c, nil)).StructuredOutput)
+
+	c = newTestChain(ChainEntry{Name: "ollama", Weight: 1, Provider: text})
+	require.False(t, CapabilitiesOf(NewRouter(c, nil)).StructuredOutput)
+
+	require.False(t, CapabilitiesOf(&providerStub{}).StructuredOutput)
+}
@@ -5,36 +5,75 @@ import (
 	"fmt"
 	"time"
 
+	"ai-code-reviewer/internal/observability"
+
 	"github.com/sony/gobreaker"
 )
 
+// BreakerSettings configures the circuit breaker of each provider.
+type BreakerSettings struct {
+	// Failures is the number of consecutive transient failures that opens
+	// the breaker.
+	Failures int
+	// Timeout is how long the breaker stays open before letting
+	// HalfOpenRequests probe the provider.
+	Timeout          time.Duration
+	HalfOpenRequests int
+}
+
 type CircuitBreakerProvider struct {
 	provider Provider
 	cb       *gobreaker.CircuitBreaker
 }
 
-func NewCircuitBreaker(p Provider) *CircuitBreakerProvider {
+func NewCircuitBreaker(name string, p Provider, s BreakerSettings) *CircuitBreakerProvider {
 
 	settings := gobreaker.Settings{
-		Name:        "ai-provider",
-		MaxRequests: 3,
+		Name:        name,
+		MaxRequests: uint32(max(s.HalfOpenRequests, 1)),
 		Interval:    0,
-		Timeout:     30 * time.Second,
+		Timeout:     s.Timeout,
+		ReadyToTrip: func(c gobreaker.Counts) bool {
+			return c.ConsecutiveFailures >= uint32(max(s.Failures, 1))
+		},
+		// Rejected requests and cancellations say nothing about the
+		// provider's health.
+		IsSuccessful: func(err error) bool {
+			return err == nil || Classify(err) != ClassTransient
+		},
+		OnStateChange: func(name string, _, to gobreaker.State) {
+			observability.AIBreakerState.WithLabelValues(name).Set(float64(to))
+		},
 	}
 
+	observability.AIBreakerState.WithLabelValues(name).Set(float64(gobreaker.StateClosed))
+
 	return &CircuitBreakerProvider{
 		provider: p,
 		cb:       gobreaker.NewCircuitBreaker(settings),
 	}
 }
 
-func (c *CircuitBreakerProvider) Review(
-	ctx context.Context,
-	r ReviewRequest,
-) (ReviewResponse, error) {
+func (c *CircuitBreakerProvider) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	return c.execute(func() (ReviewResponse, error) { return c.provider.Review(ctx, r) })
+}
+
+func (c *CircuitBreakerProvider) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return c.execute(func() (ReviewResponse, error) { return c.provider.Verify(ctx, r) })
+}
+
+func (c *CircuitBreakerProvider) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	return c.execute(func() (ReviewResponse, error) { return c.provider.Summarize(ctx, r) })
+}
+
+func (c *CircuitBreakerProvider) SuggestTests(ctx context.Context, r TestRequest) (ReviewResponse, error) {
+	return c.execute(func() (ReviewResponse, error) { return c.provider.SuggestTests(ctx, r) })
+}
+
+func (c *CircuitBreakerProvider) execute(call func() (ReviewResponse, error)) (ReviewResponse, error) {
 
 	out, err := c.cb.Execute(func() (interface{}, error) {
-		return c.provider.Review(ctx, r)
+		return call()
 	})
 
 	if err != nil {
@@ -48,3 +87,29 @@ func (c *CircuitBreakerProvider) Review(
 
 	return resp, nil
 }
+
+// ReviewStream streams through the breaker. Aborted streams do not count
+// as failures.
+func (c *CircuitBreakerProvider) ReviewStream(
+	ctx context.Context,
+	r ReviewRequest,
+	onDelta func(string) error,
+) (ReviewResponse, error) {
+
+	var resp ReviewResponse
+	_, err := c.cb.Execute(func() (interface{}, error) {
+		var err error
+		resp, err = Stream(ctx, c.provider, r, onDelta)
+		return nil, err
+	})
+	return resp, err
+}
+
+func (c *CircuitBreakerProvider) Capabilities() Capabilities {
+	return CapabilitiesOf(c.provider)
+}
+
+// Open reports whether the breaker is currently rejecting calls.
+func (c *CircuitBreakerProvider) Open() bool {
+	return c.cb.State() == gobreaker.StateOpen
+}
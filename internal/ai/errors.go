@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// StatusError is a non-2xx response from a provider API.
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s status %d: %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// ErrorClass tells the provider chain how to react to a failure.
+type ErrorClass int
+
+const (
+	// ClassTransient covers outages, timeouts, rate limits and network
+	// errors: retry, count against the breaker and fall back.
+	ClassTransient ErrorClass = iota
+	// ClassInvalid is a request the provider rejected as malformed, e.g.
+	// over its context length. Other providers would likely reject it too,
+	// so it neither trips the breaker nor falls back.
+	ClassInvalid
+	// ClassCanceled means the caller gave up or stopped a stream.
+	ClassCanceled
+)
+
+func Classify(err error) ErrorClass {
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrStreamAborted) {
+		return ClassCanceled
+	}
+
+	var se *StatusError
+	if !errors.As(err, &se) {
+		return ClassTransient
+	}
+
+	switch se.StatusCode {
+	case http.StatusBadRequest,
+		http.StatusRequestEntityTooLarge,
+		http.StatusUnprocessableEntity:
+		return ClassInvalid
+	default:
+		// 401/403 and 404 (an unknown model, deployment or endpoint) mean
+		// this provider is misconfigured, which another provider can work
+		// around; 408, 429 and 5xx are transient.
+		return ClassTransient
+	}
+}
@@ -1,21 +1,119 @@
 package ai
 
-import "ai-code-reviewer/internal/config"
+import (
+	"slices"
+	"time"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/redact"
+)
 
 func NewProvider(cfg *config.Config) Provider {
+	return newNamedProvider(cfg.AIProvider, cfg)
+}
 
-	switch cfg.AIProvider {
+// NewChainFromConfig builds the AI_PROVIDER_CHAIN providers, each behind a
+// circuit breaker with the AI_BREAKER_* settings.
+func NewChainFromConfig(cfg *config.Config) *Chain {
+	entries := make([]ChainEntry, 0, len(cfg.AIProviderChain))
+	for _, pw := range cfg.AIProviderChain {
+		entries = append(entries, ChainEntry{
+			Name:     pw.Name,
+			Weight:   pw.Weight,
+			Provider: newNamedProvider(pw.Name, cfg),
+		})
+	}
 
-	case "ollama":
+	return NewChain(entries, BreakerSettings{
+		Failures:         cfg.AIBreakerFailures,
+		Timeout:          time.Duration(cfg.AIBreakerTimeoutSec) * time.Second,
+		HalfOpenRequests: cfg.AIBreakerHalfOpen,
+	})
+}
+
+// newNamedProvider builds a provider, behind a Redactor when it is one of
+// AI_EXTERNAL_PROVIDERS.
+func newNamedProvider(name string, cfg *config.Config) Provider {
+	p := newBackend(name, cfg)
+	if slices.Contains(cfg.ExternalProviders, name) {
+		return NewRedactor(name, p, redact.New(cfg.RedactionRules, cfg.RedactionKey))
+	}
+	return p
+}
+
+func newBackend(name string, cfg *config.Config) Provider {
+
+	gen := GenerationFromConfig(cfg)
+
+	switch name {
+
+	case providerOllama:
 		return NewOllama(
 			cfg.OllamaURL,
 			cfg.OllamaModel,
+			OllamaOptionsFromConfig(cfg),
+			gen,
+		)
+
+	case providerAnthropic:
+		return NewAnthropic(
+			cfg.AnthropicKey,
+			cfg.AnthropicModel,
+			cfg.AnthropicMaxTokens,
+			gen,
+		)
+
+	case providerAzureOpenAI:
+		return NewAzureOpenAI(
+			cfg.AzureOpenAIEndpoint,
+			cfg.AzureOpenAIDeploy,
+			cfg.AzureOpenAIVersion,
+			cfg.AzureOpenAIKey,
+			gen,
+		)
+
+	case providerOpenAICompatible:
+		return NewOpenAICompatible(
+			cfg.OpenAICompatBaseURL,
+			cfg.OpenAICompatKey,
+			cfg.OpenAICompatModel,
+			gen,
 		)
 
 	default:
 		return NewOpenAI(
 			cfg.OpenAIKey,
 			cfg.OpenAIModel,
+			gen,
 		)
 	}
 }
+
+func OllamaOptionsFromConfig(cfg *config.Config) OllamaOptions {
+	return OllamaOptions{
+		NumCtx:    cfg.OllamaNumCtx,
+		KeepAlive: cfg.OllamaKeepAlive,
+	}
+}
+
+// ModelName returns the model NewProvider uses for the configured provider.
+func ModelName(cfg *config.Config) string {
+	return ModelNameFor(cfg.AIProvider, cfg)
+}
+
+// ModelNameFor returns the model a named provider is configured with.
+// Azure deployments are usually named after their model.
+func ModelNameFor(name string, cfg *config.Config) string {
+	switch name {
+	case providerOllama:
+		return cfg.OllamaModel
+	case providerAnthropic:
+		return cfg.AnthropicModel
+	case providerAzureOpenAI:
+		return cfg.AzureOpenAIDeploy
+	case providerOpenAICompatible:
+		return cfg.OpenAICompatModel
+	default:
+		return cfg.OpenAIModel
+	}
+}
@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"strings"
+
+	"ai-code-reviewer/internal/config"
+)
+
+type OutputFormat string
+
+const (
+	// FormatJSONSchema constrains decoding to review.JSONSchema.
+	FormatJSONSchema OutputFormat = "json_schema"
+	// FormatJSONObject only guarantees syntactically valid JSON.
+	FormatJSONObject OutputFormat = "json_object"
+	// FormatText relies on the prompt alone.
+	FormatText OutputFormat = "text"
+)
+
+// Generation holds sampling and output format settings shared by providers.
+type Generation struct {
+	Temperature float64
+	Seed        int // 0 leaves the seed unset
+	Format      OutputFormat
+}
+
+// Capabilities describes what a provider guarantees about its output.
+type Capabilities struct {
+	// StructuredOutput is true when responses are constrained to the review
+	// JSON schema and can be trusted without repair.
+	StructuredOutput bool
+}
+
+// Capable is implemented by providers that report their Capabilities.
+// Wrappers pass the question on to the providers they wrap.
+type Capable interface {
+	Capabilities() Capabilities
+}
+
+// CapabilitiesOf returns p's capabilities; providers that do not report
+// any guarantee nothing.
+func CapabilitiesOf(p Provider) Capabilities {
+	if c, ok := p.(Capable); ok {
+		return c.Capabilities()
+	}
+	return Capabilities{}
+}
+
+func GenerationFromConfig(cfg *config.Config) Generation {
+	format := OutputFormat(strings.ToLower(strings.TrimSpace(cfg.AIResponseFormat)))
+	switch format {
+	case FormatJSONSchema, FormatJSONObject, FormatText:
+	default:
+		format = FormatJSONSchema
+	}
+
+	return Generation{
+		Temperature: cfg.AITemperature,
+		Seed:        cfg.AISeed,
+		Format:      format,
+	}
+}
+
+func (g Generation) capabilities() Capabilities {
+	return Capabilities{StructuredOutput: g.Format == FormatJSONSchema}
+}
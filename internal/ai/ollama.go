@@ -4,110 +4,319 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"ai-code-reviewer/internal/tokenizer"
 )
 
+// ErrModelNotFound means the Ollama server does not have the model pulled.
+// It is not a StatusError: the provider is misconfigured, so the chain
+// falls back instead of treating the request as invalid.
+var ErrModelNotFound = errors.New("ollama model not found")
+
+// OllamaOptions are server-side settings sent with every Ollama request.
+type OllamaOptions struct {
+	// NumCtx sets the context window; Ollama's default silently truncates
+	// long prompts. 0 leaves the server default.
+	NumCtx int
+	// KeepAlive is how long the model stays loaded after a request, e.g.
+	// "30m" or "-1" for forever. Empty leaves the server default.
+	KeepAlive string
+}
+
 type OllamaProvider struct {
 	url    string
 	model  string
+	opts   OllamaOptions
+	gen    Generation
 	client *http.Client
 }
 
-func NewOllama(url, model string) *OllamaProvider {
+func NewOllama(url, model string, opts OllamaOptions, gen Generation) *OllamaProvider {
 	return &OllamaProvider{
-		url:   url,
+		url:   strings.TrimRight(url, "/"),
 		model: model,
+		opts:  opts,
+		gen:   gen,
 		client: &http.Client{
 			Timeout: 60 * time.Second,
 		},
 	}
 }
 
+func (o *OllamaProvider) Capabilities() Capabilities {
+	return o.gen.capabilities()
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
 type ollamaRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model     string          `json:"model"`
+	Messages  []ollamaMessage `json:"messages"`
+	Stream    bool            `json:"stream"`
+	Format    any             `json:"format,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
 }
 
+// ollamaResponse is the /api/chat response, and each line of its stream.
+// The counts are only set on the final ("done") message; prompt_eval_count
+// is left out when the whole prompt was served from the KV cache.
 type ollamaResponse struct {
-	Response string `json:"response"`
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (o *OllamaProvider) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	return o.complete(ctx, r.call())
+}
+
+func (o *OllamaProvider) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return o.complete(ctx, r.call())
+}
+
+func (o *OllamaProvider) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	return o.complete(ctx, r.call())
+}
+
+func (o *OllamaProvider) SuggestTests(ctx context.Context, r TestRequest) (ReviewResponse, error) {
+	return o.complete(ctx, r.call())
+}
+
+func (o *OllamaProvider) complete(ctx context.Context, c call) (ReviewResponse, error) {
+
+	req, reqBody, err := o.request(ctx, c, false)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+	defer resp.Body.Close()
+
+	if err := o.checkStatus(resp, reqBody.Model); err != nil {
+		return ReviewResponse{}, err
+	}
+
+	var out ollamaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return ReviewResponse{}, fmt.Errorf("decode ollama response: %w", err)
+	}
+
+	return o.response(reqBody, out.Message.Content, out), nil
+}
+
+// ReviewStream reads Ollama's newline-delimited JSON stream.
+func (o *OllamaProvider) ReviewStream(ctx context.Context, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+
+	req, reqBody, err := o.request(ctx, r.call(), true)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+
+	res, err := streamClient.Do(req)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+	defer res.Body.Close()
+
+	if err := o.checkStatus(res, reqBody.Model); err != nil {
+		return ReviewResponse{}, err
+	}
+
+	var content strings.Builder
+	var last ollamaResponse
+
+	err = readLines(res.Body, func(line []byte) error {
+		var out ollamaResponse
+		if err := json.Unmarshal(line, &out); err != nil {
+			return fmt.Errorf("decode ollama stream: %w", err)
+		}
+		if out.Error != "" {
+			return fmt.Errorf("ollama stream: %s", out.Error)
+		}
+
+		if d := out.Message.Content; d != "" {
+			content.WriteString(d)
+			if err := onDelta(d); err != nil {
+				return abortStream(err)
+			}
+		}
+		if out.Done {
+			last = out
+			return errStreamDone
+		}
+		return nil
+	})
+
+	return o.response(reqBody, content.String(), last), err
 }
 
-func (o *OllamaProvider) Review(
-	ctx context.Context,
-	r ReviewRequest,
-) (ReviewResponse, error) {
+func (o *OllamaProvider) request(ctx context.Context, c call, stream bool) (*http.Request, ollamaRequest, error) {
+
+	msgs, err := c.messages()
+	if err != nil {
+		return nil, ollamaRequest{}, err
+	}
+
+	model := o.model
+	if c.model != "" {
+		model = c.model
+	}
 
 	reqBody := ollamaRequest{
-		Model:  o.model,
-		Prompt: buildPrompt(r),
-		Stream: false,
+		Model: model,
+		Messages: []ollamaMessage{
+			{Role: "system", Content: msgs.System},
+			{Role: "user", Content: msgs.User},
+		},
+		Stream:    stream,
+		Format:    ollamaFormat(o.gen.Format, c.schema),
+		Options:   o.options(),
+		KeepAlive: o.opts.KeepAlive,
 	}
 
 	b, err := json.Marshal(reqBody)
 	if err != nil {
-		return ReviewResponse{}, fmt.Errorf("marshal ollama request: %w", err)
+		return nil, reqBody, fmt.Errorf("marshal ollama request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(
 		ctx,
 		"POST",
-		o.url+"/api/generate",
+		o.url+"/api/chat",
 		bytes.NewBuffer(b),
 	)
 	if err != nil {
-		return ReviewResponse{}, fmt.Errorf("build ollama request: %w", err)
+		return nil, reqBody, fmt.Errorf("build ollama request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := o.client.Do(req)
-	if err != nil {
-		return ReviewResponse{}, err
-	}
-	defer resp.Body.Close()
+	return req, reqBody, nil
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return ReviewResponse{}, fmt.Errorf("ollama status %d: %s", resp.StatusCode, string(msg))
+// response reports the server's token counts, estimating the ones it left
+// out.
+func (o *OllamaProvider) response(reqBody ollamaRequest, content string, final ollamaResponse) ReviewResponse {
+	usage := Usage{
+		PromptTokens:     final.PromptEvalCount,
+		CompletionTokens: final.EvalCount,
 	}
-
-	var out ollamaResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return ReviewResponse{}, fmt.Errorf("decode ollama response: %w", err)
+	count := tokenizer.Lookup(reqBody.Model).Tokenizer().Count
+	if usage.PromptTokens == 0 {
+		var prompt strings.Builder
+		for _, m := range reqBody.Messages {
+			prompt.WriteString(m.Content)
+		}
+		usage.PromptTokens = count(prompt.String())
+	}
+	if usage.CompletionTokens == 0 {
+		usage.CompletionTokens = count(content)
 	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
 
-	usage := estimateUsage(reqBody.Prompt, out.Response)
+	model := final.Model
+	if model == "" {
+		model = reqBody.Model
+	}
 
 	return ReviewResponse{
-		Content:  out.Response,
-		Provider: "ollama",
-		Model:    o.model,
+		Content:  content,
+		Provider: providerOllama,
+		Model:    model,
 		Usage:    usage,
-	}, nil
+		// Structured mirrors the "format" sent with the request.
+		Structured: o.Capabilities().StructuredOutput,
+	}
+}
+
+func (o *OllamaProvider) checkStatus(resp *http.Response, model string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode == http.StatusNotFound && strings.Contains(string(msg), "not found") {
+		return fmt.Errorf("%w: %s", ErrModelNotFound, model)
+	}
+	return &StatusError{Provider: providerOllama, StatusCode: resp.StatusCode, Body: string(msg)}
+}
+
+// EnsureModel checks that the model is available on the server and, when
+// pull is set, pulls it if it is missing. Pulling can take minutes.
+func (o *OllamaProvider) EnsureModel(ctx context.Context, pull bool) error {
+	err := o.post(ctx, "/api/show", map[string]any{"model": o.model})
+	if !errors.Is(err, ErrModelNotFound) || !pull {
+		return err
+	}
+
+	if err := o.post(ctx, "/api/pull", map[string]any{"model": o.model, "stream": false}); err != nil {
+		return fmt.Errorf("pull %s: %w", o.model, err)
+	}
+	return nil
+}
+
+func (o *OllamaProvider) post(ctx context.Context, path string, body map[string]any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.url+path, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// no client timeout: a pull is bounded by ctx only
+	res, err := streamClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if err := o.checkStatus(res, o.model); err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
 }
 
-func estimateUsage(prompt, completion string) Usage {
-	promptTokens := estimateTokens(prompt)
-	completionTokens := estimateTokens(completion)
-	return Usage{
-		PromptTokens:     promptTokens,
-		CompletionTokens: completionTokens,
-		TotalTokens:      promptTokens + completionTokens,
+// ollamaFormat maps the output format onto Ollama's "format" field, which
+// accepts either "json" or a JSON schema object.
+func ollamaFormat(f OutputFormat, schema map[string]any) any {
+	switch f {
+	case FormatJSONSchema:
+		return schema
+	case FormatJSONObject:
+		return "json"
+	default:
+		return nil
 	}
 }
 
-func estimateTokens(s string) int {
-	// Simple fallback estimate: ~4 chars/token for English-like text.
-	if len(s) == 0 {
-		return 0
+func (o *OllamaProvider) options() map[string]any {
+	opts := map[string]any{"temperature": o.gen.Temperature}
+	if o.gen.Seed != 0 {
+		opts["seed"] = o.gen.Seed
 	}
-	n := len(s) / 4
-	if n == 0 {
-		return 1
+	if o.opts.NumCtx > 0 {
+		opts["num_ctx"] = o.opts.NumCtx
 	}
-	return n
+	return opts
 }
@@ -7,54 +7,134 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"ai-code-reviewer/internal/tokenizer"
+)
+
+const (
+	openAIBaseURL = "https://api.openai.com/v1"
+
+	providerOpenAI           = "openai"
+	providerOllama           = "ollama"
+	providerAzureOpenAI      = "azure"
+	providerOpenAICompatible = "openai_compatible"
 )
 
+// OpenAI talks to the chat completions API. The same client serves OpenAI,
+// Azure OpenAI and self-hosted OpenAI-compatible servers; they differ only
+// in URL, authentication and whether the model is named in the body.
 type OpenAI struct {
 	Key    string
 	Model  string
+	gen    Generation
 	client *http.Client
+
+	name     string
+	endpoint string
+	auth     func(*http.Request)
+	// deployment builds the endpoint for another Azure deployment when a
+	// request overrides the model.
+	deployment func(name string) string
+}
+
+func NewOpenAI(key, model string, gen Generation) *OpenAI {
+	o := newChatCompletions(providerOpenAI, openAIBaseURL+"/chat/completions", model, gen)
+	o.Key = key
+	o.auth = o.bearer
+	return o
 }
 
-func NewOpenAI(key, model string) *OpenAI {
+// NewOpenAICompatible talks to any server implementing the OpenAI chat
+// completions API, e.g. vLLM, LM Studio or the llama.cpp server. baseURL
+// ends before "/chat/completions" (".../v1"); key may be empty.
+func NewOpenAICompatible(baseURL, key, model string, gen Generation) *OpenAI {
+	o := newChatCompletions(providerOpenAICompatible, strings.TrimRight(baseURL, "/")+"/chat/completions", model, gen)
+	o.Key = key
+	o.auth = o.bearer
+	return o
+}
+
+// NewAzureOpenAI talks to an Azure OpenAI deployment. The deployment picks
+// the model, so none is sent in the body.
+func NewAzureOpenAI(endpoint, deployment, apiVersion, key string, gen Generation) *OpenAI {
+	deploymentURL := func(name string) string {
+		return fmt.Sprintf(
+			"%s/openai/deployments/%s/chat/completions?api-version=%s",
+			strings.TrimRight(endpoint, "/"), name, apiVersion,
+		)
+	}
+
+	o := newChatCompletions(providerAzureOpenAI, deploymentURL(deployment), "", gen)
+	o.Key = key
+	o.deployment = deploymentURL
+	o.auth = func(req *http.Request) {
+		req.Header.Set("api-key", o.Key)
+	}
+	return o
+}
+
+func newChatCompletions(name, endpoint, model string, gen Generation) *OpenAI {
 	return &OpenAI{
-		Key:   key,
-		Model: model,
+		Model:    model,
+		gen:      gen,
+		name:     name,
+		endpoint: endpoint,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
 }
 
-func (o *OpenAI) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+func (o *OpenAI) bearer(req *http.Request) {
+	if o.Key != "" {
+		req.Header.Set("Authorization", "Bearer "+o.Key)
+	}
+}
 
-	prompt := BuildPrompt(r)
+// Capabilities reports structured output when the provider is configured
+// for it; a response is only Structured when its model accepted the schema
+// (see format).
+func (o *OpenAI) Capabilities() Capabilities {
+	return o.gen.capabilities()
+}
 
-	body := map[string]any{
-		"model": o.Model,
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": prompt},
-		},
+// format is the output format requested from model. OpenAI rejects a
+// json_schema response_format for models that predate it, such as
+// gpt-3.5-turbo, so those and unknown models get json_object instead.
+// Azure deployment and self-hosted model names say nothing reliable about
+// the model, so those keep the configured format.
+func (o *OpenAI) format(model string) OutputFormat {
+	if o.name == providerOpenAI && o.gen.Format == FormatJSONSchema && !tokenizer.Lookup(model).JSONSchema {
+		return FormatJSONObject
 	}
+	return o.gen.Format
+}
 
-	b, err := json.Marshal(body)
-	if err != nil {
-		return ReviewResponse{}, fmt.Errorf("marshal openai request: %w", err)
-	}
+func (o *OpenAI) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	return o.complete(ctx, r.call())
+}
 
-	req, err := http.NewRequestWithContext(
-		ctx,
-		"POST",
-		"https://api.openai.com/v1/chat/completions",
-		bytes.NewReader(b),
-	)
+func (o *OpenAI) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	return o.complete(ctx, r.call())
+}
+
+func (o *OpenAI) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	return o.complete(ctx, r.call())
+}
+
+func (o *OpenAI) SuggestTests(ctx context.Context, r TestRequest) (ReviewResponse, error) {
+	return o.complete(ctx, r.call())
+}
+
+func (o *OpenAI) complete(ctx context.Context, c call) (ReviewResponse, error) {
+
+	req, model, err := o.request(ctx, c, false)
 	if err != nil {
-		return ReviewResponse{}, fmt.Errorf("build openai request: %w", err)
+		return ReviewResponse{}, err
 	}
-
-	req.Header.Set("Authorization", "Bearer "+o.Key)
-	req.Header.Set("Content-Type", "application/json")
+	structured := o.format(model) == FormatJSONSchema
 
 	res, err := o.client.Do(req)
 	if err != nil {
@@ -62,9 +142,8 @@ func (o *OpenAI) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, e
 	}
 	defer res.Body.Close()
 
-	if res.StatusCode >= 300 {
-		b, _ := io.ReadAll(res.Body)
-		return ReviewResponse{}, fmt.Errorf("openai status %d: %s", res.StatusCode, string(b))
+	if err := o.checkStatus(res); err != nil {
+		return ReviewResponse{}, err
 	}
 
 	var out struct {
@@ -74,11 +153,7 @@ func (o *OpenAI) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, e
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
-		Usage struct {
-			PromptTokens     int `json:"prompt_tokens"`
-			CompletionTokens int `json:"completion_tokens"`
-			TotalTokens      int `json:"total_tokens"`
-		} `json:"usage"`
+		Usage openAIUsage `json:"usage"`
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
@@ -89,14 +164,187 @@ func (o *OpenAI) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, e
 		return ReviewResponse{}, fmt.Errorf("no response")
 	}
 
+	// local servers often echo nothing useful
+	if out.Model != "" {
+		model = out.Model
+	}
+
 	return ReviewResponse{
-		Content:  out.Choices[0].Message.Content,
-		Provider: "openai",
-		Model:    out.Model,
-		Usage: Usage{
-			PromptTokens:     out.Usage.PromptTokens,
-			CompletionTokens: out.Usage.CompletionTokens,
-			TotalTokens:      out.Usage.TotalTokens,
-		},
+		Content:    out.Choices[0].Message.Content,
+		Provider:   o.name,
+		Model:      model,
+		Usage:      out.Usage.usage(),
+		Structured: structured,
 	}, nil
 }
+
+// ReviewStream streams the completion as server-sent events. Usage is
+// requested in the final event; servers that omit it report zero tokens.
+func (o *OpenAI) ReviewStream(ctx context.Context, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+
+	req, model, err := o.request(ctx, r.call(), true)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+
+	res, err := streamClient.Do(req)
+	if err != nil {
+		return ReviewResponse{}, err
+	}
+	defer res.Body.Close()
+
+	if err := o.checkStatus(res); err != nil {
+		return ReviewResponse{}, err
+	}
+
+	resp := ReviewResponse{
+		Provider:   o.name,
+		Model:      model,
+		Structured: o.format(model) == FormatJSONSchema,
+	}
+	var content strings.Builder
+
+	err = readSSE(res.Body, func(data []byte) error {
+		if string(data) == "[DONE]" {
+			return errStreamDone
+		}
+
+		var ev struct {
+			Model   string `json:"model"`
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal(data, &ev); err != nil {
+			return fmt.Errorf("decode %s stream: %w", o.name, err)
+		}
+
+		if ev.Model != "" {
+			resp.Model = ev.Model
+		}
+		if ev.Usage != nil {
+			resp.Usage = ev.Usage.usage()
+		}
+		for _, c := range ev.Choices {
+			if c.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(c.Delta.Content)
+			if err := onDelta(c.Delta.Content); err != nil {
+				return abortStream(err)
+			}
+		}
+		return nil
+	})
+
+	resp.Content = content.String()
+	if err != nil {
+		return resp, err
+	}
+	if resp.Content == "" {
+		return resp, fmt.Errorf("no response")
+	}
+	return resp, nil
+}
+
+// request builds the chat completions call and returns the model it asks
+// for, which is empty for Azure unless the request overrides it.
+func (o *OpenAI) request(ctx context.Context, c call, stream bool) (*http.Request, string, error) {
+
+	msgs, err := c.messages()
+	if err != nil {
+		return nil, "", err
+	}
+
+	endpoint, model := o.endpoint, o.Model
+	if c.model != "" {
+		if o.deployment != nil {
+			endpoint = o.deployment(c.model)
+		}
+		model = c.model
+	}
+
+	body := map[string]any{
+		"messages": []map[string]string{
+			{"role": "system", "content": msgs.System},
+			{"role": "user", "content": msgs.User},
+		},
+		"temperature": o.gen.Temperature,
+	}
+	if model != "" && o.deployment == nil {
+		body["model"] = model
+	}
+	if o.gen.Seed != 0 {
+		body["seed"] = o.gen.Seed
+	}
+	if rf := openAIResponseFormat(o.format(model), c.schema); rf != nil {
+		body["response_format"] = rf
+	}
+	if stream {
+		body["stream"] = true
+		body["stream_options"] = map[string]any{"include_usage": true}
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshal openai request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx,
+		"POST",
+		endpoint,
+		bytes.NewReader(b),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("build openai request: %w", err)
+	}
+
+	o.auth(req)
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, model, nil
+}
+
+func (o *OpenAI) checkStatus(res *http.Response) error {
+	if res.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return &StatusError{Provider: o.name, StatusCode: res.StatusCode, Body: string(b)}
+	}
+	return nil
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u openAIUsage) usage() Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+	}
+}
+
+func openAIResponseFormat(f OutputFormat, schema map[string]any) map[string]any {
+	switch f {
+	case FormatJSONSchema:
+		return map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   "code_review",
+				"strict": true,
+				"schema": schema,
+			},
+		}
+	case FormatJSONObject:
+		return map[string]any{"type": "json_object"}
+	default:
+		return nil
+	}
+}
@@ -1,58 +1,96 @@
 package ai
 
-var systemPrompt = `You are a senior Go reviewer.
-
-Return STRICT JSON only using this schema:
-{
-  "issues": [
-    {
-      "line": 12,
-      "severity": "high|medium|low",
-      "title": "short description",
-      "suggestion": "how to fix"
-    }
-  ]
-}
-
-No markdown.
-No prose.
-`
-
-func buildPrompt(r ReviewRequest) string {
-
-	return `
-File: ` + r.File + `
-
-Changes:
-` + r.Content + `
+import (
+	"ai-code-reviewer/internal/prompt"
+	"ai-code-reviewer/internal/review"
+)
 
-Provide a concise but deep review.`
+// call is what a backend sends for any task: the prompt input, the JSON
+// schema the response must follow and the model that should answer.
+type call struct {
+	input  prompt.Input
+	schema map[string]any
+	model  string
 }
 
-func BuildPrompt(r ReviewRequest) string {
-
-	return `
-You are a senior Go reviewer.
+// messages renders the shared prompt templates so every provider sends the
+// same instructions and schema.
+func (c call) messages() (prompt.Messages, error) {
+	return prompt.Build(c.input)
+}
 
-Analyze this code and return STRICT JSON ONLY.
+// call uses the multi-file schema for batches and the single-file one
+// otherwise.
+func (r ReviewRequest) call() call {
+	in := prompt.Input{
+		Task:         prompt.TaskReview,
+		File:         r.File,
+		Content:      r.Content,
+		Instructions: r.Instructions,
+		PR:           r.PR,
+		Surrounding:  r.Surrounding,
+		Hints:        r.Hints,
+	}
+	for _, f := range r.Files {
+		in.Files = append(in.Files, prompt.FileInput{
+			File:        f.File,
+			Content:     f.Content,
+			Surrounding: f.Surrounding,
+			Hints:       f.Hints,
+		})
+	}
+	if r.Correction != nil {
+		in.Correction = &prompt.Correction{
+			Previous: r.Correction.Previous,
+			Problem:  r.Correction.Problem,
+		}
+	}
 
-Format:
+	schema := review.JSONSchema()
+	if r.IsBatch() {
+		schema = review.BatchJSONSchema(r.Paths())
+	}
+	return call{input: in, schema: schema, model: r.Model}
+}
 
-{
-  "issues": [
-    {
-      "line": 12,
-      "severity": "high|medium|low",
-      "title": "short description",
-      "suggestion": "how to fix"
-    }
-  ]
+func (r VerifyRequest) call() call {
+	return call{
+		input: prompt.Input{
+			Task:         prompt.TaskVerify,
+			File:         r.File,
+			Content:      r.Content,
+			Instructions: r.Instructions,
+			Surrounding:  r.Surrounding,
+			Issue:        &r.Issue,
+		},
+		schema: review.VerdictJSONSchema(),
+		model:  r.Model,
+	}
 }
 
-NO markdown.
-NO explanation.
-ONLY valid JSON.
+func (r SummaryRequest) call() call {
+	return call{
+		input: prompt.Input{
+			Task:         prompt.TaskSummary,
+			Instructions: r.Instructions,
+			PR:           r.PR,
+			Findings:     r.Findings,
+			Diffstat:     r.Diffstat,
+		},
+		schema: review.NarrativeJSONSchema(),
+	}
+}
 
-Code:
-` + r.Content
+func (r TestRequest) call() call {
+	return call{
+		input: prompt.Input{
+			Task:         prompt.TaskTests,
+			File:         r.File,
+			Package:      r.Package,
+			Content:      r.Content,
+			Instructions: r.Instructions,
+		},
+		schema: review.TestFileJSONSchema(),
+		model:  r.Model,
+	}
 }
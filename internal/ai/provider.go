@@ -1,10 +1,107 @@
 package ai
 
-import "context"
+import (
+	"context"
 
+	"ai-code-reviewer/internal/prompt"
+	"ai-code-reviewer/internal/review"
+)
+
+// ReviewRequest asks for the issues in a chunk of one file, or in a batch
+// of small files.
 type ReviewRequest struct {
 	File    string
 	Content string
+	// Instructions are repository-specific review rules from the repo config.
+	Instructions string
+	// PR is the pull request the chunk belongs to, already fitted to the
+	// PR context token budget.
+	PR *prompt.PRContext
+	// Surrounding is head-version code around the chunk's hunks.
+	Surrounding string
+	// HunkStarts are the new-file lines where the chunk's hunks start. The
+	// model reads line numbers off Surrounding, so a review only applies
+	// to hunks at the same place.
+	HunkStarts []int
+	// Files batches several small files into one call; File, Content and
+	// Surrounding are unused when it is set.
+	Files      []FileChunk
+	Correction *Correction
+	Target
+	// Hints are static analysis findings in File the model should not
+	// report again.
+	Hints []review.Issue
+}
+
+// VerifyRequest asks whether Issue, found in the chunk of File, is a real
+// problem.
+type VerifyRequest struct {
+	File         string
+	Content      string
+	Surrounding  string
+	Instructions string
+	Issue        review.Issue
+	Target
+}
+
+// SummaryRequest asks for a narrative of the whole PR. It has no chunk,
+// so it is neither routed nor cached.
+type SummaryRequest struct {
+	Instructions string
+	PR           *prompt.PRContext
+	Findings     []prompt.Finding
+	Diffstat     []prompt.FileStat
+}
+
+// TestRequest asks for a _test.go file covering the Go functions in
+// Content, which belong to File in Package.
+type TestRequest struct {
+	File         string
+	Package      string
+	Content      string
+	Instructions string
+	Target
+}
+
+// Target overrides the configured model of a request. It is set by model
+// routing or by the caller; Provider names a chain member.
+type Target struct {
+	Provider string
+	Model    string
+}
+
+// FileChunk is one file of a batched request.
+type FileChunk struct {
+	File        string
+	Content     string
+	Surrounding string
+	HunkStarts  []int
+	Hints       []review.Issue
+}
+
+// IsBatch reports whether the request covers several files and expects
+// the multi-file response schema.
+func (r ReviewRequest) IsBatch() bool {
+	return len(r.Files) > 0
+}
+
+// Paths lists the files the request covers.
+func (r ReviewRequest) Paths() []string {
+	if !r.IsBatch() {
+		return []string{r.File}
+	}
+	paths := make([]string, 0, len(r.Files))
+	for _, f := range r.Files {
+		paths = append(paths, f.File)
+	}
+	return paths
+}
+
+// Correction asks the model to fix a previous response that could not be
+// parsed or failed schema validation.
+type Correction struct {
+	Previous string
+	Problem  string
 }
 
 type Usage struct {
@@ -18,9 +115,24 @@ type ReviewResponse struct {
 	Provider string
 	Model    string
 	Usage    Usage
+	// Structured reports that Content was produced under the review JSON
+	// schema (see Capabilities).
+	Structured bool
+	// Route is the model routing rule that picked the model, if any.
+	Route string
+	// Cached reports that the response was replayed from the response
+	// cache; Usage is zero and nothing was billed.
+	Cached bool
 }
 
+// Provider has a method for each task, so every provider and wrapper says
+// how it handles each one: a task added later cannot pass through a cache
+// or redactor that does not know its fields.
+//
 //go:generate mockery --name Provider --output ../mocks --with-expecter
 type Provider interface {
 	Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error)
+	Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error)
+	Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error)
+	SuggestTests(ctx context.Context, r TestRequest) (ReviewResponse, error)
 }
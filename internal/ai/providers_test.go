@@ -0,0 +1,387 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/prompt"
+	"ai-code-reviewer/internal/review"
+	"ai-code-reviewer/internal/tokenizer"
+
+	"github.com/stretchr/testify/require"
+)
+
+const chatCompletionBody = `{"model":"","choices":[{"message":{"content":"{\"issues\":[]}"}}],` +
+	`"usage":{"prompt_tokens":120,"completion_tokens":30,"total_tokens":150}}`
+
+func TestAzureOpenAI_Request(t *testing.T) {
+	var got *http.Request
+	var body map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(chatCompletionBody))
+	}))
+	defer srv.Close()
+
+	p := NewAzureOpenAI(srv.URL+"/", "reviewer", "2024-10-21", "secret", Generation{Format: FormatJSONSchema})
+	resp, err := p.Review(context.Background(), ReviewRequest{File: "main.go", Content: "+x"})
+	require.NoError(t, err)
+
+	require.Equal(t, "/openai/deployments/reviewer/chat/completions", got.URL.Path)
+	require.Equal(t, "2024-10-21", got.URL.Query().Get("api-version"))
+	require.Equal(t, "secret", got.Header.Get("api-key"))
+	require.Empty(t, got.Header.Get("Authorization"))
+	require.NotContains(t, body, "model")
+	require.Contains(t, body, "response_format")
+
+	require.Equal(t, providerAzureOpenAI, resp.Provider)
+	require.Equal(t, 150, resp.Usage.TotalTokens)
+	require.True(t, resp.Structured)
+}
+
+func TestOpenAI_ResponseFormatFollowsModel(t *testing.T) {
+	// empty values fall back to the defaults
+	t.Setenv("AI_PROVIDER", "")
+	t.Setenv("OPENAI_MODEL", "")
+	t.Setenv("AI_RESPONSE_FORMAT", "")
+	cfg := config.Load()
+
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(chatCompletionBody))
+	}))
+	defer srv.Close()
+
+	p := newBackend(cfg.AIProvider, cfg).(*OpenAI)
+	p.endpoint = srv.URL
+
+	tests := []struct {
+		model      string
+		format     string
+		structured bool
+	}{
+		{model: "", format: "json_object"}, // the default model
+		{model: "gpt-4", format: "json_object"},
+		{model: "ft:custom-model", format: "json_object"},
+		{model: "gpt-4o-mini", format: "json_schema", structured: true},
+	}
+
+	for _, tt := range tests {
+		resp, err := p.Review(context.Background(), ReviewRequest{File: "main.go", Content: "+x", Target: Target{Model: tt.model}})
+		require.NoError(t, err)
+		require.Equal(t, tt.format, body["response_format"].(map[string]any)["type"], tt.model)
+		require.Equal(t, tt.structured, resp.Structured, tt.model)
+	}
+}
+
+func TestOpenAICompatible_Request(t *testing.T) {
+	var got *http.Request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		_, _ = w.Write([]byte(chatCompletionBody))
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompatible(srv.URL+"/v1", "", "qwen2.5-coder", Generation{Format: FormatText})
+	resp, err := p.Review(context.Background(), ReviewRequest{File: "main.go", Content: "+x"})
+	require.NoError(t, err)
+
+	require.Equal(t, "/v1/chat/completions", got.URL.Path)
+	require.Empty(t, got.Header.Get("Authorization"))
+	require.Equal(t, providerOpenAICompatible, resp.Provider)
+	// the server did not echo a model, so the configured one is reported
+	require.Equal(t, "qwen2.5-coder", resp.Model)
+	require.Equal(t, 120, resp.Usage.PromptTokens)
+	require.False(t, resp.Structured)
+}
+
+func TestOpenAICompatible_EachTaskSendsItsSchema(t *testing.T) {
+	var body struct {
+		ResponseFormat struct {
+			JSONSchema struct {
+				Schema json.RawMessage `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(chatCompletionBody))
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompatible(srv.URL, "", "qwen2.5-coder", Generation{Format: FormatJSONSchema})
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		call   func() (ReviewResponse, error)
+		schema map[string]any
+	}{
+		{name: "review", schema: review.JSONSchema(), call: func() (ReviewResponse, error) {
+			return p.Review(ctx, ReviewRequest{File: "main.go", Content: "+x"})
+		}},
+		{name: "verify", schema: review.VerdictJSONSchema(), call: func() (ReviewResponse, error) {
+			return p.Verify(ctx, VerifyRequest{File: "main.go", Content: "+x", Issue: review.Issue{Line: 1, Title: "unused x"}})
+		}},
+		{name: "summary", schema: review.NarrativeJSONSchema(), call: func() (ReviewResponse, error) {
+			return p.Summarize(ctx, SummaryRequest{Diffstat: []prompt.FileStat{{File: "main.go", Status: "modified", Additions: 1}}})
+		}},
+		{name: "tests", schema: review.TestFileJSONSchema(), call: func() (ReviewResponse, error) {
+			return p.SuggestTests(ctx, TestRequest{File: "main.go", Package: "main", Content: "func Add(a, b int) int { return a + b }"})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.call()
+			require.NoError(t, err)
+
+			want, err := json.Marshal(tt.schema)
+			require.NoError(t, err)
+			require.JSONEq(t, string(want), string(body.ResponseFormat.JSONSchema.Schema))
+		})
+	}
+}
+
+func TestAnthropic_ToolUseResponse(t *testing.T) {
+	var body anthropicRequest
+	var got *http.Request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{"model":"claude-sonnet-4-5-20250929","content":[` +
+			`{"type":"text","text":"Reviewing."},` +
+			`{"type":"tool_use","name":"submit_review","input":{"version":2,"issues":[]}}],` +
+			`"usage":{"input_tokens":200,"output_tokens":40}}`))
+	}))
+	defer srv.Close()
+
+	p := NewAnthropic("secret", "claude-sonnet-4-5", 1024, Generation{Format: FormatJSONSchema})
+	p.endpoint = srv.URL
+
+	resp, err := p.Review(context.Background(), ReviewRequest{File: "main.go", Content: "+x"})
+	require.NoError(t, err)
+
+	require.Equal(t, "secret", got.Header.Get("x-api-key"))
+	require.Equal(t, anthropicVersion, got.Header.Get("anthropic-version"))
+	require.Equal(t, 1024, body.MaxTokens)
+	require.NotEmpty(t, body.System)
+	require.Len(t, body.Tools, 1)
+	require.Equal(t, anthropicReviewTool, body.ToolChoice["name"])
+
+	require.JSONEq(t, `{"version":2,"issues":[]}`, resp.Content)
+	require.True(t, resp.Structured)
+	require.Equal(t, Usage{PromptTokens: 200, CompletionTokens: 40, TotalTokens: 240}, resp.Usage)
+}
+
+func TestAnthropic_TextResponseIsUnstructured(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"model":"claude-haiku-4-5","content":[{"type":"text","text":"{\"issues\":[]}"}],` +
+			`"usage":{"input_tokens":10,"output_tokens":5}}`))
+	}))
+	defer srv.Close()
+
+	p := NewAnthropic("secret", "claude-haiku-4-5", 1024, Generation{Format: FormatText})
+	p.endpoint = srv.URL
+
+	resp, err := p.Review(context.Background(), ReviewRequest{File: "main.go", Content: "+x"})
+	require.NoError(t, err)
+	require.Equal(t, `{"issues":[]}`, resp.Content)
+	require.False(t, resp.Structured)
+}
+
+func TestOpenAI_ReviewStream(t *testing.T) {
+	var body map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(
+			"data: {\"model\":\"gpt-4o-2024-08-06\",\"choices\":[{\"delta\":{\"content\":\"{\\\"issues\\\":\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"[]}\"}}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":50,\"completion_tokens\":5,\"total_tokens\":55}}\n\n" +
+				"data: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompatible(srv.URL, "", "gpt-4o", Generation{Format: FormatJSONSchema})
+
+	var deltas []string
+	resp, err := p.ReviewStream(context.Background(), ReviewRequest{File: "main.go", Content: "+x"}, func(d string) error {
+		deltas = append(deltas, d)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, true, body["stream"])
+	require.Equal(t, []string{`{"issues":`, `[]}`}, deltas)
+	require.Equal(t, `{"issues":[]}`, resp.Content)
+	require.Equal(t, "gpt-4o-2024-08-06", resp.Model)
+	require.Equal(t, 55, resp.Usage.TotalTokens)
+}
+
+func TestOpenAI_ReviewStreamAborted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(
+			"data: {\"choices\":[{\"delta\":{\"content\":\"{\\\"issues\\\":\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"[]}\"}}]}\n\n"))
+	}))
+	defer srv.Close()
+
+	p := NewOpenAICompatible(srv.URL, "", "gpt-4o", Generation{})
+	stop := errors.New("over budget")
+
+	resp, err := p.ReviewStream(context.Background(), ReviewRequest{File: "main.go", Content: "+x"}, func(string) error {
+		return stop
+	})
+	require.ErrorIs(t, err, ErrStreamAborted)
+	require.ErrorIs(t, err, stop)
+	require.Equal(t, ClassCanceled, Classify(err))
+	require.Equal(t, `{"issues":`, resp.Content)
+}
+
+func TestAnthropic_ReviewStreamToolInput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(
+			"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"model\":\"claude-sonnet-4-5\",\"usage\":{\"input_tokens\":90}}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"issues\\\"\"}}\n\n" +
+				"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\":[]}\"}}\n\n" +
+				"event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":12}}\n\n" +
+				"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer srv.Close()
+
+	p := NewAnthropic("secret", "claude-sonnet-4-5", 1024, Generation{Format: FormatJSONSchema})
+	p.endpoint = srv.URL
+
+	var streamed strings.Builder
+	resp, err := p.ReviewStream(context.Background(), ReviewRequest{File: "main.go", Content: "+x"}, func(d string) error {
+		streamed.WriteString(d)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, `{"issues":[]}`, streamed.String())
+	require.Equal(t, `{"issues":[]}`, resp.Content)
+	require.True(t, resp.Structured)
+	require.Equal(t, Usage{PromptTokens: 90, CompletionTokens: 12, TotalTokens: 102}, resp.Usage)
+}
+
+func TestOllama_ChatRequestAndUsage(t *testing.T) {
+	var got *http.Request
+	var body ollamaRequest
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		_, _ = w.Write([]byte(`{"model":"llama3.1:8b","message":{"role":"assistant","content":"{\"issues\":[]}"},` +
+			`"done":true,"prompt_eval_count":310,"eval_count":12}`))
+	}))
+	defer srv.Close()
+
+	p := NewOllama(srv.URL+"/", "llama3.1:8b", OllamaOptions{NumCtx: 16384, KeepAlive: "30m"}, Generation{Temperature: 0.2})
+
+	resp, err := p.Review(context.Background(), ReviewRequest{File: "main.go", Content: "+x"})
+	require.NoError(t, err)
+
+	require.Equal(t, "/api/chat", got.URL.Path)
+	require.Len(t, body.Messages, 2)
+	require.Equal(t, "system", body.Messages[0].Role)
+	require.Equal(t, "30m", body.KeepAlive)
+	require.EqualValues(t, 16384, body.Options["num_ctx"])
+	require.EqualValues(t, 0.2, body.Options["temperature"])
+
+	require.Equal(t, providerOllama, resp.Provider)
+	require.Equal(t, "llama3.1:8b", resp.Model)
+	require.Equal(t, Usage{PromptTokens: 310, CompletionTokens: 12, TotalTokens: 322}, resp.Usage)
+}
+
+func TestOllama_MissingCountsUseModelTokenizer(t *testing.T) {
+	var body ollamaRequest
+	content := `{"issues":[{"line":1,"severity":"low","title":"naming"}]}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		out, _ := json.Marshal(map[string]any{"message": map[string]string{"role": "assistant", "content": content}, "done": true})
+		_, _ = w.Write(out)
+	}))
+	defer srv.Close()
+
+	p := NewOllama(srv.URL, "llama3.1:8b", OllamaOptions{}, Generation{})
+	resp, err := p.Review(context.Background(), ReviewRequest{File: "main.go", Content: "+x"})
+	require.NoError(t, err)
+
+	count := tokenizer.Lookup("llama3.1:8b").Tokenizer().Count
+	require.Equal(t, count(body.Messages[0].Content+body.Messages[1].Content), resp.Usage.PromptTokens)
+	require.Equal(t, count(content), resp.Usage.CompletionTokens)
+}
+
+func TestOllama_ModelNotFoundFallsBack(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"model 'qwen2.5-coder' not found, try pulling it first"}`))
+	}))
+	defer srv.Close()
+
+	p := NewOllama(srv.URL, "qwen2.5-coder", OllamaOptions{}, Generation{})
+
+	_, err := p.Review(context.Background(), ReviewRequest{File: "main.go", Content: "+x"})
+	require.ErrorIs(t, err, ErrModelNotFound)
+	require.Equal(t, ClassTransient, Classify(err))
+}
+
+func TestOllama_EnsureModelPullsMissing(t *testing.T) {
+	var paths []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.URL.Path == "/api/show" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"model 'llama3' not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success"}`))
+	}))
+	defer srv.Close()
+
+	p := NewOllama(srv.URL, "llama3", OllamaOptions{}, Generation{})
+
+	require.ErrorIs(t, p.EnsureModel(context.Background(), false), ErrModelNotFound)
+	require.NoError(t, p.EnsureModel(context.Background(), true))
+	require.Equal(t, []string{"/api/show", "/api/show", "/api/pull"}, paths)
+}
+
+func TestOllama_ReviewStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(
+			"{\"message\":{\"content\":\"{\\\"issues\\\":\"},\"done\":false}\n" +
+				"{\"message\":{\"content\":\"[]}\"},\"done\":false}\n" +
+				"{\"message\":{\"content\":\"\"},\"done\":true,\"prompt_eval_count\":40,\"eval_count\":6}\n"))
+	}))
+	defer srv.Close()
+
+	p := NewOllama(srv.URL, "llama3", OllamaOptions{}, Generation{Format: FormatJSONObject})
+
+	var n int
+	resp, err := p.ReviewStream(context.Background(), ReviewRequest{File: "main.go", Content: "+x"}, func(string) error {
+		n++
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, `{"issues":[]}`, resp.Content)
+	require.Equal(t, "llama3", resp.Model)
+	require.Equal(t, 46, resp.Usage.TotalTokens)
+}
@@ -0,0 +1,234 @@
+package ai
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/prompt"
+	"ai-code-reviewer/internal/redact"
+	"ai-code-reviewer/internal/review"
+)
+
+// Redactor sends a provider only redacted requests: personal data and
+// credentials are replaced with placeholders, which are put back in the
+// response. Every AI_EXTERNAL_PROVIDERS member of the chain is wrapped in
+// one, so those providers never see the raw code.
+type Redactor struct {
+	name     string
+	next     Provider
+	redactor *redact.Redactor
+}
+
+func NewRedactor(name string, next Provider, r *redact.Redactor) *Redactor {
+	return &Redactor{name: name, next: next, redactor: r}
+}
+
+func (r *Redactor) Review(ctx context.Context, req ReviewRequest) (ReviewResponse, error) {
+	s := r.redactor.Session()
+	req = redactReview(s, req)
+	return r.send(ctx, s, func() (ReviewResponse, error) {
+		return r.next.Review(ctx, req)
+	})
+}
+
+// ReviewStream restores placeholders in the deltas before passing them on.
+func (r *Redactor) ReviewStream(ctx context.Context, req ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+	s := r.redactor.Session()
+	req = redactReview(s, req)
+	r.record(ctx, s)
+	restorer := s.Restorer()
+
+	resp, err := Stream(ctx, r.next, req, func(delta string) error {
+		if out := restorer.Feed(delta); out != "" {
+			return onDelta(out)
+		}
+		return nil
+	})
+	resp.Content = s.Restore(resp.Content)
+	if err != nil {
+		return resp, err
+	}
+
+	if out := restorer.Flush(); out != "" {
+		if err := onDelta(out); err != nil {
+			return resp, abortStream(err)
+		}
+	}
+	return resp, nil
+}
+
+func (r *Redactor) Verify(ctx context.Context, req VerifyRequest) (ReviewResponse, error) {
+	s := r.redactor.Session()
+	req.Content = s.Redact(req.Content)
+	req.Surrounding = s.Redact(req.Surrounding)
+	req.Instructions = s.Redact(req.Instructions)
+	req.Issue = redactIssue(s, req.Issue)
+	return r.send(ctx, s, func() (ReviewResponse, error) {
+		return r.next.Verify(ctx, req)
+	})
+}
+
+func (r *Redactor) Summarize(ctx context.Context, req SummaryRequest) (ReviewResponse, error) {
+	s := r.redactor.Session()
+	req.Instructions = s.Redact(req.Instructions)
+	req.PR = redactPR(s, req.PR)
+	if req.Findings != nil {
+		findings := make([]prompt.Finding, len(req.Findings))
+		for i, f := range req.Findings {
+			findings[i] = prompt.Finding{File: f.File, Issue: redactIssue(s, f.Issue)}
+		}
+		req.Findings = findings
+	}
+	return r.send(ctx, s, func() (ReviewResponse, error) {
+		return r.next.Summarize(ctx, req)
+	})
+}
+
+func (r *Redactor) SuggestTests(ctx context.Context, req TestRequest) (ReviewResponse, error) {
+	s := r.redactor.Session()
+	req.Content = s.Redact(req.Content)
+	req.Instructions = s.Redact(req.Instructions)
+	return r.send(ctx, s, func() (ReviewResponse, error) {
+		return r.next.SuggestTests(ctx, req)
+	})
+}
+
+func (r *Redactor) Capabilities() Capabilities {
+	return CapabilitiesOf(r.next)
+}
+
+// send records what s replaced, makes the call and restores its response.
+func (r *Redactor) send(ctx context.Context, s *redact.Session, call func() (ReviewResponse, error)) (ReviewResponse, error) {
+	r.record(ctx, s)
+	resp, err := call()
+	resp.Content = s.Restore(resp.Content)
+	return resp, err
+}
+
+// record counts the redactions of s in the metrics and the job's audit.
+func (r *Redactor) record(ctx context.Context, s *redact.Session) {
+	counts := s.Counts()
+	for rule, n := range counts {
+		observability.AIRedactions.WithLabelValues(r.name, rule).Add(float64(n))
+	}
+	if a, ok := ctx.Value(auditKey{}).(*RedactionAudit); ok {
+		a.record(r.name, counts)
+	}
+}
+
+// redactReview returns a copy of req with every text the prompt renders
+// redacted.
+func redactReview(s *redact.Session, req ReviewRequest) ReviewRequest {
+	req.Content = s.Redact(req.Content)
+	req.Surrounding = s.Redact(req.Surrounding)
+	req.Instructions = s.Redact(req.Instructions)
+	req.Hints = redactIssues(s, req.Hints)
+
+	files := make([]FileChunk, len(req.Files))
+	for i, f := range req.Files {
+		f.Content = s.Redact(f.Content)
+		f.Surrounding = s.Redact(f.Surrounding)
+		f.Hints = redactIssues(s, f.Hints)
+		files[i] = f
+	}
+	if req.Files != nil {
+		req.Files = files
+	}
+
+	req.PR = redactPR(s, req.PR)
+	if req.Correction != nil {
+		req.Correction = &Correction{
+			Previous: s.Redact(req.Correction.Previous),
+			Problem:  s.Redact(req.Correction.Problem),
+		}
+	}
+	return req
+}
+
+func redactPR(s *redact.Session, pr *prompt.PRContext) *prompt.PRContext {
+	if pr == nil {
+		return nil
+	}
+	out := *pr
+	out.Title = s.Redact(out.Title)
+	out.Body = s.Redact(out.Body)
+	out.LinkedIssues = slices.Clone(out.LinkedIssues)
+	for i := range out.LinkedIssues {
+		out.LinkedIssues[i].Title = s.Redact(out.LinkedIssues[i].Title)
+		out.LinkedIssues[i].Body = s.Redact(out.LinkedIssues[i].Body)
+	}
+	return &out
+}
+
+func redactIssues(s *redact.Session, issues []review.Issue) []review.Issue {
+	if issues == nil {
+		return nil
+	}
+	out := make([]review.Issue, len(issues))
+	for i, is := range issues {
+		out[i] = redactIssue(s, is)
+	}
+	return out
+}
+
+func redactIssue(s *redact.Session, is review.Issue) review.Issue {
+	is.Title = s.Redact(is.Title)
+	is.Suggestion = s.Redact(is.Suggestion)
+	is.Rationale = s.Redact(is.Rationale)
+	return is
+}
+
+// Redaction is one line of a RedactionAudit: how many distinct values a
+// rule replaced in the requests sent to a provider.
+type Redaction struct {
+	Provider string `json:"provider"`
+	Rule     string `json:"rule"`
+	Values   int    `json:"values"`
+}
+
+// RedactionAudit collects the redactions of one job. Attach it to the
+// job's context with WithRedactionAudit.
+type RedactionAudit struct {
+	mu     sync.Mutex
+	counts map[[2]string]int
+}
+
+type auditKey struct{}
+
+func NewRedactionAudit() *RedactionAudit {
+	return &RedactionAudit{counts: make(map[[2]string]int)}
+}
+
+// WithRedactionAudit makes every Redactor called with ctx record into a.
+func WithRedactionAudit(ctx context.Context, a *RedactionAudit) context.Context {
+	return context.WithValue(ctx, auditKey{}, a)
+}
+
+func (a *RedactionAudit) record(provider string, counts map[string]int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for rule, n := range counts {
+		a.counts[[2]string{provider, rule}] += n
+	}
+}
+
+// Redactions returns the totals by provider and rule, sorted.
+func (a *RedactionAudit) Redactions() []Redaction {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]Redaction, 0, len(a.counts))
+	for k, n := range a.counts {
+		out = append(out, Redaction{Provider: k[0], Rule: k[1], Values: n})
+	}
+	slices.SortFunc(out, func(x, y Redaction) int {
+		if c := strings.Compare(x.Provider, y.Provider); c != 0 {
+			return c
+		}
+		return strings.Compare(x.Rule, y.Rule)
+	})
+	return out
+}
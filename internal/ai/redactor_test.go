@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/prompt"
+	"ai-code-reviewer/internal/redact"
+	"ai-code-reviewer/internal/review"
+
+	"github.com/stretchr/testify/require"
+)
+
+// echoStub answers with the content it was sent, in small stream deltas.
+type echoStub struct {
+	reviewOnly
+	got        []ReviewRequest
+	verified   []VerifyRequest
+	summarized []SummaryRequest
+}
+
+func (p *echoStub) Review(ctx context.Context, r ReviewRequest) (ReviewResponse, error) {
+	p.got = append(p.got, r)
+	return ReviewResponse{Content: r.Content}, nil
+}
+
+func (p *echoStub) Verify(ctx context.Context, r VerifyRequest) (ReviewResponse, error) {
+	p.verified = append(p.verified, r)
+	return ReviewResponse{Content: r.Issue.Title}, nil
+}
+
+func (p *echoStub) Summarize(ctx context.Context, r SummaryRequest) (ReviewResponse, error) {
+	p.summarized = append(p.summarized, r)
+	return ReviewResponse{Content: r.PR.Body}, nil
+}
+
+func (p *echoStub) ReviewStream(ctx context.Context, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+	p.got = append(p.got, r)
+	for i := 0; i < len(r.Content); i += 4 {
+		if err := onDelta(r.Content[i:min(i+4, len(r.Content))]); err != nil {
+			return ReviewResponse{}, abortStream(err)
+		}
+	}
+	return ReviewResponse{Content: r.Content}, nil
+}
+
+func TestRedactor_RedactsRequestAndRestoresResponse(t *testing.T) {
+	next := &echoStub{}
+	r := NewRedactor("openai", next, redact.New(config.DefaultRedactionRules, "test-key"))
+	audit := NewRedactionAudit()
+	ctx := WithRedactionAudit(context.Background(), audit)
+
+	req := ReviewRequest{
+		File:    "internal/mail/send.go",
+		Content: "+send(\"jane@example.com\")\n",
+		PR:      &prompt.PRContext{Title: "Mail 10.0.12.7 directly", Body: "Reported by jane@example.com"},
+	}
+	resp, err := r.Review(ctx, req)
+	require.NoError(t, err)
+
+	sent := next.got[0]
+	require.NotContains(t, sent.Content, "jane@example.com")
+	require.NotContains(t, sent.PR.Title+sent.PR.Body, "jane@example.com")
+	require.NotContains(t, sent.PR.Title, "10.0.12.7")
+	require.Equal(t, "Mail 10.0.12.7 directly", req.PR.Title)
+	require.Equal(t, req.Content, resp.Content)
+
+	var streamed strings.Builder
+	resp, err = Stream(ctx, r, req, func(d string) error {
+		streamed.WriteString(d)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, req.Content, streamed.String())
+	require.Equal(t, req.Content, resp.Content)
+
+	require.Equal(t, []Redaction{
+		{Provider: "openai", Rule: "email", Values: 2},
+		{Provider: "openai", Rule: "ipv4", Values: 2},
+	}, audit.Redactions())
+}
+
+func TestRedactor_RedactsVerificationAndSummary(t *testing.T) {
+	next := &echoStub{}
+	r := NewRedactor("openai", next, redact.New(config.DefaultRedactionRules, "test-key"))
+	ctx := context.Background()
+
+	resp, err := r.Verify(ctx, VerifyRequest{
+		File:    "internal/mail/send.go",
+		Content: "+send(\"jane@example.com\")\n",
+		Issue:   review.Issue{Line: 1, Title: "mail to jane@example.com is not queued"},
+	})
+	require.NoError(t, err)
+	require.NotContains(t, next.verified[0].Content+next.verified[0].Issue.Title, "jane@example.com")
+	require.Equal(t, "mail to jane@example.com is not queued", resp.Content)
+
+	resp, err = r.Summarize(ctx, SummaryRequest{
+		PR: &prompt.PRContext{Title: "Queue mail", Body: "Reported by jane@example.com"},
+		Findings: []prompt.Finding{
+			{File: "internal/mail/send.go", Issue: review.Issue{Line: 1, Title: "mail to jane@example.com is not queued"}},
+		},
+	})
+	require.NoError(t, err)
+	sent := next.summarized[0]
+	require.NotContains(t, sent.PR.Body+sent.Findings[0].Issue.Title, "jane@example.com")
+	require.Equal(t, "Reported by jane@example.com", resp.Content)
+}
+
+func TestNewNamedProvider_RedactsOnlyExternalProviders(t *testing.T) {
+	cfg := &config.Config{
+		ExternalProviders: []string{"openai"},
+	}
+
+	require.IsType(t, &Redactor{}, newNamedProvider("openai", cfg))
+	require.IsType(t, &OllamaProvider{}, newNamedProvider("ollama", cfg))
+}
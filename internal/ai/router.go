@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"context"
+	"strings"
+
+	"ai-code-reviewer/internal/routing"
+)
+
+// Router sends each request to the model its files are routed to (see
+// MODEL_ROUTES_PATH). Requests no rule matches, requests that already name
+// a provider or model and PR summaries, which have no chunk, keep it.
+type Router struct {
+	next   Provider
+	routes *routing.Router
+}
+
+func NewRouter(next Provider, routes *routing.Router) *Router {
+	return &Router{next: next, routes: routes}
+}
+
+func (r *Router) Review(ctx context.Context, req ReviewRequest) (ReviewResponse, error) {
+	sel, ok := r.Select(req)
+	if ok {
+		req.Target = Target{Provider: sel.Provider, Model: sel.Model}
+	}
+	return routed(sel, ok, func() (ReviewResponse, error) {
+		return r.next.Review(ctx, req)
+	})
+}
+
+func (r *Router) ReviewStream(ctx context.Context, req ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+	sel, ok := r.Select(req)
+	if ok {
+		req.Target = Target{Provider: sel.Provider, Model: sel.Model}
+	}
+	return routed(sel, ok, func() (ReviewResponse, error) {
+		return Stream(ctx, r.next, req, onDelta)
+	})
+}
+
+func (r *Router) Verify(ctx context.Context, req VerifyRequest) (ReviewResponse, error) {
+	sel, ok := r.selectFor(req.Target, []string{req.File}, req.Content)
+	if ok {
+		req.Target = Target{Provider: sel.Provider, Model: sel.Model}
+	}
+	return routed(sel, ok, func() (ReviewResponse, error) {
+		return r.next.Verify(ctx, req)
+	})
+}
+
+func (r *Router) Summarize(ctx context.Context, req SummaryRequest) (ReviewResponse, error) {
+	return r.next.Summarize(ctx, req)
+}
+
+func (r *Router) SuggestTests(ctx context.Context, req TestRequest) (ReviewResponse, error) {
+	sel, ok := r.selectFor(req.Target, []string{req.File}, req.Content)
+	if ok {
+		req.Target = Target{Provider: sel.Provider, Model: sel.Model}
+	}
+	return routed(sel, ok, func() (ReviewResponse, error) {
+		return r.next.SuggestTests(ctx, req)
+	})
+}
+
+func (r *Router) Capabilities() Capabilities {
+	return CapabilitiesOf(r.next)
+}
+
+// Selector is implemented by providers that pick the provider and model of
+// a request themselves, so callers can count and price it beforehand.
+type Selector interface {
+	Select(req ReviewRequest) (routing.Selection, bool)
+}
+
+// Select returns the route req is sent to; ok is false when req keeps its
+// provider and model.
+func (r *Router) Select(req ReviewRequest) (routing.Selection, bool) {
+	content := req.Content
+	if req.IsBatch() {
+		var b strings.Builder
+		for _, f := range req.Files {
+			b.WriteString(f.Content)
+			b.WriteByte('\n')
+		}
+		content = b.String()
+	}
+	return r.selectFor(req.Target, req.Paths(), content)
+}
+
+func (r *Router) selectFor(t Target, paths []string, content string) (routing.Selection, bool) {
+	if t != (Target{}) {
+		return routing.Selection{}, false
+	}
+	return r.routes.Select(paths, content)
+}
+
+// routed makes a call sent to the model sel names, if ok, and reports the
+// rule that picked it when the routed provider answered.
+func routed(sel routing.Selection, ok bool, call func() (ReviewResponse, error)) (ReviewResponse, error) {
+	resp, err := call()
+	if err != nil || !ok {
+		return resp, err
+	}
+	if resp.Provider == sel.Provider {
+		resp.Route = sel.Rule
+	}
+	return resp, nil
+}
@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/routing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_SetsModelAndRoute(t *testing.T) {
+	next := &modelStub{}
+	c := newTestChain(ChainEntry{Name: "openai", Weight: 1, Provider: next})
+
+	r := NewRouter(c, routing.New([]config.ModelRoute{
+		{Name: "auth", Paths: []string{"internal/auth/**"}, Provider: "openai", Model: "gpt-4.1"},
+	}))
+
+	resp, err := r.Review(context.Background(), ReviewRequest{File: "internal/auth/token.go", Content: "+x"})
+	require.NoError(t, err)
+	require.Equal(t, "gpt-4.1", resp.Model)
+	require.Equal(t, "auth", resp.Route)
+
+	resp, err = r.Review(context.Background(), ReviewRequest{File: "cmd/main.go", Content: "+x"})
+	require.NoError(t, err)
+	require.Empty(t, resp.Model)
+	require.Empty(t, resp.Route)
+	require.Empty(t, next.got[1].Provider)
+}
+
+func TestRouter_KeepsRequestedModel(t *testing.T) {
+	next := &modelStub{}
+	c := newTestChain(ChainEntry{Name: "openai", Weight: 1, Provider: next})
+
+	r := NewRouter(c, routing.New([]config.ModelRoute{
+		{Name: "auth", Paths: []string{"internal/auth/**"}, Provider: "openai", Model: "gpt-4.1"},
+	}))
+
+	resp, err := r.Review(context.Background(), ReviewRequest{
+		File: "internal/auth/token.go", Content: "+x", Target: Target{Provider: "openai", Model: "gpt-4o-mini"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "gpt-4o-mini", resp.Model)
+	require.Empty(t, resp.Route)
+}
+
+func TestRouter_RoutesVerificationButNotSummaries(t *testing.T) {
+	next := &modelStub{}
+	c := newTestChain(ChainEntry{Name: "openai", Weight: 1, Provider: next})
+
+	r := NewRouter(c, routing.New([]config.ModelRoute{
+		{Name: "everything", Paths: []string{"**"}, Provider: "openai", Model: "gpt-4.1"},
+	}))
+
+	resp, err := r.Verify(context.Background(), VerifyRequest{File: "internal/auth/token.go", Content: "+x"})
+	require.NoError(t, err)
+	require.Equal(t, "gpt-4.1", resp.Model)
+	require.Equal(t, "everything", resp.Route)
+
+	resp, err = r.Summarize(context.Background(), SummaryRequest{})
+	require.NoError(t, err)
+	require.Empty(t, resp.Model)
+	require.Empty(t, resp.Route)
+}
@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrStreamAborted wraps the error returned by a stream callback. The
+// caller stopped the stream on purpose, so it is neither retried nor
+// counted against the provider.
+var ErrStreamAborted = errors.New("stream aborted")
+
+// Streamer is implemented by providers that can stream the response. onDelta
+// receives the text as it is generated; returning an error stops the
+// stream. The returned response holds the full content, and on abort the
+// content received so far.
+type Streamer interface {
+	ReviewStream(ctx context.Context, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error)
+}
+
+// Stream reviews r with p, streaming when p supports it. Other providers
+// deliver their whole response as a single delta.
+func Stream(ctx context.Context, p Provider, r ReviewRequest, onDelta func(string) error) (ReviewResponse, error) {
+	if s, ok := p.(Streamer); ok {
+		return s.ReviewStream(ctx, r, onDelta)
+	}
+
+	resp, err := p.Review(ctx, r)
+	if err != nil {
+		return resp, err
+	}
+	if err := onDelta(resp.Content); err != nil {
+		return resp, abortStream(err)
+	}
+	return resp, nil
+}
+
+func abortStream(err error) error {
+	return fmt.Errorf("%w: %w", ErrStreamAborted, err)
+}
+
+// streamClient has no overall timeout; a stream lasts as long as the
+// generation and is bounded by the caller's context instead.
+var streamClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 60 * time.Second,
+	},
+}
+
+var errStreamDone = errors.New("stream done")
+
+// readSSE calls fn with the data of each server-sent event until the body
+// ends or fn returns errStreamDone.
+func readSSE(body io.Reader, fn func(data []byte) error) error {
+	return readLines(body, func(line []byte) error {
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			return nil
+		}
+		return fn(bytes.TrimSpace(data))
+	})
+}
+
+// readLines calls fn with each non-empty line, e.g. of an NDJSON stream.
+func readLines(body io.Reader, fn func(line []byte) error) error {
+	br := bufio.NewReader(body)
+	for {
+		line, err := br.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if ferr := fn(line); ferr != nil {
+				if errors.Is(ferr, errStreamDone) {
+					return nil
+				}
+				return ferr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"slices"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/config"
+)
+
+// ensureOllamaModels pulls the Ollama models the provider chain and model
+// routes may call when the server does not have them yet.
+func (s *Server) ensureOllamaModels(ctx context.Context) {
+	for _, model := range ollamaModels(s.cfg) {
+		p := ai.NewOllama(s.cfg.OllamaURL, model, ai.OllamaOptionsFromConfig(s.cfg), ai.GenerationFromConfig(s.cfg))
+
+		s.logger.Info("checking ollama model", "model", model)
+		if err := p.EnsureModel(ctx, true); err != nil {
+			s.logger.Error("ollama model unavailable", "model", model, "err", err)
+			continue
+		}
+		s.logger.Info("ollama model ready", "model", model)
+	}
+}
+
+func ollamaModels(cfg *config.Config) []string {
+	var models []string
+	for _, pw := range cfg.AIProviderChain {
+		if pw.Name == "ollama" {
+			models = append(models, cfg.OllamaModel)
+		}
+	}
+	for _, r := range cfg.ModelRoutes {
+		if r.Provider == "ollama" && !slices.Contains(models, r.Model) {
+			models = append(models, r.Model)
+		}
+	}
+	return models
+}
@@ -3,13 +3,22 @@ package app
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"ai-code-reviewer/internal/ai"
 	"ai-code-reviewer/internal/budget"
+	"ai-code-reviewer/internal/cache"
+	"ai-code-reviewer/internal/codectx"
+	"ai-code-reviewer/internal/config"
 	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/delivery"
+	"ai-code-reviewer/internal/deps"
 	"ai-code-reviewer/internal/github"
 	"ai-code-reviewer/internal/observability"
 	"ai-code-reviewer/internal/ratelimit"
+	"ai-code-reviewer/internal/routing"
+	"ai-code-reviewer/internal/tokenizer"
+	"ai-code-reviewer/internal/vet"
 	"ai-code-reviewer/internal/worker"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -40,29 +49,57 @@ func (s *Server) routes() {
 	// github client
 	ghClient := github.NewClient(s.cfg, s.logger)
 
+	// delivery log for webhook idempotency
+	deliveries := delivery.NewStore(s.cfg)
+
 	// webhook
 	gh := github.NewWebhookHandler(
 		s.cfg,
 		s.logger,
 		adapter,
+		deliveries,
 	)
 
-	provider := ai.NewProvider(s.cfg)
-
-	// add circuit breaker
-	providerWithCB := ai.NewCircuitBreaker(provider)
+	// providers in AI_PROVIDER_CHAIN order, each with a circuit breaker
+	var providers ai.Provider = ai.NewChainFromConfig(s.cfg)
+
+	// replay reviews of unchanged chunks, e.g. after a rebase
+	if s.cfg.AICacheEnabled {
+		providers = ai.NewCache(
+			providers,
+			cache.NewStore(s.cfg),
+			time.Duration(s.cfg.AICacheTTLHours)*time.Hour,
+			s.cfg.AIProvider,
+			func(name string) string { return ai.ModelNameFor(name, s.cfg) },
+		)
+	}
 
-	// optional fallback still works
-	fallback := ai.NewFallback(
-		providerWithCB,
-		ai.NewOllama(
-			s.cfg.OllamaURL,
-			s.cfg.OllamaModel,
-		),
-	)
+	// per-chunk model overrides from MODEL_ROUTES_PATH
+	if len(s.cfg.ModelRoutes) > 0 {
+		providers = ai.NewRouter(providers, routing.New(s.cfg.ModelRoutes))
+	}
 
 	dedup := dedup.NewMemory()
 
+	// the analyzers load standard library types from a Go toolchain
+	staticAnalysis := s.cfg.StaticAnalysis
+	if staticAnalysis {
+		if err := vet.CheckStdlib(); err != nil {
+			s.logger.Error("static analysis disabled: no Go toolchain for standard library export data", "err", err)
+			staticAnalysis = false
+		}
+	}
+
+	// known vulnerabilities for dependency review
+	var osv *deps.DB
+	if s.cfg.DependencyReview && s.cfg.OSVDBPath != "" {
+		db, err := deps.LoadDB(s.cfg.OSVDBPath)
+		if err != nil {
+			s.logger.Error("vulnerability check disabled: osv database unreadable", "path", s.cfg.OSVDBPath, "err", err)
+		}
+		osv = db
+	}
+
 	//ratelimiter
 	rateLimiter := ratelimit.New(s.cfg.RateLimitRPS, s.cfg.RateLimitBurst)
 
@@ -73,7 +110,7 @@ func (s *Server) routes() {
 		ghClient,
 		dedup,
 		s.logger,
-		fallback,
+		providers,
 		rateLimiter,
 		budget.NewGuard(
 			s.cfg.BudgetEnabled,
@@ -81,6 +118,20 @@ func (s *Server) routes() {
 			s.cfg.BudgetPerPRUSD,
 			budget.NewStore(s.cfg),
 		),
+		worker.WithMinConfidence(s.cfg.ReviewMinConfidence),
+		worker.WithRepoConfig(s.cfg.Repos),
+		worker.WithPRContext(s.cfg.PRContextMaxTokens, s.cfg.PRContextLinkIssues),
+		worker.WithFileContext(fileContextOptions(s.cfg)),
+		worker.WithModel(chunkModel(s.cfg), s.cfg.ChunkMaxTokens),
+		worker.WithBatching(s.cfg.BatchMaxFiles),
+		worker.WithStreaming(s.cfg.AIStreamEnabled),
+		worker.WithVerification(s.cfg.VerifyEnabled, s.cfg.VerifyProvider, s.cfg.VerifyModel),
+		worker.WithNarrative(s.cfg.SummaryNarrative),
+		worker.WithTestSuggestions(s.cfg.TestSuggestions),
+		worker.WithStaticAnalysis(staticAnalysis),
+		worker.WithSecretScan(s.cfg.SecretScan, s.cfg.SecretAllowlist),
+		worker.WithDependencyReview(s.cfg.DependencyReview, osv),
+		worker.WithJobTimeout(time.Duration(s.cfg.JobTimeoutSec)*time.Second),
 	)
 
 	// init metrics
@@ -88,8 +139,51 @@ func (s *Server) routes() {
 
 	mux.HandleFunc(githubWebhookPath, gh.Handle)
 	mux.Handle(metricsPath, promhttp.Handler())
+	mux.HandleFunc(adminDeliveriesPath, s.requireAdmin(s.listDeliveries(deliveries)))
 
 	processor.Start(context.Background())
 
 	s.http.Handler = mux
 }
+
+// fileContextOptions maps the CONTEXT_* settings; disabled fetching is a
+// zero token budget.
+func fileContextOptions(cfg *config.Config) codectx.Options {
+	opts := codectx.Options{
+		Mode:        cfg.ContextMode,
+		WindowLines: cfg.ContextWindowLines,
+		MaxTokens:   cfg.ContextMaxTokens,
+		References:  cfg.ContextGoReferences,
+	}
+	if !cfg.ContextFetchEnabled {
+		opts.MaxTokens = 0
+	}
+	return opts
+}
+
+// chunkModel is the chain or routed model with the smallest context window,
+// so every chunk fits whichever model serves it.
+func chunkModel(cfg *config.Config) tokenizer.Model {
+	m := providerModel(cfg.AIProvider, ai.ModelName(cfg), cfg)
+	for i, pw := range cfg.AIProviderChain {
+		candidate := providerModel(pw.Name, ai.ModelNameFor(pw.Name, cfg), cfg)
+		if i == 0 || candidate.ContextWindow < m.ContextWindow {
+			m = candidate
+		}
+	}
+	for _, r := range cfg.ModelRoutes {
+		if candidate := providerModel(r.Provider, r.Model, cfg); candidate.ContextWindow < m.ContextWindow {
+			m = candidate
+		}
+	}
+	return m
+}
+
+// providerModel looks a model up, limited to OLLAMA_NUM_CTX on Ollama.
+func providerModel(provider, name string, cfg *config.Config) tokenizer.Model {
+	m := tokenizer.Lookup(name)
+	if provider == "ollama" && cfg.OllamaNumCtx > 0 {
+		m.ContextWindow = min(m.ContextWindow, cfg.OllamaNumCtx)
+	}
+	return m
+}
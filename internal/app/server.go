@@ -25,6 +25,7 @@ func NewServer(cfg *config.Config, logger *observability.Logger) *Server {
 		openAI: ai.NewOpenAI(
 			cfg.OpenAIKey,
 			cfg.OpenAIModel,
+			ai.GenerationFromConfig(cfg),
 		),
 	}
 
@@ -44,6 +45,10 @@ func (s *Server) Start(ctx context.Context) error {
 		_ = s.http.Shutdown(context.Background())
 	}()
 
+	if s.cfg.OllamaPullOnMissing {
+		go s.ensureOllamaModels(ctx)
+	}
+
 	s.logger.Info("starting server",
 		"port", s.cfg.Port,
 		"env", s.cfg.Env,
@@ -0,0 +1,14 @@
+package cache
+
+import (
+	"strings"
+
+	"ai-code-reviewer/internal/config"
+)
+
+func NewStore(cfg *config.Config) Store {
+	if strings.ToLower(strings.TrimSpace(cfg.AICacheStore)) == "redis" {
+		return NewRedisStore(cfg.RedisAddr)
+	}
+	return NewMemoryStore()
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// MemoryStore is a bounded in-process store that evicts the least recently
+// used key first.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// order holds the entries, least recently used at the front.
+	order      *list.List
+	maxEntries int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: 5000,
+	}
+}
+
+func (m *MemoryStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		m.remove(el)
+		return nil, false, nil
+	}
+	m.order.MoveToBack(el)
+	return e.value, true, nil
+}
+
+func (m *MemoryStore) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e := &entry{key: key, value: value, expires: time.Now().Add(ttl)}
+	if el, ok := m.entries[key]; ok {
+		el.Value = e
+		m.order.MoveToBack(el)
+	} else {
+		m.entries[key] = m.order.PushBack(e)
+	}
+
+	// Keep memory bounded by dropping the least recently used keys.
+	for m.order.Len() > m.maxEntries {
+		m.remove(m.order.Front())
+	}
+	return nil
+}
+
+func (m *MemoryStore) remove(el *list.Element) {
+	m.order.Remove(el)
+	delete(m.entries, el.Value.(*entry).key)
+}
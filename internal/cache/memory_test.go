@@ -0,0 +1,81 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_ExpiresEntries(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	_ = store.Set(ctx, "k", []byte("v"), -time.Second)
+
+	if _, ok, _ := store.Get(ctx, "k"); ok {
+		t.Fatalf("expected expired entry to be missing")
+	}
+}
+
+func TestMemoryStore_EvictsOldest(t *testing.T) {
+	store := NewMemoryStore()
+	store.maxEntries = 2
+	ctx := context.Background()
+
+	_ = store.Set(ctx, "a", []byte("1"), time.Hour)
+	_ = store.Set(ctx, "b", []byte("2"), time.Hour)
+	_ = store.Set(ctx, "c", []byte("3"), time.Hour)
+
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Fatalf("expected oldest entry to be evicted")
+	}
+	if v, ok, _ := store.Get(ctx, "c"); !ok || string(v) != "3" {
+		t.Fatalf("expected newest entry, got %q %v", v, ok)
+	}
+}
+
+func TestMemoryStore_ExpiredKeyReSetIsNotEvictedFirst(t *testing.T) {
+	store := NewMemoryStore()
+	store.maxEntries = 2
+	ctx := context.Background()
+
+	_ = store.Set(ctx, "a", []byte("old"), -time.Second)
+	if _, ok, _ := store.Get(ctx, "a"); ok {
+		t.Fatalf("expected expired entry to be missing")
+	}
+
+	_ = store.Set(ctx, "b", []byte("2"), time.Hour)
+	_ = store.Set(ctx, "a", []byte("1"), time.Hour)
+	_ = store.Set(ctx, "c", []byte("3"), time.Hour)
+
+	if _, ok, _ := store.Get(ctx, "b"); ok {
+		t.Fatalf("expected the oldest live entry to be evicted")
+	}
+	if v, ok, _ := store.Get(ctx, "a"); !ok || string(v) != "1" {
+		t.Fatalf("expected re-set entry to survive, got %q %v", v, ok)
+	}
+	if store.order.Len() != len(store.entries) {
+		t.Fatalf("order has %d keys for %d entries", store.order.Len(), len(store.entries))
+	}
+}
+
+func TestMemoryStore_ReSetRefreshesKey(t *testing.T) {
+	store := NewMemoryStore()
+	store.maxEntries = 2
+	ctx := context.Background()
+
+	_ = store.Set(ctx, "a", []byte("1"), time.Hour)
+	_ = store.Set(ctx, "b", []byte("2"), time.Hour)
+	_ = store.Set(ctx, "a", []byte("1'"), time.Hour)
+	_ = store.Set(ctx, "c", []byte("3"), time.Hour)
+
+	if _, ok, _ := store.Get(ctx, "b"); ok {
+		t.Fatalf("expected b to be evicted")
+	}
+	if v, ok, _ := store.Get(ctx, "a"); !ok || string(v) != "1'" {
+		t.Fatalf("expected refreshed a, got %q %v", v, ok)
+	}
+	if store.order.Len() != 2 {
+		t.Fatalf("expected 2 keys in order, got %d", store.order.Len())
+	}
+}
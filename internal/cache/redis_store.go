@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisCacheKeyPrefix = "ai_reviewer:cache:"
+
+type RedisStore struct {
+	rdb *redis.Client
+}
+
+func NewRedisStore(addr string) *RedisStore {
+	return &RedisStore{
+		rdb: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+	}
+}
+
+func (r *RedisStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	b, err := r.rdb.Get(ctx, redisCacheKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+func (r *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.rdb.Set(ctx, redisCacheKeyPrefix+key, value, ttl).Err()
+}
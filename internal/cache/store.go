@@ -0,0 +1,13 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Store keeps cached AI responses by key until their TTL expires.
+type Store interface {
+	// Get returns the value and false when the key is missing or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
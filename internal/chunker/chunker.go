@@ -1,58 +1,247 @@
 package chunker
 
 import (
+	"path"
 	"strings"
-	"unicode/utf8"
+
+	"ai-code-reviewer/internal/diff"
+	"ai-code-reviewer/internal/tokenizer"
+)
+
+const (
+	// defaultOverlap is how many lines of the previous piece are repeated,
+	// as context, at the start of a continued hunk.
+	defaultOverlap = 3
+
+	hunkHeader          = "Hunk:\n"
+	continuedHunkHeader = "Hunk (continued):\n"
 )
 
 type Chunk struct {
 	File    string
 	Content string
+	// Hunks are the hunks, or hunk pieces, rendered in Content.
+	Hunks []diff.Hunk
 }
 
 type Chunker struct {
 	MaxTokens int
+	// Overlap is the number of lines repeated between pieces of a split
+	// hunk.
+	Overlap   int
+	tokenizer tokenizer.Tokenizer
 }
 
-func New(max int) *Chunker {
-	return &Chunker{MaxTokens: max}
+// New returns a chunker counting with tok; nil uses the approximation.
+func New(max int, tok tokenizer.Tokenizer) *Chunker {
+	if tok == nil {
+		tok = tokenizer.For(tokenizer.Approx)
+	}
+	return &Chunker{MaxTokens: max, Overlap: defaultOverlap, tokenizer: tok}
 }
 
-// Rough token estimation (no tiktoken dependency)
-func estimateTokens(s string) int {
-	return utf8.RuneCountInString(s) / 3
+// EstimateTokens is a rough, model independent token estimation.
+func EstimateTokens(s string) int {
+	return tokenizer.For(tokenizer.Approx).Count(s)
 }
 
-func (c *Chunker) Split(file, content string) []Chunk {
+// Split packs the hunks of a file into chunks that fit MaxTokens. Hunks are
+// kept whole when they fit; larger ones are split at Go declaration
+// boundaries, then blank lines, then anywhere. Every chunk starts with the
+// file header.
+func (c *Chunker) Split(f diff.FileDiff) []Chunk {
 
-	if estimateTokens(content) <= c.MaxTokens {
-		return []Chunk{{File: file, Content: content}}
-	}
+	header := "File: " + f.Filename + "\n\n"
+	budget := max(c.MaxTokens-c.count(header), 1)
 
 	var chunks []Chunk
-	var current strings.Builder
-
-	for _, line := range strings.Split(content, "\n") {
+	var current []piece
+	size := 0
 
-		if estimateTokens(current.String()+line) > c.MaxTokens {
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		chunks = append(chunks, render(f.Filename, header, current))
+		current = nil
+		size = 0
+	}
 
-			chunks = append(chunks, Chunk{
-				File:    file,
-				Content: current.String(),
-			})
+	add := func(p piece) {
+		n := c.pieceSize(p)
+		if size+n > budget {
+			flush()
+		}
+		current = append(current, p)
+		size += n
+	}
 
-			current.Reset()
+	for _, h := range f.Hunks {
+		p := piece{hunk: h}
+		if c.pieceSize(p) <= budget {
+			add(p)
+			continue
 		}
+		for _, sp := range c.splitHunk(h, budget, isGo(f.Filename)) {
+			add(sp)
+		}
+	}
+	flush()
 
-		current.WriteString(line + "\n")
+	return chunks
+}
+
+// piece is a whole hunk or one part of a split hunk.
+type piece struct {
+	hunk      diff.Hunk
+	continued bool
+}
+
+func (p piece) header() string {
+	if p.continued {
+		return continuedHunkHeader
+	}
+	return hunkHeader
+}
+
+func (c *Chunker) pieceSize(p piece) int {
+	n := c.count(p.header())
+	for _, l := range p.hunk.Lines {
+		n += c.lineSize(l)
+	}
+	return n
+}
+
+func render(file, header string, pieces []piece) Chunk {
+	var b strings.Builder
+	b.WriteString(header)
+
+	hunks := make([]diff.Hunk, 0, len(pieces))
+	for _, p := range pieces {
+		b.WriteString(p.header())
+		for _, l := range p.hunk.Lines {
+			b.WriteString(l.String() + "\n")
+		}
+		hunks = append(hunks, p.hunk)
 	}
 
-	if current.Len() > 0 {
-		chunks = append(chunks, Chunk{
-			File:    file,
-			Content: current.String(),
+	return Chunk{File: file, Content: b.String(), Hunks: hunks}
+}
+
+// splitHunk cuts an oversized hunk into pieces of at most budget tokens.
+// Each piece after the first starts with up to Overlap lines of the
+// previous piece, downgraded to context so they are not reviewed twice.
+func (c *Chunker) splitHunk(h diff.Hunk, budget int, goFile bool) []piece {
+
+	var out []piece
+	lines := h.Lines
+	start := 0
+
+	for start < len(lines) {
+		var overlap []diff.Line
+		if start > 0 {
+			overlap = overlapLines(lines[:start], c.Overlap)
+		}
+
+		size := c.count(continuedHunkHeader)
+		if start == 0 {
+			size = c.count(hunkHeader)
+		}
+		for _, l := range overlap {
+			size += c.lineSize(l)
+		}
+
+		end := c.cutPoint(lines, start, budget-size, goFile)
+
+		part := append(append([]diff.Line{}, overlap...), lines[start:end]...)
+		out = append(out, piece{
+			hunk:      diff.Hunk{OldStart: part[0].OldNumber, NewStart: part[0].NewNumber, Lines: part},
+			continued: start > 0,
 		})
+		start = end
 	}
 
-	return chunks
+	return out
+}
+
+// cutPoint returns the end (exclusive) of the piece starting at start. It
+// prefers the last declaration boundary that fits, then the last blank
+// line, then as many lines as fit. At least one line is always taken.
+func (c *Chunker) cutPoint(lines []diff.Line, start, budget int, goFile bool) int {
+
+	fit := start
+	size := 0
+	for fit < len(lines) && size+c.lineSize(lines[fit]) <= budget {
+		size += c.lineSize(lines[fit])
+		fit++
+	}
+	if fit == len(lines) {
+		return fit
+	}
+	if fit == start {
+		return start + 1
+	}
+
+	if goFile {
+		for i := fit; i > start; i-- {
+			if declStart(lines, i) {
+				return i
+			}
+		}
+	}
+	for i := fit; i > start; i-- {
+		if strings.TrimSpace(lines[i-1].Content) == "" {
+			return i
+		}
+	}
+	return fit
+}
+
+// declStart reports whether a top-level Go declaration, including its doc
+// comment, starts at lines[i].
+func declStart(lines []diff.Line, i int) bool {
+	if i > 0 && strings.HasPrefix(lines[i-1].Content, "//") {
+		return false
+	}
+	for j := i; j < len(lines); j++ {
+		s := lines[j].Content
+		if strings.HasPrefix(s, "//") {
+			continue
+		}
+		for _, kw := range []string{"func ", "type ", "var ", "const ", "import "} {
+			if strings.HasPrefix(s, kw) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func overlapLines(prev []diff.Line, n int) []diff.Line {
+	var out []diff.Line
+	for i := len(prev) - 1; i >= 0 && len(out) < n; i-- {
+		l := prev[i]
+		if l.Type == diff.Removed {
+			continue
+		}
+		l.Type = diff.Context
+		out = append([]diff.Line{l}, out...)
+	}
+	return out
+}
+
+func (c *Chunker) lineSize(l diff.Line) int {
+	return c.count(l.String() + "\n")
+}
+
+func (c *Chunker) count(s string) int {
+	if c.tokenizer == nil {
+		return EstimateTokens(s)
+	}
+	return c.tokenizer.Count(s)
+}
+
+func isGo(file string) bool {
+	return path.Ext(file) == ".go"
 }
@@ -0,0 +1,101 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/diff"
+
+	"github.com/stretchr/testify/require"
+)
+
+func added(start int, contents ...string) diff.Hunk {
+	h := diff.Hunk{NewStart: start}
+	for i, c := range contents {
+		h.Lines = append(h.Lines, diff.Line{Type: diff.Added, Content: c, NewNumber: start + i})
+	}
+	return h
+}
+
+func TestSplit_SmallFileIsOneChunk(t *testing.T) {
+	f := diff.FileDiff{Filename: "a.go", Hunks: []diff.Hunk{added(1, "x := 1"), added(10, "y := 2")}}
+
+	chunks := New(1000, nil).Split(f)
+
+	require.Len(t, chunks, 1)
+	require.Equal(t, f.ToAIContext(), chunks[0].Content)
+	require.Len(t, chunks[0].Hunks, 2)
+}
+
+func TestSplit_KeepsHunksWholeAndRepeatsHeader(t *testing.T) {
+	var lines []string
+	for i := range 20 {
+		lines = append(lines, fmt.Sprintf("value%02d := compute(%d)", i, i))
+	}
+	f := diff.FileDiff{Filename: "pkg/a.go", Hunks: []diff.Hunk{
+		added(1, lines[:10]...),
+		added(50, lines[10:]...),
+	}}
+
+	// room for one hunk per chunk
+	chunks := New(120, nil).Split(f)
+
+	require.Len(t, chunks, 2)
+	for i, ch := range chunks {
+		require.True(t, strings.HasPrefix(ch.Content, "File: pkg/a.go\n\nHunk:\n"))
+		require.LessOrEqual(t, EstimateTokens(ch.Content), 120)
+		require.Len(t, ch.Hunks, 1)
+		require.Equal(t, f.Hunks[i], ch.Hunks[0])
+	}
+}
+
+func TestSplit_OversizedGoHunkSplitsAtDeclarations(t *testing.T) {
+	src := []string{
+		"// First does one thing.",
+		"func First() int {",
+		"\treturn 1",
+		"}",
+		"",
+		"// Second does another.",
+		"func Second() int {",
+		"\ta := 1",
+		"\tb := 2",
+		"\treturn a + b",
+		"}",
+	}
+	f := diff.FileDiff{Filename: "x.go", Hunks: []diff.Hunk{added(1, src...)}}
+
+	c := New(40, nil)
+	c.Overlap = 1
+	chunks := c.Split(f)
+
+	require.Len(t, chunks, 2)
+	require.Contains(t, chunks[0].Content, "+func First() int {")
+	require.NotContains(t, chunks[0].Content, "Second")
+
+	second := chunks[1].Content
+	require.True(t, strings.HasPrefix(second, "File: x.go\n\nHunk (continued):\n"))
+	// overlap is context, never re-added
+	require.Contains(t, second, "\n \n+// Second does another.\n")
+	require.Equal(t, 5, chunks[1].Hunks[0].NewStart)
+	require.Equal(t, diff.Context, chunks[1].Hunks[0].Lines[0].Type)
+}
+
+func TestSplit_OversizedHunkFallsBackToBlankLines(t *testing.T) {
+	var src []string
+	for i := range 3 {
+		src = append(src, fmt.Sprintf("line %d alpha", i), fmt.Sprintf("line %d beta", i), "")
+	}
+	f := diff.FileDiff{Filename: "notes.txt", Hunks: []diff.Hunk{added(1, src...)}}
+
+	c := New(25, nil)
+	c.Overlap = 0
+	chunks := c.Split(f)
+
+	require.Greater(t, len(chunks), 1)
+	for _, ch := range chunks {
+		last := ch.Hunks[len(ch.Hunks)-1].Lines
+		require.Empty(t, last[len(last)-1].Content)
+	}
+}
@@ -0,0 +1,149 @@
+package codectx
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"ai-code-reviewer/internal/diff"
+)
+
+const (
+	ModeFunction = "function"
+	ModeWindow   = "window"
+)
+
+// Options controls how much of the head file is added around each hunk.
+type Options struct {
+	// Mode is ModeFunction (enclosing Go declaration, window elsewhere) or
+	// ModeWindow.
+	Mode        string
+	WindowLines int
+	MaxTokens   int
+	// References adds package-level definitions used by the enclosing Go
+	// declarations.
+	References bool
+	Estimate   func(string) int
+}
+
+// Source is a file at the PR head.
+type Source struct {
+	Path    string
+	Content string
+}
+
+// span is an inclusive 1-based line range.
+type span struct {
+	start int
+	end   int
+}
+
+// Build renders the head-version code surrounding the hunks of file. pkg
+// holds the other files of the same Go package and is only used for
+// references.
+func Build(file Source, hunks []diff.Hunk, pkg []Source, opts Options) string {
+	if opts.MaxTokens <= 0 || file.Content == "" {
+		return ""
+	}
+
+	var changed []span
+	for _, h := range hunks {
+		if start, end, ok := h.NewRange(); ok {
+			changed = append(changed, span{start, end})
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	lines := strings.Split(file.Content, "\n")
+
+	var spans []span
+	var refs []string
+
+	if opts.Mode != ModeWindow && path.Ext(file.Path) == ".go" {
+		if gf, err := parseGo(file); err == nil {
+			var uncovered []span
+			spans, uncovered = gf.enclosing(changed)
+			spans = append(spans, windows(uncovered, opts.WindowLines, len(lines))...)
+			if opts.References {
+				refs = gf.references(changed, pkg)
+			}
+		}
+	}
+	if spans == nil {
+		spans = windows(changed, opts.WindowLines, len(lines))
+	}
+
+	budget := opts.MaxTokens
+	var b strings.Builder
+
+	for _, s := range merge(spans) {
+		block := renderLines(lines, s)
+		cost := opts.Estimate(block)
+		if cost > budget {
+			break
+		}
+		b.WriteString(block)
+		budget -= cost
+	}
+
+	if len(refs) > 0 {
+		var rb strings.Builder
+		for _, r := range refs {
+			cost := opts.Estimate(r)
+			if cost > budget {
+				break
+			}
+			rb.WriteString(r)
+			rb.WriteString("\n\n")
+			budget -= cost
+		}
+		if rb.Len() > 0 {
+			b.WriteString("\nReferenced definitions:\n")
+			b.WriteString(rb.String())
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func windows(changed []span, window, total int) []span {
+	out := make([]span, 0, len(changed))
+	for _, c := range changed {
+		out = append(out, span{
+			start: max(1, c.start-window),
+			end:   min(total, c.end+window),
+		})
+	}
+	return out
+}
+
+func merge(spans []span) []span {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	out := []span{spans[0]}
+	for _, s := range spans[1:] {
+		last := &out[len(out)-1]
+		if s.start <= last.end+1 {
+			last.end = max(last.end, s.end)
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func renderLines(lines []string, s span) string {
+	var b strings.Builder
+	for n := s.start; n <= s.end && n <= len(lines); n++ {
+		fmt.Fprintf(&b, "%5d | %s\n", n, lines[n-1])
+	}
+	b.WriteString("  ... |\n")
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package codectx
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/diff"
+
+	"github.com/stretchr/testify/require"
+)
+
+func estimate(s string) int { return len(s) / 4 }
+
+func numbered(n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d", i+1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hunksFor(t *testing.T, patch string) []diff.Hunk {
+	t.Helper()
+	files, err := diff.Parse(patch)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	return files[0].Hunks
+}
+
+func TestBuild_WindowMergesOverlappingRanges(t *testing.T) {
+	hunks := hunksFor(t, "@@ -10,1 +10,1 @@\n-a\n+b\n@@ -14,1 +14,1 @@\n-c\n+d\n")
+
+	got := Build(Source{Path: "run.sh", Content: numbered(40)}, hunks, nil, Options{
+		Mode:        ModeFunction,
+		WindowLines: 3,
+		MaxTokens:   1000,
+		Estimate:    estimate,
+	})
+
+	require.Contains(t, got, "    7 | line 7")
+	require.Contains(t, got, "   17 | line 17")
+	require.NotContains(t, got, "line 6\n")
+	require.NotContains(t, got, "line 18")
+	require.Equal(t, 1, strings.Count(got, "... |"))
+}
+
+func TestBuild_GoFallsBackToWindowOnParseError(t *testing.T) {
+	hunks := hunksFor(t, "@@ -5,1 +5,1 @@\n-a\n+b\n")
+
+	got := Build(Source{Path: "broken.go", Content: numbered(20)}, hunks, nil, Options{
+		Mode:        ModeFunction,
+		WindowLines: 2,
+		MaxTokens:   1000,
+		Estimate:    estimate,
+	})
+
+	require.Contains(t, got, "    3 | line 3")
+	require.Contains(t, got, "    7 | line 7")
+	require.NotContains(t, got, "line 8")
+}
+
+func TestBuild_GoEnclosingDeclarationAndReferences(t *testing.T) {
+	src := "package store\n" +
+		"\n" +
+		"// Put stores v.\n" +
+		"func (s *Store) Put(k string, v Value) error {\n" +
+		"\tif len(k) > maxKey {\n" +
+		"\t\treturn errKey\n" +
+		"\t}\n" +
+		"\ts.m[k] = v\n" +
+		"\treturn nil\n" +
+		"}\n" +
+		"\n" +
+		"func unrelated() {}\n"
+	other := "package store\n" +
+		"\n" +
+		"const maxKey = 64\n" +
+		"\n" +
+		"type Value []byte\n" +
+		"\n" +
+		"func helper() int {\n\treturn 1\n}\n"
+
+	hunks := hunksFor(t, "@@ -8,1 +8,1 @@\n-\ts.m[k] = nil\n+\ts.m[k] = v\n")
+
+	got := Build(Source{Path: "store/put.go", Content: src}, hunks,
+		[]Source{{Path: "store/types.go", Content: other}},
+		Options{Mode: ModeFunction, MaxTokens: 1000, References: true, Estimate: estimate},
+	)
+
+	require.Contains(t, got, "    3 | // Put stores v.")
+	require.Contains(t, got, "   10 | }")
+	require.NotContains(t, got, "unrelated")
+	require.Contains(t, got, "const maxKey = 64")
+	require.Contains(t, got, "type Value []byte")
+	require.NotContains(t, got, "helper")
+}
+
+func TestBuild_RespectsTokenBudget(t *testing.T) {
+	hunks := hunksFor(t, "@@ -5,1 +5,1 @@\n-a\n+b\n@@ -30,1 +30,1 @@\n-c\n+d\n")
+
+	got := Build(Source{Path: "a.txt", Content: numbered(40)}, hunks, nil, Options{
+		WindowLines: 1,
+		MaxTokens:   20,
+		Estimate:    estimate,
+	})
+
+	require.Contains(t, got, "line 5")
+	require.NotContains(t, got, "line 30")
+
+	require.Empty(t, Build(Source{Path: "a.txt", Content: numbered(40)}, hunks, nil, Options{
+		WindowLines: 1,
+		MaxTokens:   0,
+		Estimate:    estimate,
+	}))
+}
@@ -0,0 +1,84 @@
+package codectx
+
+import (
+	"go/ast"
+	"strings"
+
+	"ai-code-reviewer/internal/diff"
+)
+
+// Func is an exported Go function or method at the PR head.
+type Func struct {
+	// Name is "Func" or "Type.Method".
+	Name string
+	// Source is the declaration with its doc comment.
+	Source string
+}
+
+// ChangedFuncs returns the package name of a Go file and its exported
+// functions and methods on exported types that contain added lines.
+func ChangedFuncs(file Source, hunks []diff.Hunk) (string, []Func, error) {
+	gf, err := parseGo(file)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var added []int
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			if l.Type == diff.Added {
+				added = append(added, l.NewNumber)
+			}
+		}
+	}
+
+	lines := strings.Split(file.Content, "\n")
+
+	var out []Func
+	for _, d := range gf.file.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if !ok || !fn.Name.IsExported() {
+			continue
+		}
+		name := fn.Name.Name
+		if fn.Recv != nil {
+			recv := receiverType(fn.Recv)
+			if !ast.IsExported(recv) {
+				continue
+			}
+			name = recv + "." + name
+		}
+
+		s := gf.declSpan(fn)
+		for _, n := range added {
+			if s.start <= n && n <= s.end {
+				out = append(out, Func{Name: name, Source: strings.Join(lines[s.start-1:s.end], "\n")})
+				break
+			}
+		}
+	}
+	return gf.file.Name.Name, out, nil
+}
+
+// receiverType returns the type name of a method receiver, without pointer
+// or type parameters.
+func receiverType(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	t := recv.List[0].Type
+	for {
+		switch x := t.(type) {
+		case *ast.StarExpr:
+			t = x.X
+		case *ast.IndexExpr:
+			t = x.X
+		case *ast.IndexListExpr:
+			t = x.X
+		case *ast.Ident:
+			return x.Name
+		default:
+			return ""
+		}
+	}
+}
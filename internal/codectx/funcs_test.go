@@ -0,0 +1,39 @@
+package codectx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFuncs_ExportedWithAddedLines(t *testing.T) {
+	src := "package store\n" + //  1
+		"\n" + //                    2
+		"// Put stores v.\n" + //    3
+		"func (s *Store) Put(k string, v []byte) error {\n" + // 4
+		"\ts.m[k] = v\n" + //        5
+		"\treturn nil\n" + //        6
+		"}\n" + //                   7
+		"\n" + //                    8
+		"func (s *store) Get(k string) []byte { return s.m[k] }\n" + // 9
+		"\n" + //                    10
+		"func helper() int { return 1 }\n" + // 11
+		"\n" + //                    12
+		"func Len(s *Store) int { return len(s.m) }\n" // 13
+
+	hunks := hunksFor(t, "@@ -5,1 +5,1 @@\n-\ts.m[k] = nil\n+\ts.m[k] = v\n"+
+		"@@ -9,5 +9,5 @@\n-func (s *store) Get(k string) []byte { return nil }\n+func (s *store) Get(k string) []byte { return s.m[k] }\n"+
+		" \n-func helper() int { return 0 }\n+func helper() int { return 1 }\n \n func Len(s *Store) int { return len(s.m) }\n")
+
+	pkg, funcs, err := ChangedFuncs(Source{Path: "store/put.go", Content: src}, hunks)
+	require.NoError(t, err)
+	require.Equal(t, "store", pkg)
+	require.Len(t, funcs, 1)
+	require.Equal(t, "Store.Put", funcs[0].Name)
+	require.Equal(t, "// Put stores v.\nfunc (s *Store) Put(k string, v []byte) error {\n\ts.m[k] = v\n\treturn nil\n}", funcs[0].Source)
+}
+
+func TestChangedFuncs_ParseError(t *testing.T) {
+	_, _, err := ChangedFuncs(Source{Path: "a.go", Content: "package a\nfunc {"}, nil)
+	require.Error(t, err)
+}
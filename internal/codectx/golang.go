@@ -0,0 +1,177 @@
+package codectx
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"sort"
+)
+
+type goFile struct {
+	fset *token.FileSet
+	file *ast.File
+}
+
+func parseGo(src Source) (goFile, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, src.Path, src.Content, parser.ParseComments)
+	if err != nil {
+		return goFile{}, err
+	}
+	return goFile{fset: fset, file: f}, nil
+}
+
+// declSpan returns the lines of a top-level declaration including its doc
+// comment.
+func (g goFile) declSpan(d ast.Decl) span {
+	start := d.Pos()
+	switch x := d.(type) {
+	case *ast.FuncDecl:
+		if x.Doc != nil {
+			start = x.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if x.Doc != nil {
+			start = x.Doc.Pos()
+		}
+	}
+	return span{
+		start: g.fset.Position(start).Line,
+		end:   g.fset.Position(d.End()).Line,
+	}
+}
+
+// enclosing returns the declarations overlapping the changed spans and the
+// changed spans that fall outside any declaration.
+func (g goFile) enclosing(changed []span) (decls []span, uncovered []span) {
+	for _, c := range changed {
+		covered := false
+		for _, d := range g.file.Decls {
+			s := g.declSpan(d)
+			if s.start <= c.end && c.start <= s.end {
+				decls = append(decls, s)
+				covered = true
+			}
+		}
+		if !covered {
+			uncovered = append(uncovered, c)
+		}
+	}
+	return decls, uncovered
+}
+
+// references renders the package-level types, functions, constants and
+// variables used by the declarations that enclose the changes. Function
+// bodies are omitted; the signature and doc comment are usually enough.
+func (g goFile) references(changed []span, pkg []Source) []string {
+	used := make(map[string]bool)
+	declared := make(map[string]bool)
+
+	for _, d := range g.file.Decls {
+		s := g.declSpan(d)
+		overlaps := false
+		for _, c := range changed {
+			if s.start <= c.end && c.start <= s.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			continue
+		}
+		for _, name := range declNames(d) {
+			declared[name] = true
+		}
+		ast.Inspect(d, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				// only the receiver side can name a package-level identifier
+				ast.Inspect(sel.X, func(n ast.Node) bool {
+					if id, ok := n.(*ast.Ident); ok {
+						used[id.Name] = true
+					}
+					return true
+				})
+				return false
+			}
+			if id, ok := n.(*ast.Ident); ok {
+				used[id.Name] = true
+			}
+			return true
+		})
+	}
+
+	files := append([]Source{}, pkg...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	var out []string
+	seen := make(map[string]bool)
+
+	all := append([]goFile{g}, parsePackage(files, g.file.Name.Name)...)
+	for _, f := range all {
+		for _, d := range f.file.Decls {
+			for _, name := range declNames(d) {
+				if !used[name] || declared[name] || seen[name] {
+					continue
+				}
+				seen[name] = true
+				out = append(out, "// "+path.Base(f.fset.File(d.Pos()).Name())+"\n"+f.render(d))
+				break
+			}
+		}
+	}
+	return out
+}
+
+func parsePackage(files []Source, pkgName string) []goFile {
+	var out []goFile
+	for _, src := range files {
+		gf, err := parseGo(src)
+		if err != nil || gf.file.Name.Name != pkgName {
+			continue
+		}
+		out = append(out, gf)
+	}
+	return out
+}
+
+// declNames lists the package-level names a declaration introduces.
+// Methods are skipped since they are not referenced by bare identifiers.
+func declNames(d ast.Decl) []string {
+	var out []string
+	switch x := d.(type) {
+	case *ast.FuncDecl:
+		if x.Recv == nil {
+			out = append(out, x.Name.Name)
+		}
+	case *ast.GenDecl:
+		for _, s := range x.Specs {
+			switch sp := s.(type) {
+			case *ast.TypeSpec:
+				out = append(out, sp.Name.Name)
+			case *ast.ValueSpec:
+				for _, n := range sp.Names {
+					out = append(out, n.Name)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (g goFile) render(d ast.Decl) string {
+	if fn, ok := d.(*ast.FuncDecl); ok {
+		sig := *fn
+		sig.Body = nil
+		d = &sig
+	}
+
+	var buf bytes.Buffer
+	cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := cfg.Fprint(&buf, g.fset, &printer.CommentedNode{Node: d, Comments: g.file.Comments}); err != nil {
+		return ""
+	}
+	return buf.String()
+}
@@ -12,26 +12,83 @@ type Config struct {
 	GithubSecret         string
 	LogLevel             string
 	AIProvider           string
+	AIProviderChain      []ProviderWeight
+	AIBreakerFailures    int
+	AIBreakerTimeoutSec  int
+	AIBreakerHalfOpen    int
 	GithubPrivateKeyPath string
 	GithubAppID          string
 	GithubInstallationID string
 	OpenAIKey            string
 	OpenAIModel          string
+	AnthropicKey         string
+	AnthropicModel       string
+	AnthropicMaxTokens   int
+	AzureOpenAIEndpoint  string
+	AzureOpenAIKey       string
+	AzureOpenAIDeploy    string
+	AzureOpenAIVersion   string
+	OpenAICompatBaseURL  string
+	OpenAICompatKey      string
+	OpenAICompatModel    string
 	RedisAddr            string
 	QueueType            string
 	OllamaURL            string
 	OllamaModel          string
+	OllamaNumCtx         int
+	OllamaKeepAlive      string
+	OllamaPullOnMissing  bool
 	RateLimitRPS         int
 	RateLimitBurst       int
+	JobTimeoutSec        int
 	BudgetEnabled        bool
 	BudgetDailyUSD       float64
 	BudgetPerPRUSD       float64
 	BudgetStore          string
 	BudgetRedisAddr      string
+	DeliveryStore        string
+	DeliveryTTLHours     int
+	AdminToken           string
+	RepoConfigPath       string
+	ReviewMinConfidence  float64
+	AIResponseFormat     string
+	AITemperature        float64
+	AISeed               int
+	PRContextMaxTokens   int
+	PRContextLinkIssues  bool
+	ContextFetchEnabled  bool
+	ContextMode          string
+	ContextWindowLines   int
+	ContextMaxTokens     int
+	ContextGoReferences  bool
+	ChunkMaxTokens       int
+	BatchMaxFiles        int
+	Repos                RepoConfig
+	ModelRoutesPath      string
+	ModelRoutes          []ModelRoute
+	AICacheEnabled       bool
+	AICacheStore         string
+	AICacheTTLHours      int
+	AIStreamEnabled      bool
+	VerifyEnabled        bool
+	VerifyProvider       string
+	VerifyModel          string
+	SummaryNarrative     bool
+	TestSuggestions      string
+	StaticAnalysis       bool
+	SecretScan           bool
+	SecretAllowlistPath  string
+	SecretAllowlist      SecretAllowlist
+	ExternalProviders    []string
+	RedactionRulesPath   string
+	RedactionRules       []RedactionRule
+	RedactionKey         string
+	DependencyReview     bool
+	OSVDBPath            string
 }
 
 func Load() *Config {
-	return &Config{
+	cfg := &Config{
 		Port:                 getEnv("PORT", "8080"),
 		Env:                  getEnv("ENV", "local"),
 		GithubSecret:         getEnv("GITHUB_WEBHOOK_SECRET", ""),
@@ -39,21 +96,132 @@ func Load() *Config {
 		GithubPrivateKeyPath: getEnv("GITHUB_APP_PRIVATE_KEY_PATH", ""),
 		GithubAppID:          getEnv("GITHUB_APP_ID", ""),
 		AIProvider:           getEnv("AI_PROVIDER", "openai"),
+		AIBreakerFailures:    getEnvInt("AI_BREAKER_FAILURES", 5),
+		AIBreakerTimeoutSec:  getEnvInt("AI_BREAKER_TIMEOUT_SECONDS", 30),
+		AIBreakerHalfOpen:    getEnvInt("AI_BREAKER_HALF_OPEN_REQUESTS", 3),
 		OllamaURL:            getEnv("OLLAMA_URL", "http://localhost:11434"),
 		OllamaModel:          getEnv("OLLAMA_MODEL", "llama3"),
+		OllamaNumCtx:         getEnvInt("OLLAMA_NUM_CTX", 8192), // 0 = server default
+		OllamaKeepAlive:      getEnv("OLLAMA_KEEP_ALIVE", ""),   // e.g. 30m, -1 keeps the model loaded
+		OllamaPullOnMissing:  getEnvBool("OLLAMA_PULL_ON_MISSING", false),
 		GithubInstallationID: getEnv("GITHUB_APP_INSTALLATION_ID", ""),
 		OpenAIKey:            getEnv("OPENAI_KEY", ""),
 		OpenAIModel:          getEnv("OPENAI_MODEL", "gpt-3.5-turbo"),
+		AnthropicKey:         getEnv("ANTHROPIC_KEY", ""),
+		AnthropicModel:       getEnv("ANTHROPIC_MODEL", "claude-sonnet-4-5"),
+		AnthropicMaxTokens:   getEnvInt("ANTHROPIC_MAX_TOKENS", 4096),
+		AzureOpenAIEndpoint:  getEnv("AZURE_OPENAI_ENDPOINT", ""), // https://<resource>.openai.azure.com
+		AzureOpenAIKey:       getEnv("AZURE_OPENAI_KEY", ""),
+		AzureOpenAIDeploy:    getEnv("AZURE_OPENAI_DEPLOYMENT", ""),
+		AzureOpenAIVersion:   getEnv("AZURE_OPENAI_API_VERSION", "2024-10-21"),
+		OpenAICompatBaseURL:  getEnv("OPENAI_COMPAT_BASE_URL", "http://localhost:8000/v1"),
+		OpenAICompatKey:      getEnv("OPENAI_COMPAT_KEY", ""),
+		OpenAICompatModel:    getEnv("OPENAI_COMPAT_MODEL", ""),
 		RedisAddr:            getEnv("REDIS_ADDR", "localhost:6379"),
 		QueueType:            getEnv("QUEUE_TYPE", "memory"), // memory | redis
 		RateLimitRPS:         getEnvInt("RATE_LIMIT_RPS", 2),
 		RateLimitBurst:       getEnvInt("RATE_LIMIT_BURST", 4),
+		JobTimeoutSec:        getEnvInt("JOB_TIMEOUT_SECONDS", 90), // before optional passes add their share
 		BudgetEnabled:        getEnvBool("BUDGET_ENABLED", false),
 		BudgetDailyUSD:       getEnvFloat("BUDGET_DAILY_USD", 10.0),
 		BudgetPerPRUSD:       getEnvFloat("BUDGET_PER_PR_USD", 1.0),
 		BudgetStore:          getEnv("BUDGET_STORE", "memory"), // memory | redis
 		BudgetRedisAddr:      getEnv("BUDGET_REDIS_ADDR", ""),
+		DeliveryStore:        getEnv("DELIVERY_STORE", "memory"), // memory | redis
+		DeliveryTTLHours:     getEnvInt("DELIVERY_TTL_HOURS", 72),
+		AdminToken:           getEnv("ADMIN_TOKEN", ""),
+		RepoConfigPath:       getEnv("REPO_CONFIG_PATH", ""),
+		ReviewMinConfidence:  getEnvFloat("REVIEW_MIN_CONFIDENCE", 0.5),
+		AIResponseFormat:     getEnv("AI_RESPONSE_FORMAT", "json_schema"), // json_schema | json_object | text
+		AITemperature:        getEnvFloat("AI_TEMPERATURE", 0.2),
+		AISeed:               getEnvInt("AI_SEED", 0),
+		PRContextMaxTokens:   getEnvInt("PR_CONTEXT_MAX_TOKENS", 800), // 0 disables PR context
+		PRContextLinkIssues:  getEnvBool("PR_CONTEXT_LINKED_ISSUES", true),
+		ContextFetchEnabled:  getEnvBool("CONTEXT_FETCH_ENABLED", false),
+		ContextMode:          getEnv("CONTEXT_MODE", "function"), // function | window
+		ContextWindowLines:   getEnvInt("CONTEXT_WINDOW_LINES", 20),
+		ContextMaxTokens:     getEnvInt("CONTEXT_MAX_TOKENS", 1500),
+		ContextGoReferences:  getEnvBool("CONTEXT_GO_REFERENCES", true),
+		ChunkMaxTokens:       getEnvInt("CHUNK_MAX_TOKENS", 3000), // cap below the model's window, 0 = window only
+		BatchMaxFiles:        getEnvInt("BATCH_MAX_FILES", 8),     // small files per AI call, 1 disables batching
+		ModelRoutesPath:      getEnv("MODEL_ROUTES_PATH", ""),
+		AICacheEnabled:       getEnvBool("AI_CACHE_ENABLED", false),
+		AICacheStore:         getEnv("AI_CACHE_STORE", "memory"), // memory | redis
+		AICacheTTLHours:      getEnvInt("AI_CACHE_TTL_HOURS", 168),
+		AIStreamEnabled:      getEnvBool("AI_STREAM_ENABLED", false),
+		VerifyEnabled:        getEnvBool("VERIFY_ENABLED", false),
+		VerifyProvider:       getEnv("VERIFY_PROVIDER", ""), // chain member, empty = as reviewed
+		VerifyModel:          getEnv("VERIFY_MODEL", ""),
+		SummaryNarrative:     getEnvBool("SUMMARY_NARRATIVE_ENABLED", false),
+		TestSuggestions:      getEnv("TEST_SUGGESTIONS", "off"), // off | summary | comment
+		StaticAnalysis:       getEnvBool("STATIC_ANALYSIS_ENABLED", false),
+		SecretScan:           getEnvBool("SECRET_SCAN_ENABLED", true),
+		SecretAllowlistPath:  getEnv("SECRET_ALLOWLIST_PATH", ""),
+		RedactionRulesPath:   getEnv("REDACTION_RULES_PATH", ""),
+		RedactionKey:         getEnv("REDACTION_KEY", ""), // empty = random per process
+		DependencyReview:     getEnvBool("DEPENDENCY_REVIEW_ENABLED", true),
+		OSVDBPath:            getEnv("OSV_DB_PATH", ""), // empty = no vulnerability check
 	}
+
+	if cfg.ContextMode != "function" && cfg.ContextMode != "window" {
+		log.Fatalf("invalid env CONTEXT_MODE: %q", cfg.ContextMode)
+	}
+
+	if cfg.JobTimeoutSec <= 0 {
+		log.Fatalf("invalid env JOB_TIMEOUT_SECONDS: %d", cfg.JobTimeoutSec)
+	}
+
+	if cfg.ChunkMaxTokens < 0 {
+		log.Fatalf("invalid env CHUNK_MAX_TOKENS: %d", cfg.ChunkMaxTokens)
+	}
+
+	switch cfg.TestSuggestions {
+	case "off", "summary", "comment":
+	default:
+		log.Fatalf("invalid env TEST_SUGGESTIONS: %q", cfg.TestSuggestions)
+	}
+
+	chain, err := parseProviderChain(getEnv("AI_PROVIDER_CHAIN", ""), cfg.AIProvider)
+	if err != nil {
+		log.Fatalf("invalid env AI_PROVIDER_CHAIN: %v", err)
+	}
+	cfg.AIProviderChain = chain
+
+	repos, err := loadRepoConfig(cfg.RepoConfigPath)
+	if err != nil {
+		log.Fatalf("invalid repo config %s: %v", cfg.RepoConfigPath, err)
+	}
+	cfg.Repos = repos
+
+	routes, err := loadModelRoutes(cfg.ModelRoutesPath, cfg.AIProvider, cfg.AIProviderChain)
+	if err != nil {
+		log.Fatalf("invalid model routes %s: %v", cfg.ModelRoutesPath, err)
+	}
+	cfg.ModelRoutes = routes
+
+	allowlist, err := loadSecretAllowlist(cfg.SecretAllowlistPath)
+	if err != nil {
+		log.Fatalf("invalid secret allowlist %s: %v", cfg.SecretAllowlistPath, err)
+	}
+	cfg.SecretAllowlist = allowlist
+
+	external, err := parseExternalProviders(getEnv("AI_EXTERNAL_PROVIDERS", defaultExternalProviders))
+	if err != nil {
+		log.Fatalf("invalid env AI_EXTERNAL_PROVIDERS: %v", err)
+	}
+	cfg.ExternalProviders = external
+
+	rules, err := loadRedactionRules(cfg.RedactionRulesPath)
+	if err != nil {
+		log.Fatalf("invalid redaction rules %s: %v", cfg.RedactionRulesPath, err)
+	}
+	cfg.RedactionRules = rules
+
+	if err := cfg.resolveVerifyProvider(); err != nil {
+		log.Fatalf("invalid env VERIFY_PROVIDER: %v", err)
+	}
+
+	return cfg
 }
 
 func getEnv(key, def string) string {
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// AIProviders lists the accepted AI_PROVIDER and AI_PROVIDER_CHAIN names.
+var AIProviders = []string{"openai", "ollama", "anthropic", "azure", "openai_compatible"}
+
+// ProviderWeight is one entry of AI_PROVIDER_CHAIN.
+type ProviderWeight struct {
+	Name   string
+	Weight int
+}
+
+// parseProviderChain reads "openai:3,anthropic:1,ollama:0". Entries are
+// tried in order after a weighted pick of the first one; a missing weight
+// is 1 and weight 0 marks a fallback-only provider. An empty spec keeps the
+// historical chain: AI_PROVIDER, then Ollama as fallback.
+func parseProviderChain(spec, primary string) ([]ProviderWeight, error) {
+	if strings.TrimSpace(spec) == "" {
+		chain := []ProviderWeight{{Name: primary, Weight: 1}}
+		if primary != "ollama" {
+			chain = append(chain, ProviderWeight{Name: "ollama", Weight: 0})
+		}
+		return chain, nil
+	}
+
+	var chain []ProviderWeight
+	for _, part := range strings.Split(spec, ",") {
+		name, weight, hasWeight := strings.Cut(strings.TrimSpace(part), ":")
+		name = strings.TrimSpace(name)
+
+		if !slices.Contains(AIProviders, name) {
+			return nil, fmt.Errorf("unknown provider %q, want one of %s", name, strings.Join(AIProviders, ", "))
+		}
+		if slices.ContainsFunc(chain, func(p ProviderWeight) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("provider %q listed twice", name)
+		}
+
+		w := 1
+		if hasWeight {
+			var err error
+			w, err = strconv.Atoi(strings.TrimSpace(weight))
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight %q for %s", weight, name)
+			}
+		}
+
+		chain = append(chain, ProviderWeight{Name: name, Weight: w})
+	}
+
+	return chain, nil
+}
+
+// resolveVerifyProvider defaults VERIFY_PROVIDER to AI_PROVIDER when only a
+// model is given, and checks that it is in the chain.
+func (c *Config) resolveVerifyProvider() error {
+	if c.VerifyProvider == "" && c.VerifyModel != "" {
+		c.VerifyProvider = c.AIProvider
+	}
+	if c.VerifyProvider == "" {
+		return nil
+	}
+	if !slices.ContainsFunc(c.AIProviderChain, func(p ProviderWeight) bool { return p.Name == c.VerifyProvider }) {
+		return fmt.Errorf("provider %q is not in the provider chain", c.VerifyProvider)
+	}
+	return nil
+}
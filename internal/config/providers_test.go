@@ -0,0 +1,68 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProviderChain(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		primary string
+		want    []ProviderWeight
+	}{
+		{
+			name:    "empty spec falls back to ollama",
+			primary: "openai",
+			want:    []ProviderWeight{{Name: "openai", Weight: 1}, {Name: "ollama", Weight: 0}},
+		},
+		{
+			name:    "empty spec with ollama primary",
+			spec:    "  ",
+			primary: "ollama",
+			want:    []ProviderWeight{{Name: "ollama", Weight: 1}},
+		},
+		{
+			name: "weights",
+			spec: "openai:3, anthropic : 1 ,ollama:0",
+			want: []ProviderWeight{{Name: "openai", Weight: 3}, {Name: "anthropic", Weight: 1}, {Name: "ollama", Weight: 0}},
+		},
+		{
+			name: "missing weight is 1",
+			spec: "azure,openai_compatible:2",
+			want: []ProviderWeight{{Name: "azure", Weight: 1}, {Name: "openai_compatible", Weight: 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProviderChain(tt.spec, tt.primary)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseProviderChain_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		err  string
+	}{
+		{name: "unknown provider", spec: "openai,gemini", err: `unknown provider "gemini"`},
+		{name: "empty entry", spec: "openai,,ollama", err: `unknown provider ""`},
+		{name: "duplicate", spec: "openai:2,ollama,openai", err: `provider "openai" listed twice`},
+		{name: "negative weight", spec: "openai:-1", err: `invalid weight "-1" for openai`},
+		{name: "non-numeric weight", spec: "openai:high", err: `invalid weight "high" for openai`},
+		{name: "empty weight", spec: "openai:", err: `invalid weight "" for openai`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseProviderChain(tt.spec, "openai")
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RedactionRule replaces matching text with a placeholder before a request
+// reaches an external provider. A rule has either a Pattern or a
+// MinEntropy. Rules are loaded from REDACTION_RULES_PATH:
+//
+//	{"rules": [
+//	  {"name": "email", "pattern": "[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\\.[A-Za-z]{2,}"},
+//	  {"name": "customer_id", "pattern": "\\bCUST-[0-9]{6}\\b"},
+//	  {"name": "token", "min_entropy": 4.0, "min_length": 20}
+//	]}
+type RedactionRule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	// MinEntropy matches tokens of at least MinLength characters that mix
+	// upper case, lower case and digits with this many bits of Shannon
+	// entropy per character. MinLength defaults to 20.
+	MinEntropy float64 `json:"min_entropy"`
+	MinLength  int     `json:"min_length"`
+}
+
+// DefaultRedactionRules apply when REDACTION_RULES_PATH is not set.
+var DefaultRedactionRules = []RedactionRule{
+	{Name: "email", Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`},
+	{Name: "ipv4", Pattern: `\b(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\b`},
+	{Name: "token", MinEntropy: 4.0, MinLength: defaultMinTokenLength},
+}
+
+const (
+	// defaultExternalProviders are the hosted APIs; Ollama runs locally.
+	defaultExternalProviders = "openai,anthropic,azure,openai_compatible"
+	defaultMinTokenLength    = 20
+)
+
+var redactionRuleName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func loadRedactionRules(path string) ([]RedactionRule, error) {
+	if path == "" {
+		return DefaultRedactionRules, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read redaction rules: %w", err)
+	}
+
+	var file struct {
+		Rules []RedactionRule `json:"rules"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("decode redaction rules: %w", err)
+	}
+
+	for i, r := range file.Rules {
+		if !redactionRuleName.MatchString(r.Name) {
+			return nil, fmt.Errorf("rule %d: name %q must be lower case letters, digits and underscores", i+1, r.Name)
+		}
+		if (r.Pattern == "") == (r.MinEntropy == 0) {
+			return nil, fmt.Errorf("rule %s: set either pattern or min_entropy", r.Name)
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		if r.MinEntropy > 0 && r.MinLength <= 0 {
+			file.Rules[i].MinLength = defaultMinTokenLength
+		}
+	}
+	return file.Rules, nil
+}
+
+// parseExternalProviders reads AI_EXTERNAL_PROVIDERS, a comma separated
+// list of providers that only get redacted requests, or "none".
+func parseExternalProviders(spec string) ([]string, error) {
+	if strings.TrimSpace(spec) == "none" {
+		return nil, nil
+	}
+
+	var out []string
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(AIProviders, name) {
+			return nil, fmt.Errorf("unknown provider %q, want one of %s", name, strings.Join(AIProviders, ", "))
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
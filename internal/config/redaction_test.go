@@ -0,0 +1,77 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRedactionRules(t *testing.T) {
+	rules, err := loadRedactionRules("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultRedactionRules, rules)
+
+	path := writeFile(t, "redaction.json", `{"rules": [
+		{"name": "customer_id", "pattern": "\\bCUST-[0-9]{6}\\b"},
+		{"name": "token", "min_entropy": 4.5},
+		{"name": "long_token", "min_entropy": 4.0, "min_length": 32}
+	]}`)
+
+	rules, err = loadRedactionRules(path)
+	require.NoError(t, err)
+	require.Equal(t, []RedactionRule{
+		{Name: "customer_id", Pattern: `\bCUST-[0-9]{6}\b`},
+		{Name: "token", MinEntropy: 4.5, MinLength: defaultMinTokenLength},
+		{Name: "long_token", MinEntropy: 4.0, MinLength: 32},
+	}, rules)
+}
+
+func TestLoadRedactionRules_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "invalid json", content: `{"rules": [`, err: "decode redaction rules"},
+		{name: "bad name", content: `{"rules": [{"name": "Customer-ID", "pattern": "x"}]}`, err: `rule 1: name "Customer-ID" must be lower case`},
+		{name: "missing name", content: `{"rules": [{"pattern": "x"}]}`, err: `rule 1: name ""`},
+		{name: "neither pattern nor entropy", content: `{"rules": [{"name": "empty"}]}`, err: "rule empty: set either pattern or min_entropy"},
+		{name: "both pattern and entropy", content: `{"rules": [{"name": "both", "pattern": "x", "min_entropy": 4}]}`, err: "rule both: set either pattern or min_entropy"},
+		{name: "invalid pattern", content: `{"rules": [{"name": "broken", "pattern": "("}]}`, err: "rule broken: error parsing regexp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadRedactionRules(writeFile(t, "redaction.json", tt.content))
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	_, err := loadRedactionRules(filepath.Join(t.TempDir(), "missing.json"))
+	require.ErrorContains(t, err, "read redaction rules")
+}
+
+func TestParseExternalProviders(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want []string
+	}{
+		{name: "default", spec: defaultExternalProviders, want: []string{"openai", "anthropic", "azure", "openai_compatible"}},
+		{name: "none", spec: " none ", want: nil},
+		{name: "empty", spec: "", want: nil},
+		{name: "blank entries skipped", spec: "openai, ,ollama,", want: []string{"openai", "ollama"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExternalProviders(tt.spec)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := parseExternalProviders("openai,gemini")
+	require.ErrorContains(t, err, `unknown provider "gemini"`)
+}
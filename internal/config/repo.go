@@ -0,0 +1,119 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RepoConfig holds per-repository policies loaded from REPO_CONFIG_PATH.
+// Settings under "repos" override "default" field by field.
+//
+//	{
+//	  "default": {"filters": {"skip_title_markers": ["[skip ai]"]}},
+//	  "repos": {"acme/api": {"filters": {"branches": ["main", "release/*"]}}}
+//	}
+type RepoConfig struct {
+	Default RepoSettings            `json:"default"`
+	Repos   map[string]RepoSettings `json:"repos"`
+}
+
+type RepoSettings struct {
+	Filters FilterPolicy `json:"filters"`
+	// Instructions are extra review rules appended to the system prompt.
+	Instructions string `json:"instructions"`
+}
+
+// FilterPolicy decides which pull request events are reviewed. Nil fields
+// inherit the default policy.
+type FilterPolicy struct {
+	AllowAuthors     []string `json:"allow_authors"`
+	DenyAuthors      []string `json:"deny_authors"`
+	SkipBots         *bool    `json:"skip_bots"`
+	AllowDependabot  *bool    `json:"allow_dependabot"`
+	AllowRenovate    *bool    `json:"allow_renovate"`
+	RequiredLabels   []string `json:"required_labels"`
+	ExcludedLabels   []string `json:"excluded_labels"`
+	Branches         []string `json:"branches"`
+	SkipTitleMarkers []string `json:"skip_title_markers"`
+}
+
+var defaultSkipTitleMarkers = []string{"[skip ai]", "[ai skip]"}
+
+// ForRepo returns the effective settings for a repository full name.
+func (r RepoConfig) ForRepo(repo string) RepoSettings {
+	out := r.Default
+	if override, ok := r.Repos[repo]; ok {
+		out.Filters = out.Filters.merge(override.Filters)
+		if override.Instructions != "" {
+			out.Instructions = override.Instructions
+		}
+	}
+	return out
+}
+
+func (f FilterPolicy) merge(o FilterPolicy) FilterPolicy {
+	if o.AllowAuthors != nil {
+		f.AllowAuthors = o.AllowAuthors
+	}
+	if o.DenyAuthors != nil {
+		f.DenyAuthors = o.DenyAuthors
+	}
+	if o.SkipBots != nil {
+		f.SkipBots = o.SkipBots
+	}
+	if o.AllowDependabot != nil {
+		f.AllowDependabot = o.AllowDependabot
+	}
+	if o.AllowRenovate != nil {
+		f.AllowRenovate = o.AllowRenovate
+	}
+	if o.RequiredLabels != nil {
+		f.RequiredLabels = o.RequiredLabels
+	}
+	if o.ExcludedLabels != nil {
+		f.ExcludedLabels = o.ExcludedLabels
+	}
+	if o.Branches != nil {
+		f.Branches = o.Branches
+	}
+	if o.SkipTitleMarkers != nil {
+		f.SkipTitleMarkers = o.SkipTitleMarkers
+	}
+	return f
+}
+
+func (f FilterPolicy) ShouldSkipBots() bool {
+	return f.SkipBots == nil || *f.SkipBots
+}
+
+func (f FilterPolicy) DependabotAllowed() bool {
+	return f.AllowDependabot != nil && *f.AllowDependabot
+}
+
+func (f FilterPolicy) RenovateAllowed() bool {
+	return f.AllowRenovate != nil && *f.AllowRenovate
+}
+
+func (f FilterPolicy) TitleMarkers() []string {
+	if f.SkipTitleMarkers == nil {
+		return defaultSkipTitleMarkers
+	}
+	return f.SkipTitleMarkers
+}
+
+func loadRepoConfig(path string) (RepoConfig, error) {
+	var rc RepoConfig
+	if path == "" {
+		return rc, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return rc, fmt.Errorf("read repo config: %w", err)
+	}
+	if err := json.Unmarshal(b, &rc); err != nil {
+		return rc, fmt.Errorf("decode repo config: %w", err)
+	}
+	return rc, nil
+}
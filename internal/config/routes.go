@@ -0,0 +1,78 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Route signals detect risky calls in added lines.
+const (
+	SignalSQL    = "sql"
+	SignalCrypto = "crypto"
+	SignalExec   = "exec"
+)
+
+var routeSignals = []string{SignalSQL, SignalCrypto, SignalExec}
+
+// ModelRoute sends matching chunks to a specific provider and model. All
+// set conditions must hold; the first matching route wins.
+//
+//	{"routes": [
+//	  {"name": "security", "paths": ["**/auth/**"], "signals": ["sql", "crypto", "exec"], "model": "gpt-4o"},
+//	  {"name": "small", "max_lines": 20, "model": "gpt-4o-mini"}
+//	]}
+type ModelRoute struct {
+	Name      string   `json:"name"`
+	Paths     []string `json:"paths"`     // globs, "**" crosses directories
+	Languages []string `json:"languages"` // go, python, typescript, javascript
+	MinLines  int      `json:"min_lines"` // changed lines in the chunk
+	MaxLines  int      `json:"max_lines"`
+	Signals   []string `json:"signals"` // any of sql, crypto, exec
+	// Provider defaults to AI_PROVIDER and must be in AI_PROVIDER_CHAIN.
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+func loadModelRoutes(path, defaultProvider string, chain []ProviderWeight) ([]ModelRoute, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read model routes: %w", err)
+	}
+
+	var file struct {
+		Routes []ModelRoute `json:"routes"`
+	}
+	if err := json.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("decode model routes: %w", err)
+	}
+
+	for i := range file.Routes {
+		r := &file.Routes[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("route-%d", i+1)
+		}
+		if r.Provider == "" {
+			r.Provider = defaultProvider
+		}
+		if !slices.ContainsFunc(chain, func(pw ProviderWeight) bool { return pw.Name == r.Provider }) {
+			return nil, fmt.Errorf("route %s: provider %q is not in the provider chain", r.Name, r.Provider)
+		}
+		if strings.TrimSpace(r.Model) == "" {
+			return nil, fmt.Errorf("route %s: model is required", r.Name)
+		}
+		for _, s := range r.Signals {
+			if !slices.Contains(routeSignals, s) {
+				return nil, fmt.Errorf("route %s: unknown signal %q, want one of %s", r.Name, s, strings.Join(routeSignals, ", "))
+			}
+		}
+	}
+
+	return file.Routes, nil
+}
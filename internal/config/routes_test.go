@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testChain = []ProviderWeight{{Name: "openai", Weight: 1}, {Name: "ollama", Weight: 0}}
+
+// writeFile writes content to a file in a temporary directory and returns
+// its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadModelRoutes(t *testing.T) {
+	path := writeFile(t, "routes.json", `{"routes": [
+		{"name": "security", "paths": ["**/auth/**"], "signals": ["sql", "crypto", "exec"], "model": "gpt-4o"},
+		{"languages": ["go"], "max_lines": 20, "provider": "ollama", "model": "llama3.1"}
+	]}`)
+
+	routes, err := loadModelRoutes(path, "openai", testChain)
+	require.NoError(t, err)
+	require.Equal(t, []ModelRoute{
+		{Name: "security", Paths: []string{"**/auth/**"}, Signals: []string{"sql", "crypto", "exec"}, Provider: "openai", Model: "gpt-4o"},
+		{Name: "route-2", Languages: []string{"go"}, MaxLines: 20, Provider: "ollama", Model: "llama3.1"},
+	}, routes)
+
+	routes, err = loadModelRoutes("", "openai", testChain)
+	require.NoError(t, err)
+	require.Nil(t, routes)
+}
+
+func TestLoadModelRoutes_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "invalid json", content: `{"routes": [`, err: "decode model routes"},
+		{name: "provider not in chain", content: `{"routes": [{"name": "r", "provider": "anthropic", "model": "claude"}]}`, err: `route r: provider "anthropic" is not in the provider chain`},
+		{name: "missing model", content: `{"routes": [{"paths": ["*.go"], "model": " "}]}`, err: "route route-1: model is required"},
+		{name: "unknown signal", content: `{"routes": [{"name": "r", "signals": ["sql", "xss"], "model": "gpt-4o"}]}`, err: `route r: unknown signal "xss"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadModelRoutes(writeFile(t, "routes.json", tt.content), "openai", testChain)
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	_, err := loadModelRoutes(filepath.Join(t.TempDir(), "missing.json"), "openai", testChain)
+	require.ErrorContains(t, err, "read model routes")
+}
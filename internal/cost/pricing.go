@@ -1,5 +1,7 @@
 package cost
 
+import "strings"
+
 const tokensPer1K = 1000.0
 
 type ModelPrice struct {
@@ -7,15 +9,25 @@ type ModelPrice struct {
 	OutputPer1KUSD float64
 }
 
+// prices is matched by longest prefix so dated snapshots such as
+// "gpt-4o-2024-08-06" or "claude-sonnet-4-5-20250929" find their family.
 var prices = map[string]ModelPrice{
 	// Update these constants as provider pricing changes.
-	"gpt-3.5-turbo": {InputPer1KUSD: 0.0005, OutputPer1KUSD: 0.0015},
-	"gpt-4o-mini":   {InputPer1KUSD: 0.00015, OutputPer1KUSD: 0.0006},
-	"gpt-4o":        {InputPer1KUSD: 0.005, OutputPer1KUSD: 0.015},
+	"gpt-3.5-turbo":     {InputPer1KUSD: 0.0005, OutputPer1KUSD: 0.0015},
+	"gpt-4o-mini":       {InputPer1KUSD: 0.00015, OutputPer1KUSD: 0.0006},
+	"gpt-4o":            {InputPer1KUSD: 0.005, OutputPer1KUSD: 0.015},
+	"gpt-4.1":           {InputPer1KUSD: 0.002, OutputPer1KUSD: 0.008},
+	"gpt-4.1-mini":      {InputPer1KUSD: 0.0004, OutputPer1KUSD: 0.0016},
+	"claude-3-5-haiku":  {InputPer1KUSD: 0.0008, OutputPer1KUSD: 0.004},
+	"claude-3-5-sonnet": {InputPer1KUSD: 0.003, OutputPer1KUSD: 0.015},
+	"claude-3-7-sonnet": {InputPer1KUSD: 0.003, OutputPer1KUSD: 0.015},
+	"claude-sonnet-4":   {InputPer1KUSD: 0.003, OutputPer1KUSD: 0.015},
+	"claude-opus-4":     {InputPer1KUSD: 0.015, OutputPer1KUSD: 0.075},
+	"claude-haiku-4":    {InputPer1KUSD: 0.001, OutputPer1KUSD: 0.005},
 }
 
 func EstimateUSD(model string, promptTokens, completionTokens int) float64 {
-	price, ok := prices[model]
+	price, ok := lookup(model)
 	if !ok {
 		return 0
 	}
@@ -24,3 +36,20 @@ func EstimateUSD(model string, promptTokens, completionTokens int) float64 {
 	outputCost := (float64(completionTokens) / tokensPer1K) * price.OutputPer1KUSD
 	return inputCost + outputCost
 }
+
+func lookup(model string) (ModelPrice, bool) {
+	if p, ok := prices[model]; ok {
+		return p, true
+	}
+
+	best := ""
+	for name := range prices {
+		if strings.HasPrefix(model, name) && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return prices[best], true
+}
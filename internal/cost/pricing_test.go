@@ -0,0 +1,14 @@
+package cost
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateUSD(t *testing.T) {
+	require.InDelta(t, 0.02, EstimateUSD("gpt-4o", 1000, 1000), 1e-9)
+	require.InDelta(t, 0.00075, EstimateUSD("gpt-4o-mini-2024-07-18", 1000, 1000), 1e-9)
+	require.InDelta(t, 0.018, EstimateUSD("claude-sonnet-4-5-20250929", 1000, 1000), 1e-9)
+	require.Zero(t, EstimateUSD("llama3", 1000, 1000))
+}
@@ -0,0 +1,21 @@
+package delivery
+
+import (
+	"strings"
+	"time"
+
+	"ai-code-reviewer/internal/config"
+)
+
+func NewStore(cfg *config.Config) Store {
+	ttl := time.Duration(cfg.DeliveryTTLHours) * time.Hour
+	if ttl <= 0 {
+		ttl = 72 * time.Hour
+	}
+
+	if strings.ToLower(strings.TrimSpace(cfg.DeliveryStore)) == "redis" {
+		return NewRedisStore(cfg.RedisAddr, ttl)
+	}
+
+	return NewMemoryStore(ttl)
+}
@@ -0,0 +1,91 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type MemoryStore struct {
+	mu         sync.Mutex
+	claims     map[string]time.Time
+	recent     []Record
+	maxRecent  int
+	ttl        time.Duration
+	lastPruned time.Time
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		claims:    make(map[string]time.Time),
+		recent:    make([]Record, 0, 64),
+		maxRecent: 200,
+		ttl:       ttl,
+	}
+}
+
+func (m *MemoryStore) Claim(_ context.Context, id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.pruneLocked(now)
+
+	if exp, ok := m.claims[id]; ok && now.Before(exp) {
+		return false, nil
+	}
+
+	m.claims[id] = now.Add(pendingTTL)
+	return true, nil
+}
+
+func (m *MemoryStore) Finish(_ context.Context, r Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch r.Outcome {
+	case OutcomeFailed:
+		delete(m.claims, r.ID)
+	case OutcomeDuplicate:
+		// keep the original claim untouched
+	default:
+		if r.ID != "" {
+			m.claims[r.ID] = time.Now().Add(m.ttl)
+		}
+	}
+
+	m.recent = append(m.recent, r)
+	if len(m.recent) > m.maxRecent {
+		m.recent = m.recent[len(m.recent)-m.maxRecent:]
+	}
+	return nil
+}
+
+// Recent returns the latest records, newest first.
+func (m *MemoryStore) Recent(_ context.Context, limit int) ([]Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if limit <= 0 || limit > len(m.recent) {
+		limit = len(m.recent)
+	}
+
+	out := make([]Record, 0, limit)
+	for i := len(m.recent) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, m.recent[i])
+	}
+	return out, nil
+}
+
+func (m *MemoryStore) pruneLocked(now time.Time) {
+	if !m.lastPruned.IsZero() && now.Sub(m.lastPruned) < time.Minute {
+		return
+	}
+
+	for id, exp := range m.claims {
+		if now.After(exp) {
+			delete(m.claims, id)
+		}
+	}
+	m.lastPruned = now
+}
@@ -0,0 +1,51 @@
+package delivery
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_RejectsHandledDelivery(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	ok, _ := store.Claim(ctx, "d-1")
+	if !ok {
+		t.Fatalf("expected first claim to succeed")
+	}
+	_ = store.Finish(ctx, Record{ID: "d-1", Outcome: OutcomeQueued})
+
+	ok, _ = store.Claim(ctx, "d-1")
+	if ok {
+		t.Fatalf("expected redelivery of queued delivery to be rejected")
+	}
+}
+
+func TestMemoryStore_ReleasesFailedDelivery(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+
+	_, _ = store.Claim(ctx, "d-1")
+	_ = store.Finish(ctx, Record{ID: "d-1", Outcome: OutcomeFailed})
+
+	ok, _ := store.Claim(ctx, "d-1")
+	if !ok {
+		t.Fatalf("expected failed delivery to be claimable again")
+	}
+}
+
+func TestMemoryStore_RecentNewestFirst(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	store.maxRecent = 2
+	ctx := context.Background()
+
+	_ = store.Finish(ctx, Record{ID: "a", Outcome: OutcomeQueued})
+	_ = store.Finish(ctx, Record{ID: "b", Outcome: OutcomeFiltered, Reason: "draft pr"})
+	_ = store.Finish(ctx, Record{ID: "c", Outcome: OutcomeQueued})
+
+	got, _ := store.Recent(ctx, 10)
+	if len(got) != 2 || got[0].ID != "c" || got[1].ID != "b" {
+		t.Fatalf("unexpected recent deliveries: %+v", got)
+	}
+}
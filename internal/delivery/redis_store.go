@@ -0,0 +1,80 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisDeliveryKeyFmt    = "ai_reviewer:delivery:%s"
+	redisDeliveryRecent    = "ai_reviewer:deliveries:recent"
+	redisDeliveryPending   = "pending"
+	redisDeliveryMaxRecent = 200
+)
+
+type RedisStore struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+func NewRedisStore(addr string, ttl time.Duration) *RedisStore {
+	return &RedisStore{
+		rdb: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+		ttl: ttl,
+	}
+}
+
+func (r *RedisStore) Claim(ctx context.Context, id string) (bool, error) {
+	return r.rdb.SetNX(ctx, fmt.Sprintf(redisDeliveryKeyFmt, id), redisDeliveryPending, pendingTTL).Result()
+}
+
+func (r *RedisStore) Finish(ctx context.Context, rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal delivery record: %w", err)
+	}
+
+	key := fmt.Sprintf(redisDeliveryKeyFmt, rec.ID)
+
+	pipe := r.rdb.TxPipeline()
+	switch rec.Outcome {
+	case OutcomeFailed:
+		pipe.Del(ctx, key)
+	case OutcomeDuplicate:
+	default:
+		if rec.ID != "" {
+			pipe.Set(ctx, key, string(rec.Outcome), r.ttl)
+		}
+	}
+	pipe.LPush(ctx, redisDeliveryRecent, b)
+	pipe.LTrim(ctx, redisDeliveryRecent, 0, redisDeliveryMaxRecent-1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisStore) Recent(ctx context.Context, limit int) ([]Record, error) {
+	if limit <= 0 || limit > redisDeliveryMaxRecent {
+		limit = redisDeliveryMaxRecent
+	}
+
+	raw, err := r.rdb.LRange(ctx, redisDeliveryRecent, 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Record, 0, len(raw))
+	for _, s := range raw {
+		var rec Record
+		if err := json.Unmarshal([]byte(s), &rec); err != nil {
+			continue
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
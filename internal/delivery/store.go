@@ -0,0 +1,41 @@
+package delivery
+
+import (
+	"context"
+	"time"
+)
+
+type Outcome string
+
+const (
+	OutcomeQueued    Outcome = "queued"
+	OutcomeFiltered  Outcome = "filtered"
+	OutcomeFailed    Outcome = "failed"
+	OutcomeInvalid   Outcome = "invalid"
+	OutcomeDuplicate Outcome = "duplicate"
+)
+
+// pendingTTL bounds how long a claimed delivery blocks redeliveries when the
+// handler dies before recording an outcome.
+const pendingTTL = time.Minute
+
+// Record is one webhook delivery as seen by the handler.
+type Record struct {
+	ID         string    `json:"id"`
+	Event      string    `json:"event"`
+	Repo       string    `json:"repo,omitempty"`
+	PR         int       `json:"pr,omitempty"`
+	Outcome    Outcome   `json:"outcome"`
+	Reason     string    `json:"reason,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+type Store interface {
+	// Claim reserves a delivery ID. It returns false when the delivery was
+	// already handled (or is being handled) and must not be enqueued again.
+	Claim(ctx context.Context, id string) (bool, error)
+	// Finish records the outcome. Failed deliveries release their claim so
+	// GitHub redeliveries are processed again.
+	Finish(ctx context.Context, r Record) error
+	Recent(ctx context.Context, limit int) ([]Record, error)
+}
@@ -0,0 +1,125 @@
+package deps
+
+import (
+	"testing"
+
+	"ai-code-reviewer/internal/diff"
+
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, file, patch string) diff.FileDiff {
+	t.Helper()
+	fds, err := diff.Parse(patch)
+	require.NoError(t, err)
+	require.Len(t, fds, 1)
+	fds[0].Filename = file
+	return fds[0]
+}
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		patch string
+		want  []Change
+	}{
+		{
+			name: "go.mod",
+			file: "go.mod",
+			patch: "@@ -1,8 +1,9 @@\n module example.com/app\n \n-go 1.22\n+go 1.24\n require (\n" +
+				"-\texample.com/parser v1.4.0\n+\texample.com/parser/v2 v2.0.1\n" +
+				"-\tgolang.org/x/text v0.14.0 // indirect\n+\tgolang.org/x/text v0.14.0\n" +
+				"+\texample.com/fork v0.0.0-20240102150405-0123456789ab\n )\n" +
+				"+replace example.com/parser/v2 => ../parser\n",
+			want: []Change{
+				{Manifest: "go.mod", Ecosystem: EcosystemGo, Name: "example.com/parser/v2", From: "v1.4.0", To: "v2.0.1"},
+				{Manifest: "go.mod", Ecosystem: EcosystemGo, Name: "example.com/fork", To: "v0.0.0-20240102150405-0123456789ab"},
+				{Manifest: "go.mod", Ecosystem: EcosystemGo, Name: "example.com/parser/v2", Replace: "../parser"},
+			},
+		},
+		{
+			name: "package.json",
+			file: "web/package.json",
+			patch: "@@ -1,6 +1,7 @@\n {\n-  \"version\": \"1.0.0\",\n+  \"version\": \"1.1.0\",\n   \"dependencies\": {\n" +
+				"-    \"lodash\": \"^3.10.1\",\n+    \"lodash\": \"^4.17.15\",\n+    \"left-pad\": \"github:user/left-pad\",\n" +
+				"+    \"react\": \"~18.2.0\"\n   }\n",
+			want: []Change{
+				{Manifest: "web/package.json", Ecosystem: EcosystemNPM, Name: "lodash", From: "^3.10.1", To: "^4.17.15"},
+				{Manifest: "web/package.json", Ecosystem: EcosystemNPM, Name: "react", To: "~18.2.0"},
+			},
+		},
+		{
+			name:  "requirements.txt",
+			file:  "requirements.txt",
+			patch: "@@ -1,2 +1,3 @@\n # pinned\n-Requests==2.28.0\n+requests[socks]==2.31.0\n+-r dev.txt\n+Django>=4.2\n",
+			want: []Change{
+				{Manifest: "requirements.txt", Ecosystem: EcosystemPyPI, Name: "requests", From: "2.28.0", To: "2.31.0"},
+				{Manifest: "requirements.txt", Ecosystem: EcosystemPyPI, Name: "django", To: "4.2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Changes(parse(t, tt.file, tt.patch)))
+		})
+	}
+}
+
+func TestReview(t *testing.T) {
+	db, err := LoadDB("testdata/osv")
+	require.NoError(t, err)
+	require.Equal(t, 3, db.Len())
+
+	changes := []Change{
+		{Ecosystem: EcosystemGo, Name: "example.com/parser/v2", From: "v1.4.0", To: "v2.0.1"},
+		{Ecosystem: EcosystemGo, Name: "example.com/parser", From: "v1.3.0", To: "v1.4.1"},
+		{Ecosystem: EcosystemGo, Name: "example.com/fork", To: "v0.0.0-20240102150405-0123456789ab"},
+		{Ecosystem: EcosystemGo, Name: "example.com/lib", Replace: "../lib"},
+		{Ecosystem: EcosystemNPM, Name: "lodash", From: "^3.10.1", To: "^4.17.15"},
+		{Ecosystem: EcosystemNPM, Name: "lodash", From: "4.17.20", To: "4.17.21"},
+		{Ecosystem: EcosystemPyPI, Name: "requests", From: "2.28.0", To: "2.31.0"},
+	}
+
+	var got [][2]string
+	for _, f := range Review(changes, db) {
+		detail := f.Change.Name
+		if f.Vulnerability != nil {
+			detail = f.Vulnerability.ID + " " + f.Vulnerability.Severity + " " + f.Vulnerability.Fixed
+		}
+		got = append(got, [2]string{f.Kind, detail})
+	}
+
+	require.Equal(t, [][2]string{
+		{KindMajor, "example.com/parser/v2"},
+		{KindVulnerability, "GO-2024-0001 high 1.4.2"},
+		{KindPseudoVersion, "example.com/fork"},
+		{KindReplace, "example.com/lib"},
+		{KindMajor, "lodash"},
+		{KindVulnerability, "GHSA-aaaa-bbbb-cccc critical 4.17.21"},
+		{KindVulnerability, "PYSEC-2024-1  "},
+	}, got)
+}
+
+func TestReview_NilDB(t *testing.T) {
+	got := Review([]Change{{Ecosystem: EcosystemNPM, Name: "lodash", From: "4.17.20", To: "4.17.21"}}, nil)
+	require.Empty(t, got)
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "v1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.9", 1},
+		{"1.2.0-rc.1", "1.2.0", -1},
+		{"2.0.0rc1", "2.0.0", -1},
+		{"0.0.0-20240102150405-0123456789ab", "0.0.1", -1},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, compareVersions(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+	}
+}
@@ -0,0 +1,170 @@
+// Package deps reviews dependency manifest changes: it parses the
+// dependencies a diff adds or upgrades and flags risky ones, including
+// known vulnerabilities from a local OSV database.
+package deps
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"ai-code-reviewer/internal/diff"
+)
+
+// OSV ecosystem names.
+const (
+	EcosystemGo   = "Go"
+	EcosystemNPM  = "npm"
+	EcosystemPyPI = "PyPI"
+)
+
+// manifests maps the supported manifest file names to their ecosystem.
+var manifests = map[string]string{
+	"go.mod":           EcosystemGo,
+	"package.json":     EcosystemNPM,
+	"requirements.txt": EcosystemPyPI,
+}
+
+// Change is a dependency a manifest diff adds, upgrades or replaces.
+type Change struct {
+	Manifest  string
+	Ecosystem string
+	Name      string
+	// From is the version the diff removes, empty for new dependencies.
+	From string
+	// To is the version or version range the diff adds.
+	To string
+	// Replace is the target of a go.mod replace directive.
+	Replace string
+}
+
+// IsManifest reports whether file is a supported dependency manifest.
+func IsManifest(file string) bool {
+	_, ok := manifests[path.Base(file)]
+	return ok
+}
+
+// Changes parses the dependencies fd adds, upgrades or replaces. Only the
+// changed lines are read, so a dependency is identified by its line: an
+// added requirement whose name a removed line had is an upgrade.
+func Changes(fd diff.FileDiff) []Change {
+	eco, ok := manifests[path.Base(fd.Filename)]
+	if !ok {
+		return nil
+	}
+
+	removed := make(map[string]string)
+	type entry struct{ name, version, replace string }
+	var added []entry
+
+	for _, h := range fd.Hunks {
+		for _, l := range h.Lines {
+			if l.Type == diff.Context {
+				continue
+			}
+			name, version, replace, ok := parseLine(eco, l.Content)
+			if !ok {
+				continue
+			}
+			if l.Type == diff.Removed {
+				if replace == "" {
+					removed[baseModule(eco, name)] = version
+				}
+				continue
+			}
+			added = append(added, entry{name: name, version: version, replace: replace})
+		}
+	}
+
+	var out []Change
+	for _, e := range added {
+		c := Change{Manifest: fd.Filename, Ecosystem: eco, Name: e.name, To: e.version, Replace: e.replace}
+		if e.replace == "" {
+			c.From = removed[baseModule(eco, e.name)]
+			// moved, e.g. from the indirect to the direct requirements
+			if c.From == c.To {
+				continue
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+var (
+	goModKeywords = []string{"module", "go", "toolchain", "godebug", "exclude", "retract"}
+	npmNonDeps    = []string{"version", "node", "npm", "yarn", "pnpm"}
+	npmEntry      = regexp.MustCompile(`^\s*"([^"]+)"\s*:\s*"([^"]*)"\s*,?\s*$`)
+	pipEntry      = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?\s*(?:===|==|~=|>=|<=|!=|>|<)\s*([A-Za-z0-9._*+!-]+)`)
+	goMajorSuffix = regexp.MustCompile(`/v[0-9]+$`)
+)
+
+// parseLine reads one manifest line. replace is set for go.mod replace
+// directives, whose name is the replaced module.
+func parseLine(eco, line string) (name, version, replace string, ok bool) {
+	switch eco {
+	case EcosystemGo:
+		return parseGoModLine(line)
+	case EcosystemNPM:
+		m := npmEntry.FindStringSubmatch(line)
+		if m == nil || slices.Contains(npmNonDeps, m[1]) || !startsWithDigit(baseVersion(m[2])) {
+			return "", "", "", false
+		}
+		return m[1], m[2], "", true
+	default:
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			return "", "", "", false
+		}
+		m := pipEntry.FindStringSubmatch(line)
+		if m == nil {
+			return "", "", "", false
+		}
+		return normalizePyPI(m[1]), m[2], "", true
+	}
+}
+
+func parseGoModLine(line string) (name, version, replace string, ok bool) {
+	line, _, _ = strings.Cut(line, "//")
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "require ")
+	line = strings.TrimPrefix(line, "replace ")
+
+	if left, right, isReplace := strings.Cut(line, "=>"); isReplace {
+		old := strings.Fields(left)
+		target := strings.Fields(right)
+		if len(old) == 0 || len(target) == 0 {
+			return "", "", "", false
+		}
+		if len(old) > 1 {
+			version = old[1]
+		}
+		return old[0], version, strings.Join(target, " "), true
+	}
+
+	f := strings.Fields(line)
+	if len(f) != 2 || slices.Contains(goModKeywords, f[0]) || !strings.HasPrefix(f[1], "v") {
+		return "", "", "", false
+	}
+	return f[0], f[1], "", true
+}
+
+// baseModule drops the major version suffix of a Go module path, so
+// example.com/m/v2 upgrades example.com/m.
+func baseModule(eco, name string) string {
+	if eco != EcosystemGo {
+		return name
+	}
+	return goMajorSuffix.ReplaceAllString(name, "")
+}
+
+// normalizePyPI applies the PEP 503 name normalization.
+func normalizePyPI(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer("_", "-", ".", "-").Replace(name)
+}
+
+func startsWithDigit(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
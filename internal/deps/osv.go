@@ -0,0 +1,204 @@
+package deps
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Vulnerability is an OSV advisory that affects a dependency version.
+type Vulnerability struct {
+	ID       string
+	Summary  string
+	Severity string
+	// Fixed is the first fixed version of the affected range, if known.
+	Fixed string
+}
+
+// osvEntry is the part of the OSV schema the checks read.
+// See https://ossf.github.io/osv-schema/.
+type osvEntry struct {
+	ID               string        `json:"id"`
+	Summary          string        `json:"summary"`
+	Withdrawn        string        `json:"withdrawn"`
+	Affected         []osvAffected `json:"affected"`
+	DatabaseSpecific osvSpecific   `json:"database_specific"`
+}
+
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges []struct {
+		Type   string `json:"type"`
+		Events []struct {
+			Introduced   string `json:"introduced"`
+			Fixed        string `json:"fixed"`
+			LastAffected string `json:"last_affected"`
+		} `json:"events"`
+	} `json:"ranges"`
+	Versions         []string    `json:"versions"`
+	DatabaseSpecific osvSpecific `json:"database_specific"`
+}
+
+type osvSpecific struct {
+	Severity string `json:"severity"`
+}
+
+// DB is an offline OSV database. A nil DB has no advisories.
+type DB struct {
+	// byPackage maps ecosystem and package name to the entries affecting it.
+	byPackage map[[2]string][]*osvEntry
+}
+
+// LoadDB reads an OSV dump: a JSON file holding one entry or an array of
+// them, or a directory of such files, like the per-ecosystem archives at
+// https://osv-vulnerabilities.storage.googleapis.com.
+func LoadDB(path string) (*DB, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("read osv database: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, fmt.Errorf("list osv database: %w", err)
+		}
+	}
+
+	db := &DB{byPackage: make(map[[2]string][]*osvEntry)}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read osv database: %w", err)
+		}
+		entries, err := decodeEntries(b)
+		if err != nil {
+			return nil, fmt.Errorf("decode %s: %w", filepath.Base(f), err)
+		}
+		for _, e := range entries {
+			db.add(e)
+		}
+	}
+	return db, nil
+}
+
+func decodeEntries(b []byte) ([]*osvEntry, error) {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		var entries []*osvEntry
+		err := json.Unmarshal(b, &entries)
+		return entries, err
+	}
+	var e osvEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	return []*osvEntry{&e}, nil
+}
+
+func (db *DB) add(e *osvEntry) {
+	if e.Withdrawn != "" {
+		return
+	}
+	seen := make(map[[2]string]bool)
+	for _, a := range e.Affected {
+		k := packageKey(a.Package.Ecosystem, a.Package.Name)
+		if !seen[k] {
+			seen[k] = true
+			db.byPackage[k] = append(db.byPackage[k], e)
+		}
+	}
+}
+
+// Len returns the number of packages with advisories.
+func (db *DB) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.byPackage)
+}
+
+// Vulnerabilities returns the advisories affecting version of a package.
+func (db *DB) Vulnerabilities(ecosystem, name, version string) []Vulnerability {
+	if db == nil || version == "" {
+		return nil
+	}
+
+	var out []Vulnerability
+	for _, e := range db.byPackage[packageKey(ecosystem, name)] {
+		for _, a := range e.Affected {
+			if packageKey(a.Package.Ecosystem, a.Package.Name) != packageKey(ecosystem, name) {
+				continue
+			}
+			fixed, ok := a.affects(version)
+			if !ok {
+				continue
+			}
+			severity := a.DatabaseSpecific.Severity
+			if severity == "" {
+				severity = e.DatabaseSpecific.Severity
+			}
+			out = append(out, Vulnerability{
+				ID:       e.ID,
+				Summary:  e.Summary,
+				Severity: strings.ToLower(severity),
+				Fixed:    fixed,
+			})
+			break
+		}
+	}
+	return out
+}
+
+// affects reports whether version falls in the affected versions or one of
+// the SEMVER or ECOSYSTEM ranges, and returns the range's fixed version.
+func (a osvAffected) affects(version string) (fixed string, ok bool) {
+	for _, v := range a.Versions {
+		if compareVersions(v, version) == 0 {
+			return "", true
+		}
+	}
+
+	for _, r := range a.Ranges {
+		if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+			continue
+		}
+		// events are sorted; each introduced opens a range that the next
+		// fixed or last_affected closes
+		in := false
+		for _, ev := range r.Events {
+			switch {
+			case ev.Introduced != "":
+				in = ev.Introduced == "0" || compareVersions(version, ev.Introduced) >= 0
+			case ev.Fixed != "":
+				if in && compareVersions(version, ev.Fixed) < 0 {
+					return ev.Fixed, true
+				}
+				in = false
+			case ev.LastAffected != "":
+				if in && compareVersions(version, ev.LastAffected) <= 0 {
+					return "", true
+				}
+				in = false
+			}
+		}
+		if in {
+			return "", true
+		}
+	}
+	return "", false
+}
+
+func packageKey(ecosystem, name string) [2]string {
+	if ecosystem == EcosystemPyPI {
+		name = normalizePyPI(name)
+	}
+	return [2]string{ecosystem, name}
+}
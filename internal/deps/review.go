@@ -0,0 +1,120 @@
+package deps
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Finding kinds.
+const (
+	KindMajor         = "major"
+	KindPseudoVersion = "pseudo-version"
+	KindReplace       = "replace"
+	KindVulnerability = "vulnerability"
+)
+
+// Finding flags a risky dependency change.
+type Finding struct {
+	Change Change
+	Kind   string
+	// Vulnerability is set for KindVulnerability findings.
+	Vulnerability *Vulnerability
+}
+
+// goPseudoVersion matches the timestamp and commit hash that end a Go
+// pseudo-version, e.g. v0.0.0-20240102150405-0123456789ab.
+var goPseudoVersion = regexp.MustCompile(`[.-]\d{14}-[0-9a-f]{12}(?:\+incompatible)?$`)
+
+// Review flags the changes that jump a major version, pin a Go
+// pseudo-version, replace a module, or add a version db knows to be
+// vulnerable. db may be nil.
+func Review(changes []Change, db *DB) []Finding {
+	var out []Finding
+	for _, c := range changes {
+		if c.Replace != "" {
+			out = append(out, Finding{Change: c, Kind: KindReplace})
+			continue
+		}
+
+		to := baseVersion(c.To)
+		if c.From != "" && major(to) > major(baseVersion(c.From)) {
+			out = append(out, Finding{Change: c, Kind: KindMajor})
+		}
+		if c.Ecosystem == EcosystemGo && goPseudoVersion.MatchString(c.To) {
+			out = append(out, Finding{Change: c, Kind: KindPseudoVersion})
+		}
+		for _, v := range db.Vulnerabilities(c.Ecosystem, c.Name, to) {
+			out = append(out, Finding{Change: c, Kind: KindVulnerability, Vulnerability: &v})
+		}
+	}
+	return out
+}
+
+// baseVersion is the lowest version a requirement allows: a Go version
+// without its "v", or an npm or pip range without its operator.
+func baseVersion(v string) string {
+	v = strings.TrimSpace(v)
+	if i := strings.IndexAny(v, " ,|"); i >= 0 {
+		v = v[:i]
+	}
+	v = strings.TrimLeft(v, "^~=<>!v")
+	return strings.TrimSuffix(v, "+incompatible")
+}
+
+// major returns the leading number of v, or -1.
+func major(v string) int {
+	head, _, _ := strings.Cut(v, ".")
+	n, err := strconv.Atoi(head)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// compareVersions orders dotted versions numerically, with a pre-release
+// ("1.2.0-rc.1", "1.2.0rc1") before its release. It is not a full
+// implementation of any ecosystem's rules but orders the versions found in
+// manifests and OSV ranges.
+func compareVersions(a, b string) int {
+	a, b = baseVersion(a), baseVersion(b)
+	ra, pa := splitPrerelease(a)
+	rb, pb := splitPrerelease(b)
+
+	fa, fb := strings.Split(ra, "."), strings.Split(rb, ".")
+	for i := range max(len(fa), len(fb)) {
+		var x, y int
+		if i < len(fa) {
+			x, _ = strconv.Atoi(fa[i])
+		}
+		if i < len(fb) {
+			y, _ = strconv.Atoi(fb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	switch {
+	case pa == pb:
+		return 0
+	case pa == "":
+		return 1
+	case pb == "":
+		return -1
+	}
+	return strings.Compare(pa, pb)
+}
+
+// splitPrerelease splits v at the first character that is neither a digit
+// nor a dot.
+func splitPrerelease(v string) (release, pre string) {
+	i := strings.IndexFunc(v, func(r rune) bool { return r != '.' && (r < '0' || r > '9') })
+	if i < 0 {
+		return v, ""
+	}
+	return strings.TrimRight(v[:i], "."), strings.TrimLeft(v[i:], "-.+")
+}
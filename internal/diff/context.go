@@ -15,20 +15,21 @@ func (f FileDiff) ToAIContext() string {
 		b.WriteString("Hunk:\n")
 
 		for _, l := range h.Lines {
-
-			prefix := " "
-			if l.Type == Added {
-				prefix = "+"
-			}
-			if l.Type == Removed {
-				prefix = "-"
-			}
-
-			b.WriteString(
-				prefix + l.Content + "\n",
-			)
+			b.WriteString(l.String() + "\n")
 		}
 	}
 
 	return b.String()
 }
+
+// String renders the line with its unified diff prefix.
+func (l Line) String() string {
+	switch l.Type {
+	case Added:
+		return "+" + l.Content
+	case Removed:
+		return "-" + l.Content
+	default:
+		return " " + l.Content
+	}
+}
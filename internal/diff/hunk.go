@@ -10,6 +10,9 @@ var hunkRe = regexp.MustCompile(`@@ -(\d+),?\d* \+(\d+),?\d* @@`)
 func parseHunkHeader(line string) Hunk {
 
 	m := hunkRe.FindStringSubmatch(line)
+	if m == nil {
+		return Hunk{}
+	}
 
 	oldStart, _ := strconv.Atoi(m[1])
 	newStart, _ := strconv.Atoi(m[2])
@@ -1,9 +1,20 @@
 package diff
 
-func parseLine(raw string, h *Hunk) Line {
+// position tracks the next old/new line numbers while reading a hunk.
+type position struct {
+	old int
+	new int
+}
+
+// parseLine converts one hunk body line. It returns false for lines that
+// are not part of the file, such as "\ No newline at end of file".
+func parseLine(raw string, pos *position) (Line, bool) {
 
 	if len(raw) == 0 {
-		return Line{Type: Context, Content: ""}
+		l := Line{Type: Context, Content: "", OldNumber: pos.old, NewNumber: pos.new}
+		pos.old++
+		pos.new++
+		return l, true
 	}
 
 	switch raw[0] {
@@ -12,29 +23,32 @@ func parseLine(raw string, h *Hunk) Line {
 		l := Line{
 			Type:      Added,
 			Content:   raw[1:],
-			NewNumber: h.NewStart,
+			NewNumber: pos.new,
 		}
-		h.NewStart++
-		return l
+		pos.new++
+		return l, true
 
 	case '-':
 		l := Line{
 			Type:      Removed,
 			Content:   raw[1:],
-			OldNumber: h.OldStart,
+			OldNumber: pos.old,
 		}
-		h.OldStart++
-		return l
+		pos.old++
+		return l, true
+
+	case '\\':
+		return Line{}, false
 
 	default:
 		l := Line{
 			Type:      Context,
 			Content:   raw[1:],
-			OldNumber: h.OldStart,
-			NewNumber: h.NewStart,
+			OldNumber: pos.old,
+			NewNumber: pos.new,
 		}
-		h.OldStart++
-		h.NewStart++
-		return l
+		pos.old++
+		pos.new++
+		return l, true
 	}
 }
@@ -26,3 +26,20 @@ const (
 	Context LineType = "context"
 )
 
+// NewRange returns the first and last line of the hunk in the new version
+// of the file. ok is false for hunks that only remove lines.
+func (h Hunk) NewRange() (start, end int, ok bool) {
+	for _, l := range h.Lines {
+		if l.Type == Removed {
+			continue
+		}
+		if !ok || l.NewNumber < start {
+			start = l.NewNumber
+		}
+		if l.NewNumber > end {
+			end = l.NewNumber
+		}
+		ok = true
+	}
+	return start, end, ok
+}
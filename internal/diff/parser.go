@@ -5,13 +5,18 @@ import (
 	"strings"
 )
 
+// Parse parses a unified diff. Patches from the GitHub files API carry no
+// "diff --git" header; their hunks are returned in a single FileDiff with an
+// empty Filename for the caller to fill in.
 func Parse(patch string) ([]FileDiff, error) {
 
 	var files []FileDiff
 	var current *FileDiff
 	var hunk *Hunk
+	var pos position
 
 	scanner := bufio.NewScanner(strings.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -23,11 +28,12 @@ func Parse(patch string) ([]FileDiff, error) {
 			}
 
 			current = &FileDiff{}
+			hunk = nil
 			continue
 		}
 
 		// Filename
-		if strings.HasPrefix(line, "+++ b/") {
+		if hunk == nil && strings.HasPrefix(line, "+++ b/") {
 			if current != nil {
 				current.Filename = strings.TrimPrefix(line, "+++ b/")
 			}
@@ -37,19 +43,21 @@ func Parse(patch string) ([]FileDiff, error) {
 		// Hunk start
 		if strings.HasPrefix(line, "@@") {
 			if current == nil {
-				continue
+				current = &FileDiff{}
 			}
 
 			h := parseHunkHeader(line)
-			hunk = &h
-			current.Hunks = append(current.Hunks, *hunk)
+			current.Hunks = append(current.Hunks, h)
+			hunk = &current.Hunks[len(current.Hunks)-1]
+			pos = position{old: h.OldStart, new: h.NewStart}
 			continue
 		}
 
 		// Content lines
 		if hunk != nil {
-			l := parseLine(line, hunk)
-			hunk.Lines = append(hunk.Lines, l)
+			if l, ok := parseLine(line, &pos); ok {
+				hunk.Lines = append(hunk.Lines, l)
+			}
 		}
 	}
 
@@ -57,5 +65,5 @@ func Parse(patch string) ([]FileDiff, error) {
 		files = append(files, *current)
 	}
 
-	return files, nil
+	return files, scanner.Err()
 }
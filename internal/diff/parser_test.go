@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_GitDiff(t *testing.T) {
+	patch := "diff --git a/main.go b/main.go\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -10,3 +10,4 @@ func main() {\n" +
+		" a := 1\n" +
+		"-b := 2\n" +
+		"+b := 3\n" +
+		"+c := 4\n" +
+		" fmt.Println(a)\n" +
+		"\\ No newline at end of file\n"
+
+	files, err := Parse(patch)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "main.go", files[0].Filename)
+
+	h := files[0].Hunks[0]
+	require.Equal(t, 10, h.NewStart)
+	require.Len(t, h.Lines, 5)
+	require.Equal(t, Line{Type: Added, Content: "c := 4", NewNumber: 12}, h.Lines[3])
+	require.Equal(t, Line{Type: Context, Content: "fmt.Println(a)", OldNumber: 12, NewNumber: 13}, h.Lines[4])
+
+	start, end, ok := h.NewRange()
+	require.True(t, ok)
+	require.Equal(t, 10, start)
+	require.Equal(t, 13, end)
+}
+
+func TestParse_FilesAPIPatch(t *testing.T) {
+	patch := "@@ -1,1 +1,2 @@\n-old\n+new\n+more\n@@ -20 +21 @@\n-x\n+y\n"
+
+	files, err := Parse(patch)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Empty(t, files[0].Filename)
+	require.Len(t, files[0].Hunks, 2)
+	require.Equal(t, 21, files[0].Hunks[1].Lines[1].NewNumber)
+	require.Contains(t, files[0].ToAIContext(), "+more\n")
+}
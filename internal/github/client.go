@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"strings"
 	"time"
 
 	"ai-code-reviewer/internal/config"
@@ -29,10 +31,13 @@ type client struct {
 const (
 	githubAcceptJSON      = "application/vnd.github+json"
 	githubAcceptDiff      = "application/vnd.github.diff"
+	githubAcceptRaw       = "application/vnd.github.raw"
 	githubContentTypeJSON = "application/json"
 	githubUserAgent       = "ai-code-reviewer"
 	httpStatusOK          = 200
 	httpStatusForbidden   = 403
+	httpStatusNotFound    = 404
+	maxFileContentBytes   = 1 << 20 // 1 MiB
 	maxResponseBodyLog    = 4096
 )
 
@@ -97,6 +102,8 @@ func (c *client) getToken(ctx context.Context) (string, error) {
 	return r.Token, nil
 }
 
+// GetPRFiles returns every changed file. Only IsReviewable files go to the
+// AI; the rest are still scanned for secrets.
 func (c *client) GetPRFiles(ctx context.Context, repo string, pr int) ([]PRFile, error) {
 
 	var files []PRFile
@@ -146,20 +153,19 @@ func (c *client) GetPRFiles(ctx context.Context, repo string, pr int) ([]PRFile,
 		return nil
 	})
 
-	// Filter only reviewable files
-	var result []PRFile
+	reviewable := 0
 	for _, f := range files {
 		if IsReviewable(f) {
-			result = append(result, f)
+			reviewable++
 		}
 	}
 
 	c.logger.Info("files fetched",
 		"total", len(files),
-		"reviewable", len(result),
+		"reviewable", reviewable,
 	)
 
-	return result, err
+	return files, err
 }
 
 func (c *client) GetPRDiff(ctx context.Context, repo string, pr int) (string, error) {
@@ -200,6 +206,154 @@ func (c *client) GetPRDiff(ctx context.Context, repo string, pr int) (string, er
 	return string(b), nil
 }
 
+func (c *client) GetPR(ctx context.Context, repo string, pr int) (PRMeta, error) {
+
+	url := fmt.Sprintf(
+		"https://api.github.com/repos/%s/pulls/%d",
+		repo, pr,
+	)
+
+	var out PullRequest
+	if err := c.getJSON(ctx, url, &out); err != nil {
+		return PRMeta{}, fmt.Errorf("get pr: %w", err)
+	}
+
+	return PRMeta{
+		Number:  out.Number,
+		Title:   out.Title,
+		Body:    out.Body,
+		Author:  out.User.Login,
+		BaseRef: out.Base.Ref,
+		HeadSHA: out.Head.SHA,
+	}, nil
+}
+
+func (c *client) GetIssue(ctx context.Context, repo string, number int) (Issue, error) {
+
+	url := fmt.Sprintf(
+		"https://api.github.com/repos/%s/issues/%d",
+		repo, number,
+	)
+
+	var out struct {
+		Number int    `json:"number"`
+		Title  string `json:"title"`
+		Body   string `json:"body"`
+	}
+	if err := c.getJSON(ctx, url, &out); err != nil {
+		return Issue{}, fmt.Errorf("get issue: %w", err)
+	}
+
+	return Issue{Number: out.Number, Title: out.Title, Body: out.Body}, nil
+}
+
+func (c *client) GetFileContent(ctx context.Context, repo, path, ref string) (string, error) {
+
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", contentsURL(repo, path, ref), nil)
+	if err != nil {
+		return "", fmt.Errorf("build contents request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", githubAcceptRaw)
+	req.Header.Set("User-Agent", githubUserAgent)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == httpStatusNotFound {
+		return "", ErrNotFound
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBodyLog))
+		return "", fmt.Errorf("github contents status %d: %s", res.StatusCode, string(msg))
+	}
+
+	b, err := io.ReadAll(io.LimitReader(res.Body, maxFileContentBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("read contents response: %w", err)
+	}
+	if len(b) > maxFileContentBytes {
+		return "", fmt.Errorf("file %s exceeds %d bytes", path, maxFileContentBytes)
+	}
+
+	return string(b), nil
+}
+
+func (c *client) ListDir(ctx context.Context, repo, dir, ref string) ([]string, error) {
+
+	var entries []struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	if err := c.getJSON(ctx, contentsURL(repo, dir, ref), &entries); err != nil {
+		return nil, fmt.Errorf("list dir: %w", err)
+	}
+
+	var out []string
+	for _, e := range entries {
+		if e.Type == "file" {
+			out = append(out, e.Path)
+		}
+	}
+	return out, nil
+}
+
+// contentsURL escapes each segment of path, so names with "#", "?", "%"
+// or spaces address the right file.
+func contentsURL(repo, path, ref string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, seg := range segments {
+		segments[i] = url.PathEscape(seg)
+	}
+	return fmt.Sprintf(
+		"https://api.github.com/repos/%s/contents/%s?ref=%s",
+		repo, strings.Join(segments, "/"), url.QueryEscape(ref),
+	)
+}
+
+// getJSON performs an authenticated GET and decodes the JSON response.
+func (c *client) getJSON(ctx context.Context, endpoint string, out any) error {
+
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", githubAcceptJSON)
+	req.Header.Set("User-Agent", githubUserAgent)
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseBodyLog))
+		return fmt.Errorf("github status %d: %s", res.StatusCode, string(msg))
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
 func loadPrivateKey(path string) (*rsa.PrivateKey, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
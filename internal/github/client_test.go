@@ -0,0 +1,22 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentsURL(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"internal/worker/processor.go", "https://api.github.com/repos/acme/repo/contents/internal/worker/processor.go?ref=feature%2Fx"},
+		{"/docs/my notes.md", "https://api.github.com/repos/acme/repo/contents/docs/my%20notes.md?ref=feature%2Fx"},
+		{"web/#issue?.ts", "https://api.github.com/repos/acme/repo/contents/web/%23issue%3F.ts?ref=feature%2Fx"},
+		{"data/100%.go", "https://api.github.com/repos/acme/repo/contents/data/100%25.go?ref=feature%2Fx"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, contentsURL("acme/repo", tt.path, "feature/x"), tt.path)
+	}
+}
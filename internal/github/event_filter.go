@@ -0,0 +1,136 @@
+package github
+
+import (
+	"path"
+	"strings"
+
+	"ai-code-reviewer/internal/config"
+)
+
+const (
+	userTypeBot  = "Bot"
+	botSuffix    = "[bot]"
+	dependabotID = "dependabot"
+	renovateID   = "renovate"
+)
+
+// eventFilter returns a non-empty reason when the event must be skipped.
+type eventFilter func(e PullRequestEvent, p config.FilterPolicy) string
+
+// eventFilters run in order; the first reason wins.
+var eventFilters = []eventFilter{
+	filterDraft,
+	filterAction,
+	filterAuthor,
+	filterLabels,
+	filterBranch,
+	filterTitle,
+}
+
+func skipReason(e PullRequestEvent, p config.FilterPolicy) string {
+	for _, f := range eventFilters {
+		if reason := f(e, p); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func filterDraft(e PullRequestEvent, _ config.FilterPolicy) string {
+	if e.PullRequest.Draft {
+		return "draft pr"
+	}
+	return ""
+}
+
+func filterAction(e PullRequestEvent, _ config.FilterPolicy) string {
+	if e.Action != prActionOpened && e.Action != prActionSynchronize {
+		return "action ignored: " + e.Action
+	}
+	return ""
+}
+
+func filterAuthor(e PullRequestEvent, p config.FilterPolicy) string {
+	login := strings.ToLower(e.PullRequest.User.Login)
+
+	if containsLogin(p.DenyAuthors, login) {
+		return "author denied: " + e.PullRequest.User.Login
+	}
+
+	if len(p.AllowAuthors) > 0 {
+		if containsLogin(p.AllowAuthors, login) {
+			return ""
+		}
+		return "author not allowed: " + e.PullRequest.User.Login
+	}
+
+	if !isBot(e) || !p.ShouldSkipBots() {
+		return ""
+	}
+
+	name := strings.TrimSuffix(login, botSuffix)
+	if strings.HasPrefix(name, dependabotID) && p.DependabotAllowed() {
+		return ""
+	}
+	if strings.HasPrefix(name, renovateID) && p.RenovateAllowed() {
+		return ""
+	}
+	return "bot author: " + e.PullRequest.User.Login
+}
+
+func filterLabels(e PullRequestEvent, p config.FilterPolicy) string {
+	labels := make(map[string]bool, len(e.PullRequest.Labels))
+	for _, l := range e.PullRequest.Labels {
+		labels[strings.ToLower(l.Name)] = true
+	}
+
+	for _, l := range p.ExcludedLabels {
+		if labels[strings.ToLower(l)] {
+			return "excluded label: " + l
+		}
+	}
+	for _, l := range p.RequiredLabels {
+		if !labels[strings.ToLower(l)] {
+			return "missing required label: " + l
+		}
+	}
+	return ""
+}
+
+func filterBranch(e PullRequestEvent, p config.FilterPolicy) string {
+	if len(p.Branches) == 0 {
+		return ""
+	}
+
+	base := e.PullRequest.Base.Ref
+	for _, pattern := range p.Branches {
+		if ok, _ := path.Match(pattern, base); ok {
+			return ""
+		}
+	}
+	return "base branch not reviewed: " + base
+}
+
+func filterTitle(e PullRequestEvent, p config.FilterPolicy) string {
+	title := strings.ToLower(e.PullRequest.Title)
+	for _, m := range p.TitleMarkers() {
+		if m != "" && strings.Contains(title, strings.ToLower(m)) {
+			return "title marker: " + m
+		}
+	}
+	return ""
+}
+
+func isBot(e PullRequestEvent) bool {
+	return e.PullRequest.User.Type == userTypeBot ||
+		strings.HasSuffix(strings.ToLower(e.PullRequest.User.Login), botSuffix)
+}
+
+func containsLogin(list []string, login string) bool {
+	for _, l := range list {
+		if strings.EqualFold(strings.TrimSpace(l), login) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package github
+
+import (
+	"testing"
+
+	"ai-code-reviewer/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func prEvent(login, userType, title, base string, labels ...string) PullRequestEvent {
+	var e PullRequestEvent
+	e.Action = prActionOpened
+	e.PullRequest.User.Login = login
+	e.PullRequest.User.Type = userType
+	e.PullRequest.Title = title
+	e.PullRequest.Base.Ref = base
+	for _, l := range labels {
+		e.PullRequest.Labels = append(e.PullRequest.Labels, Label{Name: l})
+	}
+	return e
+}
+
+func TestSkipReason(t *testing.T) {
+	yes := true
+	no := false
+
+	tests := []struct {
+		name   string
+		event  PullRequestEvent
+		policy config.FilterPolicy
+		skip   bool
+	}{
+		{name: "human containing bot substring", event: prEvent("abbott", "User", "fix", "main")},
+		{name: "bot by type", event: prEvent("ci-runner", "Bot", "fix", "main"), skip: true},
+		{name: "bot by login suffix", event: prEvent("github-actions[bot]", "", "fix", "main"), skip: true},
+		{name: "bots allowed", event: prEvent("ci-runner", "Bot", "fix", "main"), policy: config.FilterPolicy{SkipBots: &no}},
+		{name: "dependabot skipped by default", event: prEvent("dependabot[bot]", "Bot", "bump", "main"), skip: true},
+		{name: "dependabot opt-in", event: prEvent("dependabot[bot]", "Bot", "bump", "main"), policy: config.FilterPolicy{AllowDependabot: &yes}},
+		{name: "renovate opt-in", event: prEvent("renovate[bot]", "Bot", "bump", "main"), policy: config.FilterPolicy{AllowRenovate: &yes}},
+		{name: "deny list", event: prEvent("Mallory", "User", "fix", "main"), policy: config.FilterPolicy{DenyAuthors: []string{"mallory"}}, skip: true},
+		{name: "allow list excludes others", event: prEvent("bob", "User", "fix", "main"), policy: config.FilterPolicy{AllowAuthors: []string{"alice"}}, skip: true},
+		{name: "allow list admits bot", event: prEvent("ci-runner", "Bot", "fix", "main"), policy: config.FilterPolicy{AllowAuthors: []string{"ci-runner"}}},
+		{name: "excluded label", event: prEvent("alice", "User", "fix", "main", "WIP"), policy: config.FilterPolicy{ExcludedLabels: []string{"wip"}}, skip: true},
+		{name: "missing required label", event: prEvent("alice", "User", "fix", "main"), policy: config.FilterPolicy{RequiredLabels: []string{"ai-review"}}, skip: true},
+		{name: "required label present", event: prEvent("alice", "User", "fix", "main", "ai-review"), policy: config.FilterPolicy{RequiredLabels: []string{"ai-review"}}},
+		{name: "branch pattern match", event: prEvent("alice", "User", "fix", "release/1.2"), policy: config.FilterPolicy{Branches: []string{"main", "release/*"}}},
+		{name: "branch pattern miss", event: prEvent("alice", "User", "fix", "feature/x"), policy: config.FilterPolicy{Branches: []string{"main", "release/*"}}, skip: true},
+		{name: "default title marker", event: prEvent("alice", "User", "Docs tweak [Skip AI]", "main"), skip: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := skipReason(tt.event, tt.policy)
+			require.Equal(t, tt.skip, reason != "", "reason: %q", reason)
+		})
+	}
+}
+
+func TestRepoConfigOverridesDefault(t *testing.T) {
+	rc := config.RepoConfig{
+		Default: config.RepoSettings{Filters: config.FilterPolicy{DenyAuthors: []string{"mallory"}}},
+		Repos: map[string]config.RepoSettings{
+			"acme/api": {Filters: config.FilterPolicy{Branches: []string{"main"}}},
+		},
+	}
+
+	p := rc.ForRepo("acme/api").Filters
+	require.Equal(t, []string{"mallory"}, p.DenyAuthors)
+	require.Equal(t, []string{"main"}, p.Branches)
+	require.Empty(t, rc.ForRepo("acme/web").Filters.Branches)
+}
@@ -15,8 +15,11 @@ type PullRequest struct {
 
 	User struct {
 		Login string `json:"login"`
+		Type  string `json:"type"`
 	} `json:"user"`
 
+	Labels []Label `json:"labels"`
+
 	Head struct {
 		SHA string `json:"sha"`
 	} `json:"head"`
@@ -29,6 +32,10 @@ type PullRequest struct {
 	Body  string `json:"body"`
 }
 
+type Label struct {
+	Name string `json:"name"`
+}
+
 type Repository struct {
 	FullName string `json:"full_name"`
 }
@@ -1,6 +1,10 @@
 package github
 
-import "strings"
+import (
+	"strings"
+
+	"ai-code-reviewer/internal/deps"
+)
 
 var skipExt = []string{
 	".json", ".lock", ".sum", ".yaml",
@@ -8,7 +12,9 @@ var skipExt = []string{
 }
 
 var allowExt = []string{
-	".go", ".js", ".ts", ".py",
+	".go", ".py",
+	".js", ".jsx", ".mjs", ".cjs",
+	".ts", ".tsx",
 }
 
 func IsReviewable(f PRFile) bool {
@@ -27,3 +33,9 @@ func IsReviewable(f PRFile) bool {
 
 	return false
 }
+
+// IsManifest reports whether f is a dependency manifest. Manifests are not
+// reviewable code but get a dependency review.
+func IsManifest(f PRFile) bool {
+	return deps.IsManifest(f.Filename)
+}
@@ -0,0 +1,32 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsReviewable(t *testing.T) {
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{file: "main.go", want: true},
+		{file: "app.py", want: true},
+		{file: "web/index.js", want: true},
+		{file: "web/App.jsx", want: true},
+		{file: "web/server.mjs", want: true},
+		{file: "web/config.cjs", want: true},
+		{file: "web/api.ts", want: true},
+		{file: "web/App.tsx", want: true},
+		{file: "package.json"},
+		{file: "README.md"},
+		{file: "main.rs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			require.Equal(t, tt.want, IsReviewable(PRFile{Filename: tt.file}))
+		})
+	}
+}
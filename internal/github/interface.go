@@ -1,10 +1,20 @@
 package github
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned when a requested file does not exist at the ref.
+var ErrNotFound = errors.New("not found")
 
 type Client interface {
 	GetPRFiles(ctx context.Context, repo string, pr int) ([]PRFile, error)
 	GetPRDiff(ctx context.Context, repo string, pr int) (string, error)
+	GetPR(ctx context.Context, repo string, pr int) (PRMeta, error)
+	GetIssue(ctx context.Context, repo string, number int) (Issue, error)
+	GetFileContent(ctx context.Context, repo, path, ref string) (string, error)
+	ListDir(ctx context.Context, repo, dir, ref string) ([]string, error)
 	CreateComment(ctx context.Context, repo string, pr int, body string) error
 	CreateLineComment(ctx context.Context, repo string, pr int, comment LineComment) error
 }
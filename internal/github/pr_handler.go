@@ -4,19 +4,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
+
+	"ai-code-reviewer/internal/delivery"
 )
 
 const (
 	prActionOpened      = "opened"
 	prActionSynchronize = "synchronize"
-	botLoginToken       = "bot"
 	enqueueTimeout      = 3 * time.Second
 	tenantFallback      = "default"
 )
 
-func (h *WebhookHandler) handlePullRequest(payload []byte) {
+// prResult describes what happened to a pull_request delivery and which
+// status GitHub should see for it.
+type prResult struct {
+	outcome delivery.Outcome
+	reason  string
+	status  int
+	repo    string
+	pr      int
+}
+
+func (h *WebhookHandler) handlePullRequest(payload []byte) prResult {
 
 	var event PullRequestEvent
 
@@ -24,40 +36,32 @@ func (h *WebhookHandler) handlePullRequest(payload []byte) {
 		h.logger.Error("failed to parse pr event",
 			"error", err,
 		)
-		return
+		return prResult{
+			outcome: delivery.OutcomeInvalid,
+			reason:  "invalid payload",
+			status:  http.StatusBadRequest,
+		}
+	}
+
+	res := prResult{
+		repo: event.Repository.FullName,
+		pr:   event.PullRequest.Number,
 	}
 
 	// ─────────────────────────────────────
 	// 1. FILTERS
 	// ─────────────────────────────────────
 
-	// Ignore draft PRs
-	if event.PullRequest.Draft {
-		h.logger.Info("draft pr ignored",
+	policy := h.cfg.Repos.ForRepo(event.Repository.FullName).Filters
+	if reason := skipReason(event, policy); reason != "" {
+		h.logger.Info("pr ignored",
 			"repo", event.Repository.FullName,
 			"pr", event.PullRequest.Number,
-		)
-		return
-	}
-
-	// Ignore bots
-	if strings.Contains(
-		strings.ToLower(event.PullRequest.User.Login),
-		botLoginToken,
-	) {
-		h.logger.Info("bot pr ignored",
 			"user", event.PullRequest.User.Login,
-		)
-		return
-	}
-
-	// Only specific actions
-	if event.Action != prActionOpened &&
-		event.Action != prActionSynchronize {
-		h.logger.Info("action ignored",
 			"action", event.Action,
+			"reason", reason,
 		)
-		return
+		return res.filtered(reason)
 	}
 
 	// ─────────────────────────────────────
@@ -83,7 +87,11 @@ func (h *WebhookHandler) handlePullRequest(payload []byte) {
 			"repo", event.Repository.FullName,
 			"pr", event.PullRequest.Number,
 		)
-		return
+		// 5xx makes GitHub redeliver once the queue is back.
+		res.outcome = delivery.OutcomeFailed
+		res.reason = "enqueue failed: " + err.Error()
+		res.status = http.StatusServiceUnavailable
+		return res
 	}
 
 	h.logger.Info("pr job queued",
@@ -91,6 +99,17 @@ func (h *WebhookHandler) handlePullRequest(payload []byte) {
 		"pr", event.PullRequest.Number,
 		"action", event.Action,
 	)
+
+	res.outcome = delivery.OutcomeQueued
+	res.status = http.StatusOK
+	return res
+}
+
+func (r prResult) filtered(reason string) prResult {
+	r.outcome = delivery.OutcomeFiltered
+	r.reason = reason
+	r.status = http.StatusAccepted
+	return r
 }
 
 func resolveTenant(event PullRequestEvent) string {
@@ -0,0 +1,26 @@
+package github
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// closingRefRe matches GitHub closing keywords such as "Fixes #123".
+var closingRefRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s*:?\s+#(\d+)\b`)
+
+// LinkedIssueNumbers returns the same-repo issues a PR body closes, in
+// order of appearance and without duplicates.
+func LinkedIssueNumbers(body string) []int {
+	var out []int
+	seen := make(map[int]bool)
+
+	for _, m := range closingRefRe.FindAllStringSubmatch(body, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n <= 0 || seen[n] {
+			continue
+		}
+		seen[n] = true
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,13 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkedIssueNumbers(t *testing.T) {
+	body := "Fixes #12 and closes: #7.\nRelated to #99, resolves #12, Fixed #3"
+	require.Equal(t, []int{12, 7, 3}, LinkedIssueNumbers(body))
+	require.Empty(t, LinkedIssueNumbers("see #4"))
+}
@@ -9,8 +9,17 @@ type PRFile struct {
 }
 
 type PRMeta struct {
+	Number  int
+	Title   string
+	Body    string
+	Author  string
+	BaseRef string
+	HeadSHA string
+	Files   []PRFile
+}
+
+type Issue struct {
 	Number int
 	Title  string
-	Author string
-	Files  []PRFile
+	Body   string
 }
@@ -1,38 +1,46 @@
 package github
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
 	"net/http"
+	"time"
 
 	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/delivery"
 	"ai-code-reviewer/internal/observability"
 )
 
 type WebhookHandler struct {
-	cfg    *config.Config
-	logger *observability.Logger
-	queue  JobQueue
+	cfg        *config.Config
+	logger     *observability.Logger
+	queue      JobQueue
+	deliveries delivery.Store
 }
 
 const (
 	maxWebhookBodyBytes = 1 << 20 // 1 MiB
 	headerGithubEvent   = "X-GitHub-Event"
+	headerGithubID      = "X-GitHub-Delivery"
 	headerSignature256  = "X-Hub-Signature-256"
 	eventPullRequest    = "pull_request"
+	outcomeUnauthorized = "unauthorized"
 )
 
 func NewWebhookHandler(
 	cfg *config.Config,
 	logger *observability.Logger,
 	queue JobQueue,
+	deliveries delivery.Store,
 ) *WebhookHandler {
 	return &WebhookHandler{
-		cfg:    cfg,
-		logger: logger,
-		queue:  queue,
+		cfg:        cfg,
+		logger:     logger,
+		queue:      queue,
+		deliveries: deliveries,
 	}
 }
 
@@ -46,27 +54,97 @@ func (h *WebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	payload, err := io.ReadAll(r.Body)
 	if err != nil {
+		observability.WebhookDeliveries.WithLabelValues(string(delivery.OutcomeInvalid)).Inc()
 		http.Error(w, "invalid body", http.StatusBadRequest)
 		return
 	}
 
 	if !h.verifySignature(r.Header.Get(headerSignature256), payload) {
 		h.logger.Error("invalid github signature")
+		observability.WebhookDeliveries.WithLabelValues(outcomeUnauthorized).Inc()
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
 	event := r.Header.Get(headerGithubEvent)
-	h.logger.Info("github event received", "event", event)
+	deliveryID := r.Header.Get(headerGithubID)
+	h.logger.Info("github event received", "event", event, "delivery", deliveryID)
+
+	rec := delivery.Record{
+		ID:         deliveryID,
+		Event:      event,
+		ReceivedAt: time.Now().UTC(),
+	}
+
+	if !h.claimDelivery(r.Context(), deliveryID) {
+		h.logger.Info("duplicate delivery ignored", "delivery", deliveryID)
+		rec.Outcome = delivery.OutcomeDuplicate
+		h.respond(w, r, rec, http.StatusOK)
+		return
+	}
+
+	status := http.StatusAccepted
 
 	switch event {
 	case eventPullRequest:
-		h.handlePullRequest(payload)
+		res := h.handlePullRequest(payload)
+		rec.Repo, rec.PR = res.repo, res.pr
+		rec.Outcome, rec.Reason = res.outcome, res.reason
+		status = res.status
 	default:
 		h.logger.Info("event ignored", "event", event)
+		rec.Outcome = delivery.OutcomeFiltered
+		rec.Reason = "event ignored: " + event
+	}
+
+	h.respond(w, r, rec, status)
+}
+
+// respond records the delivery outcome and writes the status GitHub acts on:
+// 2xx settles the delivery, 5xx lets GitHub redeliver it. Server errors
+// carry only the status text; their detail stays in the delivery log.
+func (h *WebhookHandler) respond(w http.ResponseWriter, r *http.Request, rec delivery.Record, status int) {
+	h.finishDelivery(r.Context(), rec)
+	observability.WebhookDeliveries.WithLabelValues(string(rec.Outcome)).Inc()
+
+	if status == http.StatusOK {
+		w.WriteHeader(status)
+		return
+	}
+
+	body := rec.Reason
+	if status >= http.StatusInternalServerError {
+		body = http.StatusText(status)
 	}
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body))
+}
+
+// claimDelivery reports whether the delivery should be processed. Lookup
+// errors fail open so a broken store never drops webhooks.
+func (h *WebhookHandler) claimDelivery(ctx context.Context, id string) bool {
+	if h.deliveries == nil || id == "" {
+		return true
+	}
+
+	ok, err := h.deliveries.Claim(ctx, id)
+	if err != nil {
+		h.logger.Error("delivery claim failed", "delivery", id, "error", err)
+		return true
+	}
+	return ok
+}
+
+func (h *WebhookHandler) finishDelivery(ctx context.Context, rec delivery.Record) {
+	if h.deliveries == nil || rec.ID == "" {
+		return
+	}
+
+	if err := h.deliveries.Finish(ctx, rec); err != nil {
+		h.logger.Error("delivery record failed", "delivery", rec.ID, "error", err)
+	}
 }
 
 func (h *WebhookHandler) verifySignature(signature string, body []byte) bool {
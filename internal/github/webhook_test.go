@@ -0,0 +1,111 @@
+package github
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/delivery"
+	"ai-code-reviewer/internal/observability"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "s3cret"
+
+type queueStub struct {
+	err   error
+	calls int
+}
+
+func (q *queueStub) Enqueue(ctx context.Context, tenant, repo string, pr int) error {
+	q.calls++
+	return q.err
+}
+
+func newTestHandler(q JobQueue, store delivery.Store) *WebhookHandler {
+	cfg := &config.Config{GithubSecret: testSecret, LogLevel: "info"}
+	return NewWebhookHandler(cfg, observability.NewLogger(cfg), q, store)
+}
+
+func sendWebhook(h *WebhookHandler, id, payload string) *httptest.ResponseRecorder {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(payload))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/github", strings.NewReader(payload))
+	req.Header.Set(headerGithubEvent, eventPullRequest)
+	req.Header.Set(headerGithubID, id)
+	req.Header.Set(headerSignature256, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	rec := httptest.NewRecorder()
+	h.Handle(rec, req)
+	return rec
+}
+
+const openedPayload = `{"action":"opened","pull_request":{"number":7,"user":{"login":"alice"}},"repository":{"full_name":"acme/repo"}}`
+
+func TestHandle_StatusCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		payload  string
+		queueErr error
+		status   int
+		body     string
+	}{
+		{name: "queued", payload: openedPayload, status: http.StatusOK},
+		{name: "enqueue failure", payload: openedPayload, queueErr: errors.New("redis down"), status: http.StatusServiceUnavailable, body: "Service Unavailable"},
+		{name: "parse failure", payload: `{"action":`, status: http.StatusBadRequest, body: "invalid payload"},
+		{name: "filtered", payload: `{"action":"closed","pull_request":{"number":7},"repository":{"full_name":"acme/repo"}}`, status: http.StatusAccepted, body: "action ignored: closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(&queueStub{err: tt.queueErr}, nil)
+
+			rec := sendWebhook(h, "", tt.payload)
+
+			require.Equal(t, tt.status, rec.Code)
+			require.Contains(t, rec.Body.String(), tt.body)
+		})
+	}
+}
+
+func TestHandle_SkipsDuplicateDelivery(t *testing.T) {
+	q := &queueStub{}
+	store := delivery.NewMemoryStore(time.Hour)
+	h := newTestHandler(q, store)
+
+	require.Equal(t, http.StatusOK, sendWebhook(h, "d-1", openedPayload).Code)
+	require.Equal(t, http.StatusOK, sendWebhook(h, "d-1", openedPayload).Code)
+	require.Equal(t, 1, q.calls)
+
+	recent, err := store.Recent(context.Background(), 10)
+	require.NoError(t, err)
+	require.Equal(t, delivery.OutcomeDuplicate, recent[0].Outcome)
+	require.Equal(t, delivery.OutcomeQueued, recent[1].Outcome)
+}
+
+func TestHandle_RetriesDeliveryAfterEnqueueFailure(t *testing.T) {
+	q := &queueStub{err: errors.New("redis down")}
+	h := newTestHandler(q, delivery.NewMemoryStore(time.Hour))
+
+	rec := sendWebhook(h, "d-1", openedPayload)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.NotContains(t, rec.Body.String(), "redis down")
+
+	recent, err := h.deliveries.Recent(context.Background(), 1)
+	require.NoError(t, err)
+	require.Equal(t, "enqueue failed: redis down", recent[0].Reason)
+
+	q.err = nil
+	require.Equal(t, http.StatusOK, sendWebhook(h, "d-1", openedPayload).Code)
+	require.Equal(t, 2, q.calls)
+}
@@ -79,6 +79,177 @@ func (_c *Provider_Review_Call) RunAndReturn(run func(context.Context, ai.Review
 	return _c
 }
 
+// Summarize provides a mock function with given fields: ctx, r
+func (_m *Provider) Summarize(ctx context.Context, r ai.SummaryRequest) (ai.ReviewResponse, error) {
+	ret := _m.Called(ctx, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Summarize")
+	}
+
+	var r0 ai.ReviewResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ai.SummaryRequest) (ai.ReviewResponse, error)); ok {
+		return rf(ctx, r)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ai.SummaryRequest) ai.ReviewResponse); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(ai.ReviewResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ai.SummaryRequest) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Provider_Summarize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Summarize'
+type Provider_Summarize_Call struct {
+	*mock.Call
+}
+
+// Summarize is a helper method to define mock.On call
+//   - ctx context.Context
+//   - r ai.SummaryRequest
+func (_e *Provider_Expecter) Summarize(ctx interface{}, r interface{}) *Provider_Summarize_Call {
+	return &Provider_Summarize_Call{Call: _e.mock.On("Summarize", ctx, r)}
+}
+
+func (_c *Provider_Summarize_Call) Run(run func(ctx context.Context, r ai.SummaryRequest)) *Provider_Summarize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(ai.SummaryRequest))
+	})
+	return _c
+}
+
+func (_c *Provider_Summarize_Call) Return(_a0 ai.ReviewResponse, _a1 error) *Provider_Summarize_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Provider_Summarize_Call) RunAndReturn(run func(context.Context, ai.SummaryRequest) (ai.ReviewResponse, error)) *Provider_Summarize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SuggestTests provides a mock function with given fields: ctx, r
+func (_m *Provider) SuggestTests(ctx context.Context, r ai.TestRequest) (ai.ReviewResponse, error) {
+	ret := _m.Called(ctx, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SuggestTests")
+	}
+
+	var r0 ai.ReviewResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ai.TestRequest) (ai.ReviewResponse, error)); ok {
+		return rf(ctx, r)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ai.TestRequest) ai.ReviewResponse); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(ai.ReviewResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ai.TestRequest) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Provider_SuggestTests_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SuggestTests'
+type Provider_SuggestTests_Call struct {
+	*mock.Call
+}
+
+// SuggestTests is a helper method to define mock.On call
+//   - ctx context.Context
+//   - r ai.TestRequest
+func (_e *Provider_Expecter) SuggestTests(ctx interface{}, r interface{}) *Provider_SuggestTests_Call {
+	return &Provider_SuggestTests_Call{Call: _e.mock.On("SuggestTests", ctx, r)}
+}
+
+func (_c *Provider_SuggestTests_Call) Run(run func(ctx context.Context, r ai.TestRequest)) *Provider_SuggestTests_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(ai.TestRequest))
+	})
+	return _c
+}
+
+func (_c *Provider_SuggestTests_Call) Return(_a0 ai.ReviewResponse, _a1 error) *Provider_SuggestTests_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Provider_SuggestTests_Call) RunAndReturn(run func(context.Context, ai.TestRequest) (ai.ReviewResponse, error)) *Provider_SuggestTests_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Verify provides a mock function with given fields: ctx, r
+func (_m *Provider) Verify(ctx context.Context, r ai.VerifyRequest) (ai.ReviewResponse, error) {
+	ret := _m.Called(ctx, r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Verify")
+	}
+
+	var r0 ai.ReviewResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, ai.VerifyRequest) (ai.ReviewResponse, error)); ok {
+		return rf(ctx, r)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, ai.VerifyRequest) ai.ReviewResponse); ok {
+		r0 = rf(ctx, r)
+	} else {
+		r0 = ret.Get(0).(ai.ReviewResponse)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, ai.VerifyRequest) error); ok {
+		r1 = rf(ctx, r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Provider_Verify_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Verify'
+type Provider_Verify_Call struct {
+	*mock.Call
+}
+
+// Verify is a helper method to define mock.On call
+//   - ctx context.Context
+//   - r ai.VerifyRequest
+func (_e *Provider_Expecter) Verify(ctx interface{}, r interface{}) *Provider_Verify_Call {
+	return &Provider_Verify_Call{Call: _e.mock.On("Verify", ctx, r)}
+}
+
+func (_c *Provider_Verify_Call) Run(run func(ctx context.Context, r ai.VerifyRequest)) *Provider_Verify_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(ai.VerifyRequest))
+	})
+	return _c
+}
+
+func (_c *Provider_Verify_Call) Return(_a0 ai.ReviewResponse, _a1 error) *Provider_Verify_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Provider_Verify_Call) RunAndReturn(run func(context.Context, ai.VerifyRequest) (ai.ReviewResponse, error)) *Provider_Verify_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewProvider creates a new instance of Provider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewProvider(t interface {
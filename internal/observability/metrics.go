@@ -57,10 +57,97 @@ var (
 		},
 		[]string{"scope"},
 	)
+
+	AIOutputRepairs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_ai_output_repairs_total",
+			Help: "Invalid AI output repaired by a corrective re-prompt, failed, or dropped issue by issue for structured output, by result",
+		},
+		[]string{"result"},
+	)
+
+	AIProviderRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_ai_provider_requests_total",
+			Help: "Provider chain attempts by provider and result (served, failed, rejected, skipped)",
+		},
+		[]string{"provider", "result"},
+	)
+
+	AIBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "ai_reviewer_ai_breaker_state",
+			Help: "Circuit breaker state per provider: 0 closed, 1 half-open, 2 open",
+		},
+		[]string{"provider"},
+	)
+
+	AICacheRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_ai_cache_requests_total",
+			Help: "AI response cache lookups by result (hit, miss, error)",
+		},
+		[]string{"result"},
+	)
+
+	AIVerifications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_ai_verifications_total",
+			Help: "Verification of candidate issues by verdict (yes, no, error, skipped)",
+		},
+		[]string{"verdict"},
+	)
+
+	StaticFindings = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_static_findings_total",
+			Help: "Static analysis findings on changed lines by analyzer",
+		},
+		[]string{"analyzer"},
+	)
+
+	StaticStdlibFallbacks = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_static_stdlib_fallbacks_total",
+			Help: "Standard library imports static analysis could not load from the Go toolchain",
+		},
+	)
+
+	SecretFindings = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_secret_findings_total",
+			Help: "Possible secrets in added lines by rule",
+		},
+		[]string{"rule"},
+	)
+
+	DependencyFindings = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_dependency_findings_total",
+			Help: "Flagged dependency changes in manifests by kind",
+		},
+		[]string{"kind"},
+	)
+
+	AIRedactions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_ai_redactions_total",
+			Help: "Values redacted from requests to external providers by provider and rule",
+		},
+		[]string{"provider", "rule"},
+	)
+
+	WebhookDeliveries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_reviewer_webhook_deliveries_total",
+			Help: "Total webhook deliveries by outcome",
+		},
+		[]string{"outcome"},
+	)
 )
 
 func InitMetrics() {
 	registerMetricsOnce.Do(func() {
-		prometheus.MustRegister(AICalls, AIErrors, AILatency, AITokens, AICostUSD, AIBudgetBlocks)
+		prometheus.MustRegister(AICalls, AIErrors, AILatency, AITokens, AICostUSD, AIBudgetBlocks, AIOutputRepairs, AIProviderRequests, AIBreakerState, AICacheRequests, AIVerifications, StaticFindings, StaticStdlibFallbacks, SecretFindings, DependencyFindings, AIRedactions, WebhookDeliveries)
 	})
 }
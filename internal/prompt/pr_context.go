@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	truncatedMarker   = "\n[truncated]"
+	maxChangedFiles   = 50
+	minSectionTokens  = 16
+	titleBudgetShare  = 4 // title and files get at most 1/4 of the budget
+	bodyBudgetDivisor = 2 // the description gets half of what is left
+)
+
+// PRContext describes the pull request a chunk belongs to.
+type PRContext struct {
+	Title        string
+	Body         string
+	BaseBranch   string
+	ChangedFiles []string
+	LinkedIssues []LinkedIssue
+}
+
+type LinkedIssue struct {
+	Number int
+	Title  string
+	Body   string
+}
+
+// Fit trims the context to roughly maxTokens, as measured by estimate.
+// The title, base branch and file list are kept first, then the PR
+// description, then linked issues share whatever budget remains.
+func (c PRContext) Fit(maxTokens int, estimate func(string) int) PRContext {
+	if maxTokens <= 0 {
+		return PRContext{}
+	}
+
+	out := PRContext{
+		Title:      truncateTokens(c.Title, maxTokens/titleBudgetShare, estimate),
+		BaseBranch: c.BaseBranch,
+	}
+	remaining := maxTokens - estimate(out.Title) - estimate(out.BaseBranch)
+
+	for _, f := range c.ChangedFiles {
+		if len(out.ChangedFiles) == maxChangedFiles || remaining-estimate(f) < maxTokens/titleBudgetShare {
+			break
+		}
+		out.ChangedFiles = append(out.ChangedFiles, f)
+		remaining -= estimate(f)
+	}
+
+	out.Body = truncateTokens(strings.TrimSpace(c.Body), remaining/bodyBudgetDivisor, estimate)
+	remaining -= estimate(out.Body)
+
+	for i, is := range c.LinkedIssues {
+		share := remaining / (len(c.LinkedIssues) - i)
+		if share < minSectionTokens {
+			break
+		}
+		is.Title = truncateTokens(is.Title, share/titleBudgetShare, estimate)
+		is.Body = truncateTokens(strings.TrimSpace(is.Body), share-estimate(is.Title), estimate)
+		out.LinkedIssues = append(out.LinkedIssues, is)
+		remaining -= estimate(is.Title) + estimate(is.Body)
+	}
+
+	return out
+}
+
+// truncateTokens cuts s to at most maxTokens by bisecting on rune length.
+func truncateTokens(s string, maxTokens int, estimate func(string) int) string {
+	if estimate(s) <= maxTokens {
+		return s
+	}
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	runes := []rune(s)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if estimate(string(runes[:mid])+truncatedMarker) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo == 0 {
+		return ""
+	}
+	return string(runes[:lo]) + truncatedMarker
+}
+
+func otherFiles(files []string, current []string) []string {
+	var out []string
+	for _, f := range files {
+		if !slices.Contains(current, f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,231 @@
+package prompt
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"ai-code-reviewer/internal/review"
+)
+
+// Version identifies the prompt templates and rule packs. Bump it whenever
+// anything under templates/ or rules/ changes.
+const Version = "9"
+
+const maxCorrectionEcho = 2000
+
+// Tasks select the templates a request is rendered with.
+const (
+	// TaskReview asks for the issues in the changes.
+	TaskReview = ""
+	// TaskVerify asks whether Input.Issue is a real problem.
+	TaskVerify = "verify"
+	// TaskSummary asks for a PR-level narrative from Input.Findings,
+	// Input.Diffstat and the PR context.
+	TaskSummary = "summary"
+	// TaskTests asks for a _test.go file covering the Go functions in
+	// Input.Content.
+	TaskTests = "tests"
+)
+
+// taskTemplates are the system and user templates of each task.
+var taskTemplates = map[string][2]string{
+	TaskReview:  {"system.tmpl", "user.tmpl"},
+	TaskVerify:  {"verify_system.tmpl", "verify_user.tmpl"},
+	TaskSummary: {"summary_system.tmpl", "summary_user.tmpl"},
+	TaskTests:   {"tests_system.tmpl", "tests_user.tmpl"},
+}
+
+//go:embed templates/*.tmpl rules/*.md
+var files embed.FS
+
+var templates = template.Must(
+	template.New("prompt").
+		Funcs(template.FuncMap{"join": strings.Join, "indent": indent}).
+		ParseFS(files, "templates/*.tmpl"),
+)
+
+var languages = map[string]string{
+	".go":  "go",
+	".py":  "python",
+	".ts":  "typescript",
+	".tsx": "typescript",
+	".js":  "javascript",
+	".jsx": "javascript",
+	".mjs": "javascript",
+	".cjs": "javascript",
+}
+
+var languageNames = map[string]string{
+	"go":         "Go",
+	"python":     "Python",
+	"typescript": "TypeScript",
+	"javascript": "JavaScript",
+}
+
+// Input is everything the templates can render.
+type Input struct {
+	Task         string
+	File         string
+	Content      string
+	Instructions string
+	PR           *PRContext
+	// Surrounding is head-version code around the changes, rendered with
+	// line numbers.
+	Surrounding string
+	// Files batches several small files into one request. When set, File,
+	// Content and Surrounding are ignored.
+	Files      []FileInput
+	Correction *Correction
+	// Issue is the finding to check for TaskVerify.
+	Issue *review.Issue
+	// Findings and Diffstat describe the whole PR for TaskSummary.
+	Findings []Finding
+	Diffstat []FileStat
+	// Package is the Go package of File for TaskTests.
+	Package string
+	// Hints are static analysis findings in File, listed so the model
+	// does not repeat them.
+	Hints []review.Issue
+}
+
+// FileInput is one file of a batched request.
+type FileInput struct {
+	File        string
+	Content     string
+	Surrounding string
+	Hints       []review.Issue
+}
+
+// Correction carries a rejected response back to the model.
+type Correction struct {
+	Previous string
+	Problem  string
+}
+
+type Messages struct {
+	System string
+	User   string
+}
+
+type templateData struct {
+	Input
+	LanguageName  string
+	Rules         string
+	OtherFiles    []string
+	Blocks        []FileInput
+	Batch         bool
+	Paths         []string
+	IssueExample  string
+	SchemaVersion int
+	Severities    []string
+	Categories    []string
+}
+
+// Build renders the system and user messages for a request.
+func Build(in Input) (Messages, error) {
+	names, ok := taskTemplates[in.Task]
+	if !ok {
+		return Messages{}, fmt.Errorf("unknown prompt task %q", in.Task)
+	}
+
+	data := templateData{
+		Input:         in,
+		SchemaVersion: review.SchemaVersion,
+		Severities:    review.Severities,
+		Categories:    review.Categories,
+	}
+
+	blocks := in.Files
+	if len(blocks) == 0 {
+		blocks = []FileInput{{File: in.File, Content: in.Content, Surrounding: in.Surrounding, Hints: in.Hints}}
+	}
+	for _, b := range blocks {
+		b.Content = strings.TrimRight(b.Content, "\n")
+		data.Blocks = append(data.Blocks, b)
+		data.Paths = append(data.Paths, b.File)
+	}
+	data.Batch = len(in.Files) > 0
+
+	if lang := commonLanguage(data.Paths); lang != "" {
+		data.LanguageName = languageNames[lang]
+		rules, err := files.ReadFile("rules/" + lang + ".md")
+		if err != nil {
+			return Messages{}, fmt.Errorf("read %s rules: %w", lang, err)
+		}
+		data.Rules = strings.TrimSpace(string(rules))
+	}
+
+	if in.PR != nil {
+		data.OtherFiles = otherFiles(in.PR.ChangedFiles, data.Paths)
+	}
+	data.Instructions = strings.TrimSpace(in.Instructions)
+
+	if in.Correction != nil {
+		c := *in.Correction
+		c.Previous = truncate(c.Previous, maxCorrectionEcho)
+		data.Correction = &c
+	}
+
+	example, err := render("issue.tmpl", data)
+	if err != nil {
+		return Messages{}, err
+	}
+	data.IssueExample = example
+
+	system, err := render(names[0], data)
+	if err != nil {
+		return Messages{}, err
+	}
+	user, err := render(names[1], data)
+	if err != nil {
+		return Messages{}, err
+	}
+
+	return Messages{System: system, User: user}, nil
+}
+
+// Language returns the rule pack name for a file path, or "" when there is
+// no language-specific pack.
+func Language(file string) string {
+	return languages[strings.ToLower(path.Ext(file))]
+}
+
+// truncate cuts s to at most n bytes on a rune boundary and marks the cut.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+// commonLanguage returns the language shared by all files, or "" for a
+// mixed batch, which then gets the generic prompt.
+func commonLanguage(files []string) string {
+	lang := Language(files[0])
+	for _, f := range files[1:] {
+		if Language(f) != lang {
+			return ""
+		}
+	}
+	return lang
+}
+
+func render(name string, data templateData) (string, error) {
+	var b strings.Builder
+	if err := templates.ExecuteTemplate(&b, name, data); err != nil {
+		return "", fmt.Errorf("render %s: %w", name, err)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
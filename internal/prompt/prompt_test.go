@@ -0,0 +1,186 @@
+package prompt
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"ai-code-reviewer/internal/review"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Run `go test ./internal/prompt -update` after an intended prompt change
+// and review the golden diff.
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestBuild_Golden(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Input
+	}{
+		{name: "go", in: Input{File: "internal/app/server.go", Content: "Hunk:\n-return nil\n+return err\n"}},
+		{name: "python", in: Input{File: "app/views.py", Content: "Hunk:\n+cursor.execute(q % user)\n"}},
+		{name: "typescript", in: Input{File: "web/src/api.ts", Content: "Hunk:\n+el.innerHTML = msg\n"}},
+		{name: "javascript", in: Input{File: "web/src/api.js", Content: "Hunk:\n+if (user == null) return\n"}},
+		{name: "unknown_language", in: Input{File: "scripts/build.sh", Content: "Hunk:\n+rm -rf $DIR\n"}},
+		{name: "repo_instructions", in: Input{
+			File:         "main.go",
+			Content:      "Hunk:\n+log.Println(token)\n",
+			Instructions: "Never log tokens.\nUse the internal/errs package for errors.",
+		}},
+		{name: "pr_context", in: Input{
+			File:    "internal/auth/token.go",
+			Content: "Hunk:\n-ttl := time.Hour\n+ttl := 10 * time.Minute\n",
+			PR: &PRContext{
+				Title:        "Shorten token lifetime",
+				Body:         "Tokens now expire after 10 minutes.\n\nFixes #42",
+				BaseBranch:   "main",
+				ChangedFiles: []string{"internal/auth/token.go", "internal/auth/token_test.go"},
+				LinkedIssues: []LinkedIssue{{Number: 42, Title: "Tokens live too long", Body: "Security asked for <= 15 minutes."}},
+			},
+		}},
+		{name: "surrounding", in: Input{
+			File:        "internal/store/cache.go",
+			Content:     "Hunk:\n+delete(c.items, key)\n",
+			Surrounding: "   10 | func (c *Cache) Evict(key string) {\n   11 | \tdelete(c.items, key)\n   12 | }\n  ... |",
+		}},
+		{name: "static_hints", in: Input{
+			File:    "internal/store/cache.go",
+			Content: "Hunk:\n+fmt.Printf(\"%d\\n\", key)\n",
+			Hints:   []review.Issue{{Line: 12, Title: "fmt.Printf format %d has arg key of wrong type string"}},
+		}},
+		{name: "batch", in: Input{
+			Files: []FileInput{
+				{File: "cmd/api/main.go", Content: "Hunk:\n+defer db.Close()\n"},
+				{File: "internal/config/env.go", Content: "Hunk:\n+port := os.Getenv(\"PORT\")\n"},
+			},
+			PR: &PRContext{
+				Title:        "Read the port from the environment",
+				ChangedFiles: []string{"cmd/api/main.go", "internal/config/env.go", "README.md"},
+			},
+		}},
+		{name: "correction", in: Input{
+			File:    "main.go",
+			Content: "Hunk:\n+x := 1\n",
+			Correction: &Correction{
+				Previous: "Sure! Here are the issues: none",
+				Problem:  "no json object in ai output",
+			},
+		}},
+		{name: "verify", in: Input{
+			Task:        TaskVerify,
+			File:        "internal/store/users.go",
+			Content:     "Hunk:\n+rows, _ := db.Query(\"SELECT * FROM users WHERE id = \" + id)\n",
+			Surrounding: "   20 | func (s *Store) User(id string) (*User, error) {\n  ... |",
+			Issue: &review.Issue{
+				Line:       21,
+				Severity:   "high",
+				Category:   "security",
+				Title:      "SQL injection through id",
+				Suggestion: "Use a placeholder: db.Query(\"... WHERE id = ?\", id)",
+				Rationale:  "id comes from the request path.",
+			},
+		}},
+		{name: "summary", in: Input{
+			Task: TaskSummary,
+			PR: &PRContext{
+				Title:      "Refresh expired tokens",
+				Body:       "Clients no longer have to log in again after an hour.",
+				BaseBranch: "main",
+			},
+			Diffstat: []FileStat{
+				{File: "internal/auth/refresh.go", Status: "added", Additions: 84},
+				{File: "internal/auth/token.go", Status: "modified", Additions: 12, Deletions: 3},
+			},
+			Findings: []Finding{
+				{File: "internal/auth/refresh.go", Issue: review.Issue{Line: 31, Severity: "high", Category: "concurrency", Title: "refresh races with logout"}},
+			},
+		}},
+		{name: "tests", in: Input{
+			Task:    TaskTests,
+			File:    "internal/store/put.go",
+			Package: "store",
+			Content: "// Put stores v under k.\nfunc (s *Store) Put(k string, v []byte) error {\n\tif k == \"\" {\n\t\treturn ErrEmptyKey\n\t}\n\ts.m[k] = v\n\treturn nil\n}",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msgs, err := Build(tt.in)
+			require.NoError(t, err)
+
+			got := "=== system ===\n" + msgs.System + "\n=== user ===\n" + msgs.User + "\n"
+			golden := filepath.Join("testdata", tt.name+".golden")
+
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(got), 0o644))
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestBuild_UnknownTask(t *testing.T) {
+	_, err := Build(Input{Task: "poem", File: "main.go", Content: "+x"})
+	require.Error(t, err)
+}
+
+func TestBuild_CorrectionEchoKeepsRunes(t *testing.T) {
+	// each "é" is two bytes, so the byte limit falls inside a rune
+	previous := "x" + strings.Repeat("é", maxCorrectionEcho)
+	msgs, err := Build(Input{
+		File:       "main.go",
+		Content:    "Hunk:\n+x := 1\n",
+		Correction: &Correction{Previous: previous, Problem: "no json object in ai output"},
+	})
+	require.NoError(t, err)
+	require.True(t, utf8.ValidString(msgs.User))
+	require.Contains(t, msgs.User, "x"+strings.Repeat("é", (maxCorrectionEcho-1)/2)+"...")
+}
+
+func TestLanguage(t *testing.T) {
+	require.Equal(t, "go", Language("a/b.go"))
+	require.Equal(t, "typescript", Language("a/B.TSX"))
+	require.Equal(t, "javascript", Language("web/app.jsx"))
+	require.Equal(t, "python", Language("x.py"))
+	require.Equal(t, "", Language("Makefile"))
+}
+
+func TestPRContextFit(t *testing.T) {
+	estimate := func(s string) int { return len([]rune(s)) / 4 }
+
+	c := PRContext{
+		Title:        "Refactor the scheduler",
+		Body:         strings.Repeat("long description ", 200),
+		BaseBranch:   "main",
+		ChangedFiles: []string{"a.go", "b.go"},
+		LinkedIssues: []LinkedIssue{
+			{Number: 1, Title: "first", Body: strings.Repeat("issue one ", 200)},
+			{Number: 2, Title: "second", Body: strings.Repeat("issue two ", 200)},
+		},
+	}
+
+	got := c.Fit(300, estimate)
+
+	total := estimate(got.Title) + estimate(got.Body) + estimate(got.BaseBranch)
+	for _, f := range got.ChangedFiles {
+		total += estimate(f)
+	}
+	for _, is := range got.LinkedIssues {
+		total += estimate(is.Title) + estimate(is.Body)
+	}
+
+	require.LessOrEqual(t, total, 300)
+	require.Equal(t, c.Title, got.Title)
+	require.Equal(t, c.ChangedFiles, got.ChangedFiles)
+	require.True(t, strings.HasSuffix(got.Body, truncatedMarker))
+	require.Len(t, got.LinkedIssues, 2)
+	require.Empty(t, c.Fit(0, estimate).Title)
+}
@@ -0,0 +1,17 @@
+package prompt
+
+import "ai-code-reviewer/internal/review"
+
+// Finding is an issue reported on the PR, as listed for TaskSummary.
+type Finding struct {
+	File  string
+	Issue review.Issue
+}
+
+// FileStat is one line of the PR diffstat.
+type FileStat struct {
+	File      string
+	Status    string
+	Additions int
+	Deletions int
+}
@@ -0,0 +1,204 @@
+// Package redact replaces personal data and credentials with stable
+// placeholders before code leaves for an external AI provider, and puts
+// the original values back into what the provider returns.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/secrets"
+)
+
+// placeholderPrefix starts every placeholder, e.g. REDACTED_EMAIL_1A2B3C4D.
+const placeholderPrefix = "REDACTED_"
+
+// processKey signs placeholders when no key is configured; they are then
+// stable for the life of the process.
+var processKey = func() []byte {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return b
+}()
+
+type rule struct {
+	name       string
+	re         *regexp.Regexp
+	minEntropy float64
+}
+
+// Redactor holds the compiled rules. It is safe for concurrent use; the
+// state of one request lives in a Session.
+type Redactor struct {
+	rules []rule
+	key   []byte
+}
+
+// New compiles rules validated by config.Load. Placeholders are derived
+// from the value with an HMAC under key, so they do not reveal it.
+func New(rules []config.RedactionRule, key string) *Redactor {
+	r := &Redactor{key: []byte(key)}
+	if key == "" {
+		r.key = processKey
+	}
+
+	for _, cr := range rules {
+		ru := rule{name: cr.Name, minEntropy: cr.MinEntropy}
+		if cr.Pattern != "" {
+			ru.re = regexp.MustCompile(cr.Pattern)
+		} else {
+			ru.re = regexp.MustCompile(fmt.Sprintf(`[A-Za-z0-9+/_-]{%d,}`, cr.MinLength))
+		}
+		r.rules = append(r.rules, ru)
+	}
+	return r
+}
+
+// Session redacts the texts of one request and restores its response. The
+// same value always gets the same placeholder, within a request and
+// across them.
+type Session struct {
+	r *Redactor
+	// values maps placeholders back to what they replaced.
+	values map[string]string
+	counts map[string]int
+}
+
+func (r *Redactor) Session() *Session {
+	return &Session{r: r, values: make(map[string]string), counts: make(map[string]int)}
+}
+
+// Redact replaces every match of the rules in text. Where matches overlap,
+// the earlier rule wins.
+func (s *Session) Redact(text string) string {
+	if text == "" {
+		return text
+	}
+
+	type span struct {
+		rule       string
+		start, end int
+	}
+	var spans []span
+	overlaps := func(start, end int) bool {
+		return slices.ContainsFunc(spans, func(sp span) bool { return start < sp.end && sp.start < end })
+	}
+
+	for _, ru := range s.r.rules {
+		for _, m := range ru.re.FindAllStringIndex(text, -1) {
+			value := text[m[0]:m[1]]
+			if m[0] == m[1] || overlaps(m[0], m[1]) || strings.HasPrefix(value, placeholderPrefix) {
+				continue
+			}
+			if ru.minEntropy > 0 && !secrets.LooksRandom(value, ru.minEntropy) {
+				continue
+			}
+			spans = append(spans, span{rule: ru.name, start: m[0], end: m[1]})
+		}
+	}
+	if len(spans) == 0 {
+		return text
+	}
+
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+
+	var b strings.Builder
+	last := 0
+	for _, sp := range spans {
+		b.WriteString(text[last:sp.start])
+		b.WriteString(s.placeholder(sp.rule, text[sp.start:sp.end]))
+		last = sp.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+func (s *Session) placeholder(rule, value string) string {
+	mac := hmac.New(sha256.New, s.r.key)
+	mac.Write([]byte(rule))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	p := placeholderPrefix + strings.ToUpper(rule) + "_" + strings.ToUpper(hex.EncodeToString(mac.Sum(nil)[:4]))
+
+	if _, ok := s.values[p]; !ok {
+		s.values[p] = value
+		s.counts[rule]++
+	}
+	return p
+}
+
+// Counts returns how many distinct values each rule replaced.
+func (s *Session) Counts() map[string]int {
+	return s.counts
+}
+
+// Restore puts the original values back in text. Responses are JSON and
+// placeholders sit inside its strings, so values are escaped as string
+// content: a quote or backslash in a secret must not break the response.
+func (s *Session) Restore(text string) string {
+	if len(s.values) == 0 {
+		return text
+	}
+
+	pairs := make([]string, 0, 2*len(s.values))
+	for p, v := range s.values {
+		pairs = append(pairs, p, jsonEscape(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// jsonEscape returns v as the content of a JSON string, without quotes.
+func jsonEscape(v string) string {
+	b, _ := json.Marshal(v)
+	return string(b[1 : len(b)-1])
+}
+
+// Restorer restores a streamed response. A placeholder split across deltas
+// is held back until it is complete.
+type Restorer struct {
+	s       *Session
+	pending string
+}
+
+func (s *Session) Restorer() *Restorer {
+	return &Restorer{s: s}
+}
+
+// Feed returns the restored text that is safe to pass on.
+func (r *Restorer) Feed(delta string) string {
+	r.pending += delta
+	hold := r.s.partialSuffix(r.pending)
+	out := r.pending[:len(r.pending)-hold]
+	r.pending = r.pending[len(r.pending)-hold:]
+	return r.s.Restore(out)
+}
+
+// Flush returns whatever is still held back.
+func (r *Restorer) Flush() string {
+	out := r.s.Restore(r.pending)
+	r.pending = ""
+	return out
+}
+
+// partialSuffix is the length of the longest end of text that begins a
+// placeholder without completing it.
+func (s *Session) partialSuffix(text string) int {
+	longest := 0
+	for p := range s.values {
+		for k := min(len(p)-1, len(text)); k > longest; k-- {
+			if strings.HasSuffix(text, p[:k]) {
+				longest = k
+				break
+			}
+		}
+	}
+	return longest
+}
@@ -0,0 +1,68 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession_RedactsWithStablePlaceholders(t *testing.T) {
+	r := New(append(config.DefaultRedactionRules, config.RedactionRule{Name: "customer_id", Pattern: `\bCUST-[0-9]{6}\b`}), "test-key")
+	text := "notify(\"jane@example.com\", \"10.0.12.7\") // CUST-004211, cc jane@example.com\n" +
+		"token := \"q8Zr3vT1xKp9LmW2sYd7Fh4N\""
+
+	s := r.Session()
+	got := s.Redact(text)
+
+	for _, raw := range []string{"jane@example.com", "10.0.12.7", "CUST-004211", "q8Zr3vT1xKp9LmW2sYd7Fh4N"} {
+		require.NotContains(t, got, raw)
+	}
+	require.Equal(t, map[string]int{"email": 1, "ipv4": 1, "customer_id": 1, "token": 1}, s.Counts())
+	require.Equal(t, text, s.Restore(got))
+
+	email := s.Redact("jane@example.com")
+	require.Regexp(t, `^REDACTED_EMAIL_[0-9A-F]{8}$`, email)
+	require.Equal(t, 2, strings.Count(got, email))
+	require.Equal(t, email, r.Session().Redact("jane@example.com"))
+	require.NotEqual(t, email, New(config.DefaultRedactionRules, "other-key").Session().Redact("jane@example.com"))
+}
+
+func TestSession_KeepsOrdinaryCode(t *testing.T) {
+	s := New(config.DefaultRedactionRules, "test-key").Session()
+	text := "func TestProcessorHandle_PostsComments(t *testing.T) {\n\tversion := \"1.24.0\"\n}"
+
+	require.Equal(t, text, s.Redact(text))
+	require.Empty(t, s.Counts())
+}
+
+func TestRestorer_HoldsBackSplitPlaceholders(t *testing.T) {
+	s := New(config.DefaultRedactionRules, "test-key").Session()
+	redacted := s.Redact(`{"title":"mail to jane@example.com fails"}`)
+
+	restorer := s.Restorer()
+	var out strings.Builder
+	for i := 0; i < len(redacted); i += 5 {
+		out.WriteString(restorer.Feed(redacted[i:min(i+5, len(redacted))]))
+		require.NotContains(t, out.String(), "REDACTED_")
+	}
+	out.WriteString(restorer.Flush())
+
+	require.Equal(t, `{"title":"mail to jane@example.com fails"}`, out.String())
+}
+
+func TestSession_RestoresIntoJSONStrings(t *testing.T) {
+	r := New([]config.RedactionRule{{Name: "password", Pattern: `pa"ss\\word`}}, "test-key")
+	s := r.Session()
+	placeholder := s.Redact(`pa"ss\word`)
+	require.Regexp(t, `^REDACTED_PASSWORD_[0-9A-F]{8}$`, placeholder)
+
+	restored := s.Restore(`{"title":"password ` + placeholder + ` is hard-coded"}`)
+
+	var got struct{ Title string }
+	require.NoError(t, json.Unmarshal([]byte(restored), &got))
+	require.Equal(t, `password pa"ss\word is hard-coded`, got.Title)
+}
@@ -2,11 +2,26 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
 type Fn func() error
 
+// Permanent marks an error that retrying cannot fix; Do returns the wrapped
+// error immediately.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
 func Do(ctx context.Context, attempts int, wait time.Duration, fn Fn) error {
 
 	var err error
@@ -22,6 +37,11 @@ func Do(ctx context.Context, attempts int, wait time.Duration, fn Fn) error {
 			return nil
 		}
 
+		var p permanentError
+		if errors.As(err, &p) {
+			return p.err
+		}
+
 		time.Sleep(wait)
 		wait = wait * 2
 	}
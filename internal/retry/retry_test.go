@@ -36,6 +36,25 @@ func (s *RetrySuite) Test_Eventual_Success() {
 	s.Equal(2, calls)
 }
 
+func (s *RetrySuite) Test_Permanent_Stops() {
+
+	calls := 0
+	cause := errors.New("bad request")
+
+	err := retry.Do(
+		context.Background(),
+		3,
+		1*time.Millisecond,
+		func() error {
+			calls++
+			return retry.Permanent(cause)
+		},
+	)
+
+	s.Equal(cause, err)
+	s.Equal(1, calls)
+}
+
 func TestRetrySuite(t *testing.T) {
 	suite.Run(t, new(RetrySuite))
 }
@@ -0,0 +1,98 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrMissingFiles = errors.New(`missing "files" array`)
+
+// BatchResult is the response to a request covering several files. Issues
+// are keyed by file path; line numbers refer to that file.
+type BatchResult struct {
+	Version int          `json:"version,omitempty"`
+	Files   []FileResult `json:"files"`
+	// Dropped lists what DecodeStrictBatch left out as invalid.
+	Dropped []string `json:"-"`
+}
+
+type FileResult struct {
+	Path   string  `json:"path"`
+	Issues []Issue `json:"issues"`
+}
+
+// ParseBatchResult is ParseResult for multi-file responses. paths are the
+// files in the request; entries for any other path are rejected.
+func ParseBatchResult(raw string, paths []string) (BatchResult, error) {
+	obj, err := extractJSONObject(raw)
+	if err != nil {
+		return BatchResult{}, err
+	}
+
+	var r BatchResult
+
+	if err := json.Unmarshal([]byte(obj), &r); err != nil {
+		r = BatchResult{}
+		if repairErr := json.Unmarshal([]byte(repairJSON(obj)), &r); repairErr != nil {
+			return BatchResult{}, fmt.Errorf("decode review json: %w", err)
+		}
+	}
+
+	r.Version = responseVersion(r.Version)
+
+	return r, ValidateBatch(&r, paths)
+}
+
+// DecodeStrictBatch is DecodeStrict for multi-file responses. Entries for
+// paths outside the request are dropped too.
+func DecodeStrictBatch(raw string, paths []string) (BatchResult, error) {
+	var r BatchResult
+	if err := json.Unmarshal([]byte(raw), &r); err != nil {
+		return BatchResult{}, fmt.Errorf("decode review json: %w", err)
+	}
+	r.Version = responseVersion(r.Version)
+	if r.Files == nil {
+		return BatchResult{}, ErrMissingFiles
+	}
+
+	files := make([]FileResult, 0, len(r.Files))
+	for i, f := range r.Files {
+		if !contains(paths, f.Path) {
+			r.Dropped = append(r.Dropped, fmt.Sprintf("files[%d].path %q is not one of the reviewed files", i, f.Path))
+			continue
+		}
+		var dropped []string
+		f.Issues, dropped = validIssues(fmt.Sprintf("files[%d].issues", i), f.Issues)
+		r.Dropped = append(r.Dropped, dropped...)
+		files = append(files, f)
+	}
+	r.Files = files
+	return r, nil
+}
+
+// ValidateBatch validates every file's issues and checks that each entry
+// names a requested path. Files the model left out simply have no issues.
+func ValidateBatch(r *BatchResult, paths []string) error {
+	if r.Files == nil {
+		return ErrMissingFiles
+	}
+
+	var problems []string
+	for i := range r.Files {
+		f := &r.Files[i]
+		if !contains(paths, f.Path) {
+			problems = append(problems, fmt.Sprintf("files[%d].path %q is not one of the reviewed files", i, f.Path))
+		}
+		if f.Issues == nil {
+			problems = append(problems, fmt.Sprintf("files[%d] is missing its \"issues\" array", i))
+			continue
+		}
+		problems = append(problems, validateIssues(fmt.Sprintf("files[%d].issues", i), f.Issues)...)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package review
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatchResult(t *testing.T) {
+	raw := "```json\n" + `{
+  "version": 2,
+  "files": [
+    {"path": "a.go", "issues": [{"line": 4, "severity": "High", "title": "t"},]},
+    {"path": "b.py", "issues": []}
+  ]
+}` + "\n```"
+
+	r, err := ParseBatchResult(raw, []string{"a.go", "b.py", "c.ts"})
+	require.NoError(t, err)
+	require.Len(t, r.Files, 2)
+	require.Equal(t, "a.go", r.Files[0].Path)
+	require.Equal(t, "high", r.Files[0].Issues[0].Severity)
+	require.Empty(t, r.Files[1].Issues)
+	require.Equal(t, SchemaV2, r.Version)
+}
+
+func TestBatchResult_UnversionedIsV1(t *testing.T) {
+	raw := `{"files":[{"path":"a.go","issues":[{"line":4,"severity":"high","title":"t"}]}]}`
+
+	r, err := ParseBatchResult(raw, []string{"a.go"})
+	require.NoError(t, err)
+	require.Equal(t, SchemaV1, r.Version)
+
+	r, err = DecodeStrictBatch(raw, []string{"a.go"})
+	require.NoError(t, err)
+	require.Equal(t, SchemaV1, r.Version)
+}
+
+func TestParseBatchResult_Errors(t *testing.T) {
+	_, err := ParseBatchResult(`{"issues":[]}`, []string{"a.go"})
+	require.ErrorIs(t, err, ErrMissingFiles)
+
+	_, err = ParseBatchResult(`{"files":[{"path":"x.go","issues":[]},{"path":"a.go","issues":[{"line":0,"severity":"high","title":"t"}]}]}`, []string{"a.go"})
+	var ve *ValidationError
+	require.True(t, errors.As(err, &ve))
+	require.Equal(t, []string{
+		`files[0].path "x.go" is not one of the reviewed files`,
+		"files[1].issues[0].line must be a positive integer, got 0",
+	}, ve.Problems)
+}
+
+func TestBatchJSONSchema_RestrictsPaths(t *testing.T) {
+	s := BatchJSONSchema([]string{"a.go", "b.go"})
+	files := s["properties"].(map[string]any)["files"].(map[string]any)
+	path := files["items"].(map[string]any)["properties"].(map[string]any)["path"].(map[string]any)
+	require.Equal(t, []string{"a.go", "b.go"}, path["enum"])
+}
+
+func TestDecodeStrictBatch_DropsInvalidEntries(t *testing.T) {
+	r, err := DecodeStrictBatch(`{"version":2,"files":[`+
+		`{"path":"x.go","issues":[{"line":1,"severity":"high","title":"t"}]},`+
+		`{"path":"a.go","issues":[{"line":0,"severity":"high","title":"t"},{"line":2,"severity":"low","title":"kept"}]}]}`,
+		[]string{"a.go"})
+	require.NoError(t, err)
+	require.Len(t, r.Files, 1)
+	require.Equal(t, "a.go", r.Files[0].Path)
+	require.Len(t, r.Files[0].Issues, 1)
+	require.Equal(t, "kept", r.Files[0].Issues[0].Title)
+	require.Equal(t, []string{
+		`files[0].path "x.go" is not one of the reviewed files`,
+		"files[1].issues[0].line must be a positive integer, got 0",
+	}, r.Dropped)
+}
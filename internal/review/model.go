@@ -1,12 +1,95 @@
 package review
 
+import "strings"
+
+// Schema versions of the JSON the model returns. Responses without a
+// "version" field are v1 (line/severity/title/suggestion only); v2 adds
+// category, confidence, CWE and rationale. Later versions must only add
+// optional fields so older parsers keep working.
+const (
+	SchemaV1      = 1
+	SchemaV2      = 2
+	SchemaVersion = SchemaV2
+)
+
+// responseVersion returns the schema version of a decoded response.
+func responseVersion(v int) int {
+	if v == 0 {
+		return SchemaV1
+	}
+	return v
+}
+
+const (
+	CategoryBug         = "bug"
+	CategorySecurity    = "security"
+	CategoryPerformance = "performance"
+	CategoryConcurrency = "concurrency"
+	CategoryStyle       = "style"
+	CategoryTest        = "test"
+	CategoryOther       = "other"
+)
+
+// SourceStatic marks issues found by static analysis rather than the model.
+const SourceStatic = "static"
+
+// Severities lists the accepted severities, most severe first.
+var Severities = []string{"critical", "high", "medium", "low"}
+
+// Categories lists the categories the model may use, in summary order.
+var Categories = []string{
+	CategoryBug,
+	CategorySecurity,
+	CategoryPerformance,
+	CategoryConcurrency,
+	CategoryStyle,
+	CategoryTest,
+}
+
 type ReviewResult struct {
-	Issues []Issue `json:"issues"`
+	Version int     `json:"version,omitempty"`
+	Issues  []Issue `json:"issues"`
+	// Dropped lists what DecodeStrict left out as invalid.
+	Dropped []string `json:"-"`
 }
 
 type Issue struct {
-	Line       int    `json:"line"`
-	Severity   string `json:"severity"`
-	Title      string `json:"title"`
-	Suggestion string `json:"suggestion"`
+	Line       int      `json:"line"`
+	Severity   string   `json:"severity"`
+	Title      string   `json:"title"`
+	Suggestion string   `json:"suggestion"`
+	Category   string   `json:"category,omitempty"`
+	Confidence *float64 `json:"confidence,omitempty"`
+	CWE        string   `json:"cwe,omitempty"`
+	Rationale  string   `json:"rationale,omitempty"`
+	// Source is SourceStatic for static analysis findings and empty for
+	// the model's own.
+	Source string `json:"-"`
+}
+
+// ConfidenceScore returns the model's confidence in [0,1]. Issues without a
+// score (v1 responses) are fully trusted.
+func (i Issue) ConfidenceScore() float64 {
+	if i.Confidence == nil {
+		return 1
+	}
+	c := *i.Confidence
+	if c < 0 {
+		return 0
+	}
+	if c > 1 {
+		return 1
+	}
+	return c
+}
+
+// NormalizedCategory maps the issue onto one of Categories, or CategoryOther.
+func (i Issue) NormalizedCategory() string {
+	c := strings.ToLower(strings.TrimSpace(i.Category))
+	for _, known := range Categories {
+		if c == known {
+			return c
+		}
+	}
+	return CategoryOther
 }
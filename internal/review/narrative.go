@@ -0,0 +1,77 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxFocus is how many "where to look first" items a Narrative keeps.
+const MaxFocus = 3
+
+var ErrMissingOverview = errors.New(`missing "overview"`)
+
+// Narrative is the PR-level review written from the findings, the PR
+// description and the diffstat.
+type Narrative struct {
+	Overview string   `json:"overview"`
+	Risks    []string `json:"risks"`
+	TestGaps []string `json:"test_gaps"`
+	Focus    []string `json:"focus"`
+}
+
+// ParseNarrative decodes a summary response with the same tolerance as
+// ParseResult. Blank items are dropped and Focus is cut to MaxFocus.
+func ParseNarrative(raw string) (Narrative, error) {
+	obj, err := extractJSONObject(raw)
+	if err != nil {
+		return Narrative{}, err
+	}
+
+	var n Narrative
+	if err := json.Unmarshal([]byte(obj), &n); err != nil {
+		n = Narrative{}
+		if repairErr := json.Unmarshal([]byte(repairJSON(obj)), &n); repairErr != nil {
+			return Narrative{}, fmt.Errorf("decode narrative json: %w", err)
+		}
+	}
+
+	n.Overview = strings.TrimSpace(n.Overview)
+	if n.Overview == "" {
+		return Narrative{}, ErrMissingOverview
+	}
+	n.Risks = nonBlank(n.Risks)
+	n.TestGaps = nonBlank(n.TestGaps)
+	n.Focus = nonBlank(n.Focus)
+	if len(n.Focus) > MaxFocus {
+		n.Focus = n.Focus[:MaxFocus]
+	}
+	return n, nil
+}
+
+// NarrativeJSONSchema is the strict-mode JSON Schema of a Narrative.
+func NarrativeJSONSchema() map[string]any {
+	list := map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"overview", "risks", "test_gaps", "focus"},
+		"properties": map[string]any{
+			"overview":  map[string]any{"type": "string"},
+			"risks":     list,
+			"test_gaps": list,
+			"focus":     list,
+		},
+	}
+}
+
+func nonBlank(items []string) []string {
+	var out []string
+	for _, s := range items {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNarrative(t *testing.T) {
+	n, err := ParseNarrative(`{
+		"overview": " Adds token refresh. ",
+		"risks": ["refresh races with logout", " "],
+		"test_gaps": [],
+		"focus": ["a", "b", "c", "d"],
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, "Adds token refresh.", n.Overview)
+	require.Equal(t, []string{"refresh races with logout"}, n.Risks)
+	require.Empty(t, n.TestGaps)
+	require.Equal(t, []string{"a", "b", "c"}, n.Focus)
+}
+
+func TestParseNarrative_Errors(t *testing.T) {
+	_, err := ParseNarrative("This PR adds token refresh.")
+	require.ErrorIs(t, err, ErrNoJSONObject)
+
+	_, err = ParseNarrative(`{"overview":"","risks":[],"test_gaps":[],"focus":[]}`)
+	require.ErrorIs(t, err, ErrMissingOverview)
+}
@@ -2,10 +2,37 @@ package review
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 )
 
+var (
+	ErrNoJSONObject  = errors.New("no json object in ai output")
+	ErrUnterminated  = errors.New("unterminated json object in ai output")
+	ErrMissingIssues = errors.New(`missing "issues" array`)
+)
+
+const (
+	markdownFence     = "```"
+	maxProblemsListed = 5
+)
+
+// ValidationError lists the schema violations found in a decoded response.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	problems := e.Problems
+	suffix := ""
+	if len(problems) > maxProblemsListed {
+		suffix = fmt.Sprintf("; and %d more", len(problems)-maxProblemsListed)
+		problems = problems[:maxProblemsListed]
+	}
+	return "invalid review: " + strings.Join(problems, "; ") + suffix
+}
+
 func ExtractIssues(aiText string) []Issue {
 
 	var issues []Issue
@@ -38,10 +65,249 @@ func ExtractIssues(aiText string) []Issue {
 	return issues
 }
 
+// ParseResult decodes a model response. It tolerates markdown fences, prose
+// around the JSON object, comments and trailing commas, then validates the
+// result against the issue schema.
 func ParseResult(raw string) (ReviewResult, error) {
 
+	obj, err := extractJSONObject(raw)
+	if err != nil {
+		return ReviewResult{}, err
+	}
+
 	var r ReviewResult
 
-	err := json.Unmarshal([]byte(raw), &r)
-	return r, err
+	if err := json.Unmarshal([]byte(obj), &r); err != nil {
+		r = ReviewResult{}
+		if repairErr := json.Unmarshal([]byte(repairJSON(obj)), &r); repairErr != nil {
+			return ReviewResult{}, fmt.Errorf("decode review json: %w", err)
+		}
+	}
+
+	r.Version = responseVersion(r.Version)
+
+	return r, Validate(&r)
+}
+
+// DecodeStrict decodes output produced under a provider-enforced JSON schema.
+// No repair is attempted. Schemas cannot express every rule (e.g. positive
+// line numbers), so issues are still validated, and the invalid ones are
+// dropped and listed in Dropped: a re-prompt would be decoded under the
+// same constraint, and one bad issue should not cost the others.
+func DecodeStrict(raw string) (ReviewResult, error) {
+	var r ReviewResult
+	if err := json.Unmarshal([]byte(raw), &r); err != nil {
+		return ReviewResult{}, fmt.Errorf("decode review json: %w", err)
+	}
+	r.Version = responseVersion(r.Version)
+	if r.Issues == nil {
+		return ReviewResult{}, ErrMissingIssues
+	}
+	r.Issues, r.Dropped = validIssues("issues", r.Issues)
+	return r, nil
+}
+
+// Validate normalizes severities and categories in place and reports every
+// issue that does not match the schema.
+func Validate(r *ReviewResult) error {
+	if r.Issues == nil {
+		return ErrMissingIssues
+	}
+
+	if problems := validateIssues("issues", r.Issues); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validateIssues(field string, issues []Issue) []string {
+	var problems []string
+	for i := range issues {
+		problems = append(problems, validateIssue(fmt.Sprintf("%s[%d]", field, i), &issues[i])...)
+	}
+	return problems
+}
+
+// validIssues returns the issues that pass validation and the problems of
+// the others.
+func validIssues(field string, issues []Issue) ([]Issue, []string) {
+	valid := make([]Issue, 0, len(issues))
+	var problems []string
+	for i := range issues {
+		if p := validateIssue(fmt.Sprintf("%s[%d]", field, i), &issues[i]); len(p) > 0 {
+			problems = append(problems, p...)
+			continue
+		}
+		valid = append(valid, issues[i])
+	}
+	return valid, problems
+}
+
+// validateIssue normalizes is in place and reports its schema violations.
+func validateIssue(field string, is *Issue) []string {
+	is.Severity = strings.ToLower(strings.TrimSpace(is.Severity))
+	is.Category = strings.ToLower(strings.TrimSpace(is.Category))
+
+	var problems []string
+	if is.Line <= 0 {
+		problems = append(problems, fmt.Sprintf("%s.line must be a positive integer, got %d", field, is.Line))
+	}
+	if !contains(Severities, is.Severity) {
+		problems = append(problems, fmt.Sprintf("%s.severity %q must be one of %s", field, is.Severity, strings.Join(Severities, "|")))
+	}
+	if is.Category != "" && !contains(Categories, is.Category) {
+		problems = append(problems, fmt.Sprintf("%s.category %q must be one of %s", field, is.Category, strings.Join(Categories, "|")))
+	}
+	if is.Confidence != nil && (*is.Confidence < 0 || *is.Confidence > 1) {
+		problems = append(problems, fmt.Sprintf("%s.confidence must be between 0 and 1", field))
+	}
+	if strings.TrimSpace(is.Title) == "" && strings.TrimSpace(is.Suggestion) == "" {
+		problems = append(problems, fmt.Sprintf("%s needs a title or suggestion", field))
+	}
+	return problems
+}
+
+// extractJSONObject returns the first balanced {...} object, preferring the
+// content of a markdown code fence that opens before it. A fence after the
+// first brace is inside the object, e.g. in a suggestion's code.
+func extractJSONObject(raw string) (string, error) {
+	text := raw
+	if fence := strings.Index(raw, markdownFence); fence >= 0 && fence < strings.IndexByte(raw, '{') {
+		if fenced, ok := fencedBlock(raw); ok && strings.Contains(fenced, "{") {
+			text = fenced
+		}
+	}
+
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return "", ErrNoJSONObject
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(text); i++ {
+		c := text[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], nil
+			}
+		}
+	}
+
+	return "", ErrUnterminated
+}
+
+func fencedBlock(raw string) (string, bool) {
+	open := strings.Index(raw, markdownFence)
+	if open < 0 {
+		return "", false
+	}
+
+	body := raw[open+len(markdownFence):]
+	// skip the info string, e.g. ```json
+	if nl := strings.IndexByte(body, '\n'); nl >= 0 {
+		body = body[nl+1:]
+	}
+
+	end := strings.Index(body, markdownFence)
+	if end < 0 {
+		return body, true
+	}
+	return body[:end], true
+}
+
+// repairJSON fixes mistakes models commonly make: // and /* */ comments,
+// trailing commas and raw control characters inside strings.
+func repairJSON(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	inString := false
+	escaped := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			case c == '\n':
+				b.WriteString(`\n`)
+				continue
+			case c == '\r':
+				b.WriteString(`\r`)
+				continue
+			case c == '\t':
+				b.WriteString(`\t`)
+				continue
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			continue
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				i = len(s)
+			} else {
+				i += end + 3
+			}
+			continue
+		case c == ',':
+			j := i + 1
+			for j < len(s) && strings.ContainsRune(" \t\r\n", rune(s[j])) {
+				j++
+			}
+			if j < len(s) && (s[j] == '}' || s[j] == ']') {
+				continue
+			}
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
 }
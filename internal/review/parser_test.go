@@ -0,0 +1,66 @@
+package review
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResult_Tolerant(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{name: "plain", raw: `{"issues":[{"line":3,"severity":"high","title":"t","suggestion":"s"}]}`},
+		{name: "json fence", raw: "```json\n{\"issues\":[{\"line\":3,\"severity\":\"high\",\"title\":\"t\"}]}\n```"},
+		{name: "prose prefix and suffix", raw: "Here is my review:\n{\"issues\":[{\"line\":3,\"severity\":\"High\",\"title\":\"t\"}]}\nHope it helps {:"},
+		{name: "trailing commas", raw: `{"issues":[{"line":3,"severity":"high","title":"t",},],}`},
+		{name: "comments", raw: "{\n// findings\n\"issues\":[/* one */{\"line\":3,\"severity\":\"high\",\"title\":\"see http://x\"}]}"},
+		{name: "raw newline in string", raw: "{\"issues\":[{\"line\":3,\"severity\":\"high\",\"title\":\"a\nb\"}]}"},
+		{name: "braces inside strings", raw: `{"issues":[{"line":3,"severity":"high","title":"use {} not }"}]}`},
+		{name: "fence inside a string", raw: "{\"issues\":[{\"line\":3,\"severity\":\"high\",\"title\":\"t\",\"suggestion\":\"use ```go\\nx := map[string]int{}\\n``` instead\"}]}"},
+		{name: "fence after prose", raw: "Review below.\n```json\n{\"issues\":[{\"line\":3,\"severity\":\"high\",\"title\":\"t\"}]}\n```"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseResult(tt.raw)
+			require.NoError(t, err)
+			require.Len(t, r.Issues, 1)
+			require.Equal(t, 3, r.Issues[0].Line)
+			require.Equal(t, "high", r.Issues[0].Severity)
+			require.Equal(t, SchemaV1, r.Version)
+		})
+	}
+}
+
+func TestParseResult_Errors(t *testing.T) {
+	_, err := ParseResult("I could not find any problems.")
+	require.ErrorIs(t, err, ErrNoJSONObject)
+
+	_, err = ParseResult(`{"issues":[{"line":3`)
+	require.ErrorIs(t, err, ErrUnterminated)
+
+	_, err = ParseResult(`{"findings":[]}`)
+	require.ErrorIs(t, err, ErrMissingIssues)
+
+	_, err = ParseResult(`{"version":2,"issues":[{"line":0,"severity":"urgent","category":"typo","confidence":1.5,"title":"t"}]}`)
+	var verr *ValidationError
+	require.True(t, errors.As(err, &verr))
+	require.Len(t, verr.Problems, 4)
+}
+
+func TestDecodeStrict_DropsInvalidIssues(t *testing.T) {
+	r, err := DecodeStrict(`{"version":2,"issues":[` +
+		`{"line":0,"severity":"high","title":"no line"},` +
+		`{"line":4,"severity":"High","title":"kept"}]}`)
+	require.NoError(t, err)
+	require.Len(t, r.Issues, 1)
+	require.Equal(t, "kept", r.Issues[0].Title)
+	require.Equal(t, "high", r.Issues[0].Severity)
+	require.Equal(t, []string{"issues[0].line must be a positive integer, got 0"}, r.Dropped)
+
+	_, err = DecodeStrict(`{"version":2}`)
+	require.ErrorIs(t, err, ErrMissingIssues)
+}
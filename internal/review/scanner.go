@@ -0,0 +1,129 @@
+package review
+
+import "encoding/json"
+
+// IssueScanner extracts issues from a JSON review while it is still being
+// streamed. Every object that completes inside an "issues" array is
+// decoded, validated and passed to emit together with the "path" of the
+// enclosing file in batch responses ("" for single-file responses).
+// Invalid issues are skipped; the full response is still parsed at the end.
+type IssueScanner struct {
+	emit  func(path string, is Issue)
+	buf   []byte
+	stack []scanFrame
+	path  string
+
+	inString bool
+	escaped  bool
+	strStart int
+}
+
+type scanFrame struct {
+	kind    byte   // '{' or '['
+	key     string // key of this container in its parent object
+	start   int
+	wantKey bool
+	lastKey string
+}
+
+func NewIssueScanner(emit func(path string, is Issue)) *IssueScanner {
+	return &IssueScanner{emit: emit}
+}
+
+// Feed consumes the next piece of the response.
+func (s *IssueScanner) Feed(delta string) {
+	from := len(s.buf)
+	s.buf = append(s.buf, delta...)
+
+	for i := from; i < len(s.buf); i++ {
+		c := s.buf[i]
+
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				s.onString(s.buf[s.strStart : i+1])
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			s.inString = true
+			s.strStart = i
+		case '{', '[':
+			key := ""
+			switch top := s.top(); {
+			case top != nil && top.kind == '{':
+				key = top.lastKey
+			case top != nil && top.key == "files":
+				s.path = "" // until the new file names itself
+			}
+			s.stack = append(s.stack, scanFrame{kind: c, key: key, start: i, wantKey: c == '{'})
+		case '}', ']':
+			if len(s.stack) == 0 {
+				continue
+			}
+			f := s.stack[len(s.stack)-1]
+			s.stack = s.stack[:len(s.stack)-1]
+			if parent := s.top(); f.kind == '{' && parent != nil && parent.kind == '[' && parent.key == "issues" {
+				s.onIssue(s.buf[f.start : i+1])
+			}
+		case ':':
+			if top := s.top(); top != nil && top.kind == '{' {
+				top.wantKey = false
+			}
+		case ',':
+			if top := s.top(); top != nil && top.kind == '{' {
+				top.wantKey = true
+			}
+		}
+	}
+}
+
+func (s *IssueScanner) top() *scanFrame {
+	if len(s.stack) == 0 {
+		return nil
+	}
+	return &s.stack[len(s.stack)-1]
+}
+
+func (s *IssueScanner) onString(raw []byte) {
+	top := s.top()
+	if top == nil || top.kind != '{' {
+		return
+	}
+
+	var v string
+	if json.Unmarshal(raw, &v) != nil {
+		return
+	}
+
+	if top.wantKey {
+		top.lastKey = v
+		return
+	}
+
+	// a file object of a batch response: {"path": ..., "issues": [...]}
+	if top.lastKey == "path" && len(s.stack) >= 2 {
+		if parent := s.stack[len(s.stack)-2]; parent.kind == '[' && parent.key == "files" {
+			s.path = v
+		}
+	}
+}
+
+func (s *IssueScanner) onIssue(raw []byte) {
+	var is Issue
+	if json.Unmarshal(raw, &is) != nil {
+		return
+	}
+	issues := []Issue{is}
+	if len(validateIssues("issues", issues)) > 0 {
+		return
+	}
+	s.emit(s.path, issues[0])
+}
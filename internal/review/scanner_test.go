@@ -0,0 +1,57 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type scanned struct {
+	path  string
+	issue Issue
+}
+
+func scanInPieces(raw string, size int) []scanned {
+	var got []scanned
+	s := NewIssueScanner(func(path string, is Issue) {
+		got = append(got, scanned{path, is})
+	})
+	for len(raw) > 0 {
+		n := min(size, len(raw))
+		s.Feed(raw[:n])
+		raw = raw[n:]
+	}
+	return got
+}
+
+func TestIssueScanner_EmitsCompletedIssues(t *testing.T) {
+	raw := `{"version":2,"issues":[` +
+		`{"line":3,"severity":"HIGH","title":"nil map {write}","suggestion":"init \"m\""},` +
+		`{"line":0,"severity":"low","title":"bad line"},` +
+		`{"line":9,"severity":"low","title":"naming"}]}`
+
+	for _, size := range []int{1, 7, len(raw)} {
+		got := scanInPieces(raw, size)
+		require.Len(t, got, 2, "piece size %d", size)
+		require.Equal(t, "", got[0].path)
+		require.Equal(t, "high", got[0].issue.Severity)
+		require.Equal(t, "nil map {write}", got[0].issue.Title)
+		require.Equal(t, 9, got[1].issue.Line)
+	}
+}
+
+func TestIssueScanner_TracksBatchPaths(t *testing.T) {
+	raw := `{"version":2,"files":[` +
+		`{"path":"a.go","issues":[{"line":1,"severity":"low","title":"x"}]},` +
+		`{"path":"b.go","issues":[{"line":2,"severity":"medium","title":"y"}]}]}`
+
+	got := scanInPieces(raw, 5)
+	require.Len(t, got, 2)
+	require.Equal(t, "a.go", got[0].path)
+	require.Equal(t, "b.go", got[1].path)
+}
+
+func TestIssueScanner_IgnoresIncompleteIssue(t *testing.T) {
+	got := scanInPieces(`{"issues":[{"line":1,"severity":"low","title":"cut of`, 4)
+	require.Empty(t, got)
+}
@@ -0,0 +1,74 @@
+package review
+
+// JSONSchema returns the JSON Schema of a current-version ReviewResult. It is
+// written for OpenAI strict mode: every property is required and optional
+// values are nullable instead of omitted.
+func JSONSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"version", "issues"},
+		"properties": map[string]any{
+			"version": map[string]any{
+				"type": "integer",
+				"enum": []int{SchemaVersion},
+			},
+			"issues": map[string]any{
+				"type":  "array",
+				"items": issueSchema(),
+			},
+		},
+	}
+}
+
+// BatchJSONSchema is JSONSchema for multi-file requests; paths restricts
+// the file keys to the files that were sent.
+func BatchJSONSchema(paths []string) map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"version", "files"},
+		"properties": map[string]any{
+			"version": map[string]any{
+				"type": "integer",
+				"enum": []int{SchemaVersion},
+			},
+			"files": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":                 "object",
+					"additionalProperties": false,
+					"required":             []string{"path", "issues"},
+					"properties": map[string]any{
+						"path": map[string]any{"type": "string", "enum": paths},
+						"issues": map[string]any{
+							"type":  "array",
+							"items": issueSchema(),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func issueSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required": []string{
+			"line", "severity", "category", "confidence",
+			"cwe", "title", "suggestion", "rationale",
+		},
+		"properties": map[string]any{
+			"line":       map[string]any{"type": "integer"},
+			"severity":   map[string]any{"type": "string", "enum": Severities},
+			"category":   map[string]any{"type": "string", "enum": Categories},
+			"confidence": map[string]any{"type": "number"},
+			"cwe":        map[string]any{"type": []string{"string", "null"}},
+			"title":      map[string]any{"type": "string"},
+			"suggestion": map[string]any{"type": "string"},
+			"rationale":  map[string]any{"type": "string"},
+		},
+	}
+}
@@ -0,0 +1,69 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+var ErrNoTestFuncs = errors.New("no Test functions in suggested file")
+
+// TestFile is a suggested _test.go file.
+type TestFile struct {
+	Code string `json:"code"`
+	// Tests are the Test functions the file declares.
+	Tests []string `json:"-"`
+}
+
+// ParseTestFile decodes a test suggestion and checks that the code parses
+// as Go, belongs to pkg or its external test package and declares at least
+// one Test function. It does not type-check.
+func ParseTestFile(raw, pkg string) (TestFile, error) {
+	obj, err := extractJSONObject(raw)
+	if err != nil {
+		return TestFile{}, err
+	}
+
+	var tf TestFile
+	if err := json.Unmarshal([]byte(obj), &tf); err != nil {
+		tf = TestFile{}
+		if repairErr := json.Unmarshal([]byte(repairJSON(obj)), &tf); repairErr != nil {
+			return TestFile{}, fmt.Errorf("decode test file json: %w", err)
+		}
+	}
+
+	tf.Code = strings.TrimSpace(tf.Code) + "\n"
+	f, err := parser.ParseFile(token.NewFileSet(), "suggested_test.go", tf.Code, parser.SkipObjectResolution)
+	if err != nil {
+		return TestFile{}, fmt.Errorf("suggested test does not parse: %w", err)
+	}
+	if name := f.Name.Name; name != pkg && name != pkg+"_test" {
+		return TestFile{}, fmt.Errorf("suggested test is in package %s, want %s or %s_test", name, pkg, pkg)
+	}
+
+	for _, d := range f.Decls {
+		if fn, ok := d.(*ast.FuncDecl); ok && fn.Recv == nil && strings.HasPrefix(fn.Name.Name, "Test") {
+			tf.Tests = append(tf.Tests, fn.Name.Name)
+		}
+	}
+	if len(tf.Tests) == 0 {
+		return TestFile{}, ErrNoTestFuncs
+	}
+	return tf, nil
+}
+
+// TestFileJSONSchema is the strict-mode JSON Schema of a TestFile.
+func TestFileJSONSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"code"},
+		"properties": map[string]any{
+			"code": map[string]any{"type": "string"},
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package review
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testFileJSON(t *testing.T, code string) string {
+	t.Helper()
+	b, err := json.Marshal(map[string]string{"code": code})
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestParseTestFile(t *testing.T) {
+	code := "package store\n\nimport \"testing\"\n\nfunc TestPut(t *testing.T) {\n\ttests := []struct{ k string }{{\"a\"}}\n\tfor _, tt := range tests {\n\t\t_ = tt\n\t}\n}\n\nfunc helper() {}\n"
+
+	tf, err := ParseTestFile("```json\n"+testFileJSON(t, code)+"\n```", "store")
+	require.NoError(t, err)
+	require.Equal(t, code, tf.Code)
+	require.Equal(t, []string{"TestPut"}, tf.Tests)
+
+	_, err = ParseTestFile(testFileJSON(t, "package store_test\n\nimport \"testing\"\n\nfunc TestGet(t *testing.T) {}"), "store")
+	require.NoError(t, err)
+}
+
+func TestParseTestFile_Errors(t *testing.T) {
+	_, err := ParseTestFile(testFileJSON(t, "package store\n\nfunc TestPut(t *testing.T) {"), "store")
+	require.ErrorContains(t, err, "does not parse")
+
+	_, err = ParseTestFile(testFileJSON(t, "package other\n\nfunc TestPut(t *testing.T) {}"), "store")
+	require.ErrorContains(t, err, "want store or store_test")
+
+	_, err = ParseTestFile(testFileJSON(t, "package store\n\nfunc helper() {}"), "store")
+	require.ErrorIs(t, err, ErrNoTestFuncs)
+}
@@ -0,0 +1,63 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	VerdictYes = "yes"
+	VerdictNo  = "no"
+)
+
+var ErrInvalidVerdict = errors.New(`"verdict" must be "yes" or "no"`)
+
+// Verdict is the answer to a verification request: whether a candidate
+// issue is a real problem, and why.
+type Verdict struct {
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason"`
+}
+
+// Confirmed reports whether the model agreed the issue is real.
+func (v Verdict) Confirmed() bool {
+	return v.Verdict == VerdictYes
+}
+
+// ParseVerdict decodes a verification response with the same tolerance as
+// ParseResult.
+func ParseVerdict(raw string) (Verdict, error) {
+	obj, err := extractJSONObject(raw)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	var v Verdict
+	if err := json.Unmarshal([]byte(obj), &v); err != nil {
+		v = Verdict{}
+		if repairErr := json.Unmarshal([]byte(repairJSON(obj)), &v); repairErr != nil {
+			return Verdict{}, fmt.Errorf("decode verdict json: %w", err)
+		}
+	}
+
+	v.Verdict = strings.ToLower(strings.TrimSpace(v.Verdict))
+	if v.Verdict != VerdictYes && v.Verdict != VerdictNo {
+		return Verdict{}, fmt.Errorf("%w, got %q", ErrInvalidVerdict, v.Verdict)
+	}
+	return v, nil
+}
+
+// VerdictJSONSchema is the strict-mode JSON Schema of a Verdict.
+func VerdictJSONSchema() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"required":             []string{"verdict", "reason"},
+		"properties": map[string]any{
+			"verdict": map[string]any{"type": "string", "enum": []string{VerdictYes, VerdictNo}},
+			"reason":  map[string]any{"type": "string"},
+		},
+	}
+}
@@ -0,0 +1,27 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVerdict(t *testing.T) {
+	v, err := ParseVerdict("```json\n{\"verdict\": \"No\", \"reason\": \"err is checked two lines below\",}\n```")
+	require.NoError(t, err)
+	require.Equal(t, VerdictNo, v.Verdict)
+	require.False(t, v.Confirmed())
+	require.Equal(t, "err is checked two lines below", v.Reason)
+
+	v, err = ParseVerdict(`{"verdict":"yes","reason":"query is built from user input"}`)
+	require.NoError(t, err)
+	require.True(t, v.Confirmed())
+}
+
+func TestParseVerdict_Errors(t *testing.T) {
+	_, err := ParseVerdict("Yes, this is a real problem.")
+	require.ErrorIs(t, err, ErrNoJSONObject)
+
+	_, err = ParseVerdict(`{"verdict":"maybe","reason":"unclear"}`)
+	require.ErrorIs(t, err, ErrInvalidVerdict)
+}
@@ -0,0 +1,129 @@
+package routing
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/prompt"
+)
+
+// Selection is the provider and model chosen for a request.
+type Selection struct {
+	Rule     string
+	Provider string
+	Model    string
+}
+
+// Router picks a model per chunk from the MODEL_ROUTES_PATH rules.
+type Router struct {
+	routes []route
+}
+
+type route struct {
+	config.ModelRoute
+	globs []*regexp.Regexp
+}
+
+func New(routes []config.ModelRoute) *Router {
+	r := &Router{}
+	for _, mr := range routes {
+		rt := route{ModelRoute: mr}
+		for _, g := range mr.Paths {
+			rt.globs = append(rt.globs, compileGlob(g))
+		}
+		r.routes = append(r.routes, rt)
+	}
+	return r
+}
+
+// Select returns the first route matching the files and their diff text.
+func (r *Router) Select(paths []string, diff string) (Selection, bool) {
+	if r == nil || len(r.routes) == 0 {
+		return Selection{}, false
+	}
+
+	changed := -1
+	var added string
+
+	for _, rt := range r.routes {
+		if len(rt.globs) > 0 && !anyPath(paths, func(p string) bool {
+			return slices.ContainsFunc(rt.globs, func(g *regexp.Regexp) bool { return g.MatchString(p) })
+		}) {
+			continue
+		}
+		if len(rt.Languages) > 0 && !anyPath(paths, func(p string) bool {
+			return slices.Contains(rt.Languages, prompt.Language(p))
+		}) {
+			continue
+		}
+
+		if rt.MinLines > 0 || rt.MaxLines > 0 || len(rt.Signals) > 0 {
+			if changed < 0 {
+				changed, added = scan(diff)
+			}
+		}
+		if rt.MinLines > 0 && changed < rt.MinLines {
+			continue
+		}
+		if rt.MaxLines > 0 && changed > rt.MaxLines {
+			continue
+		}
+		if len(rt.Signals) > 0 && !slices.ContainsFunc(rt.Signals, func(s string) bool {
+			return signals[s].MatchString(added)
+		}) {
+			continue
+		}
+
+		return Selection{Rule: rt.Name, Provider: rt.Provider, Model: rt.Model}, true
+	}
+
+	return Selection{}, false
+}
+
+func anyPath(paths []string, match func(string) bool) bool {
+	return slices.ContainsFunc(paths, match)
+}
+
+// scan counts changed diff lines and joins the added ones.
+func scan(diff string) (int, string) {
+	var added strings.Builder
+	changed := 0
+	for _, l := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(l, "+"):
+			changed++
+			added.WriteString(l[1:])
+			added.WriteByte('\n')
+		case strings.HasPrefix(l, "-"):
+			changed++
+		}
+	}
+	return changed, added.String()
+}
+
+// compileGlob turns a path glob into a regexp. "**/" matches any number of
+// directories, "*" and "?" stay within one path segment.
+func compileGlob(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
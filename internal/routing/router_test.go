@@ -0,0 +1,57 @@
+package routing
+
+import (
+	"testing"
+
+	"ai-code-reviewer/internal/config"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	r := New([]config.ModelRoute{
+		{Name: "auth", Paths: []string{"**/auth/**"}, Provider: "anthropic", Model: "claude-opus-4-1"},
+		{Name: "risky", Signals: []string{config.SignalSQL, config.SignalExec}, Provider: "openai", Model: "gpt-4.1"},
+		{Name: "scripts", Languages: []string{"python"}, Provider: "openai", Model: "gpt-4o-mini"},
+		{Name: "small", Languages: []string{"go"}, MaxLines: 3, Provider: "openai", Model: "gpt-4o-mini"},
+	})
+
+	tests := []struct {
+		name  string
+		paths []string
+		diff  string
+		want  string
+	}{
+		{"nested auth path", []string{"internal/auth/token.go"}, "+x", "auth"},
+		{"top-level auth dir", []string{"auth/login.py"}, "+x", "auth"},
+		{"sql in added line", []string{"store/user.go"}, "+db.QueryContext(ctx, q)", "risky"},
+		{"sql only removed", []string{"store/user.go"}, "-db.QueryContext(ctx, q)\n+x\n+y\n+z", ""},
+		{"exec call", []string{"tools/run.go"}, "+cmd := exec.Command(\"sh\")", "risky"},
+		{"language", []string{"scripts/release.py"}, "+print(1)", "scripts"},
+		{"small go change", []string{"cmd/main.go"}, "+a\n-b", "small"},
+		{"no match", []string{"web/app.ts"}, "+a", ""},
+		{"any file of a batch", []string{"web/app.ts", "pkg/auth/jwt.go"}, "+a", "auth"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, ok := r.Select(tt.paths, tt.diff)
+			require.Equal(t, tt.want != "", ok)
+			require.Equal(t, tt.want, sel.Rule)
+		})
+	}
+}
+
+func TestCompileGlob(t *testing.T) {
+	require.True(t, compileGlob("*.sql").MatchString("schema.sql"))
+	require.False(t, compileGlob("*.sql").MatchString("db/schema.sql"))
+	require.True(t, compileGlob("**/*.sql").MatchString("db/schema.sql"))
+	require.True(t, compileGlob("cmd/?/main.go").MatchString("cmd/a/main.go"))
+	require.False(t, compileGlob("docs/**").MatchString("internal/docs.go"))
+}
+
+func TestSelect_NilRouter(t *testing.T) {
+	var r *Router
+	_, ok := r.Select([]string{"main.go"}, "+x")
+	require.False(t, ok)
+}
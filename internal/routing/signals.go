@@ -0,0 +1,19 @@
+package routing
+
+import (
+	"regexp"
+
+	"ai-code-reviewer/internal/config"
+)
+
+// signals flag risky code in added lines. They are deliberately broad: a
+// false positive only costs a stronger model.
+var signals = map[string]*regexp.Regexp{
+	config.SignalSQL: regexp.MustCompile(
+		`(?i)\b(select\s.+\sfrom|insert\s+into|update\s.+\sset|delete\s+from)\b|database/sql|` +
+			`\.(Query|QueryRow|Exec)(Context)?\(|cursor\.execute|\.raw\(`),
+	config.SignalCrypto: regexp.MustCompile(
+		`(?i)crypto/|\b(md5|sha1|sha256|hmac|aes|rsa|ecdsa|bcrypt|scrypt|argon2|jwt)\b|createCipher|createHash`),
+	config.SignalExec: regexp.MustCompile(
+		`os/exec|exec\.Command|syscall\.Exec|subprocess\.|os\.system\(|child_process|\beval\(|\bexec\(`),
+}
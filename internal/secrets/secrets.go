@@ -0,0 +1,270 @@
+// Package secrets finds credentials in diffs and code and redacts them, so
+// they are reported without ever reaching an AI provider.
+package secrets
+
+import (
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/diff"
+)
+
+// Rule IDs.
+const (
+	RuleAWSAccessKey = "aws_access_key"
+	RuleGitHubToken  = "github_token"
+	RulePrivateKey   = "private_key"
+	RuleJWT          = "jwt"
+	RuleSlackWebhook = "slack_webhook"
+	RuleHighEntropy  = "high_entropy"
+)
+
+const (
+	// minEntropy is when an unknown token looks random enough to be a
+	// secret, in bits per character.
+	minEntropy  = 4.0
+	maskedChars = 4
+)
+
+// Rule is a known secret format.
+type Rule struct {
+	ID   string
+	Name string
+	re   *regexp.Regexp
+	// keep is how much of a match is shown when it is masked.
+	keep int
+}
+
+// Rules are the known formats, checked before the entropy rule.
+var Rules = []Rule{
+	{ID: RuleAWSAccessKey, Name: "AWS access key", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), keep: maskedChars},
+	{ID: RuleGitHubToken, Name: "GitHub token", re: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`), keep: maskedChars},
+	{ID: RulePrivateKey, Name: "private key", re: privateKeyBegin, keep: math.MaxInt},
+	{ID: RuleJWT, Name: "JWT", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), keep: maskedChars},
+	{ID: RuleSlackWebhook, Name: "Slack webhook URL", re: regexp.MustCompile(`https://hooks\.slack\.com/services/T[A-Za-z0-9]+/B[A-Za-z0-9]+/[A-Za-z0-9]+`), keep: len("https://hooks.slack.com/services/")},
+}
+
+var highEntropy = Rule{ID: RuleHighEntropy, Name: "high-entropy string", keep: maskedChars}
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN[A-Z ]*PRIVATE KEY(?: BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END[A-Z ]*PRIVATE KEY(?: BLOCK)?-----`)
+	// entropyCandidate finds quoted or assigned tokens of 20 characters
+	// or more; group 1 is the token.
+	entropyCandidate = regexp.MustCompile(`(?:["'\x60]|[=:]\s*)([A-Za-z0-9+/_-]{20,}={0,2})`)
+)
+
+// Finding is a secret in an added line.
+type Finding struct {
+	Rule string
+	Name string
+	Line int
+	// Masked shows the start of the secret only.
+	Masked string
+}
+
+// Detector finds and redacts secrets. A nil Detector finds nothing and
+// leaves content unchanged.
+type Detector struct {
+	allow config.SecretAllowlist
+}
+
+func New(allow config.SecretAllowlist) *Detector {
+	return &Detector{allow: allow}
+}
+
+// Allowed reports whether file is exempt, e.g. a test fixture.
+func (d *Detector) Allowed(file string) bool {
+	if d == nil {
+		return true
+	}
+	parts := strings.Split(file, "/")
+	for i := range parts {
+		tail := path.Join(parts[i:]...)
+		if slices.ContainsFunc(d.allow.Paths, func(p string) bool {
+			ok, _ := path.Match(p, tail)
+			return ok
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff returns fd with secrets redacted from every line, and the secrets
+// found in added lines.
+func (d *Detector) Diff(fd diff.FileDiff) (diff.FileDiff, []Finding) {
+	if d.Allowed(fd.Filename) {
+		return fd, nil
+	}
+
+	var findings []Finding
+	sc := newScanner(fd.Filename)
+	out := diff.FileDiff{Filename: fd.Filename, Hunks: make([]diff.Hunk, 0, len(fd.Hunks))}
+	for _, h := range fd.Hunks {
+		lines := make([]diff.Line, len(h.Lines))
+		for i, l := range h.Lines {
+			redacted, found := d.line(&sc, l.Content)
+			l.Content = redacted
+			lines[i] = l
+			if l.Type != diff.Added {
+				continue
+			}
+			for _, f := range found {
+				f.Line = l.NewNumber
+				findings = append(findings, f)
+			}
+		}
+		h.Lines = lines
+		out.Hunks = append(out.Hunks, h)
+	}
+	return out, findings
+}
+
+// Text redacts the secrets in text taken from file.
+func (d *Detector) Text(file, text string) string {
+	if d.Allowed(file) || text == "" {
+		return text
+	}
+
+	sc := newScanner(file)
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i], _ = d.line(&sc, l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// scanner carries state across the lines of one text: the lines between
+// a private key's BEGIN and END markers are redacted whole.
+type scanner struct {
+	inKey bool
+	// checksums skips the high-entropy rule in lock files, whose hashes
+	// would all match it.
+	checksums bool
+}
+
+// checksumFiles list dependency hashes on every line.
+var checksumFiles = []string{"go.sum", "package-lock.json", "npm-shrinkwrap.json", "pnpm-lock.yaml"}
+
+func newScanner(file string) scanner {
+	return scanner{checksums: slices.Contains(checksumFiles, path.Base(file)) || path.Ext(file) == ".lock"}
+}
+
+func (d *Detector) line(sc *scanner, s string) (string, []Finding) {
+	if sc.inKey {
+		if privateKeyEnd.MatchString(s) {
+			sc.inKey = false
+			return s, nil
+		}
+		return placeholder(RulePrivateKey), nil
+	}
+
+	type span struct {
+		rule       Rule
+		start, end int
+	}
+	var spans []span
+	overlaps := func(start, end int) bool {
+		return slices.ContainsFunc(spans, func(sp span) bool { return start < sp.end && sp.start < end })
+	}
+
+	for _, r := range Rules {
+		for _, m := range r.re.FindAllStringIndex(s, -1) {
+			if !overlaps(m[0], m[1]) && !d.allowedValue(s[m[0]:m[1]]) {
+				spans = append(spans, span{rule: r, start: m[0], end: m[1]})
+			}
+		}
+	}
+	for _, m := range entropyCandidate.FindAllStringSubmatchIndex(s, -1) {
+		start, end := m[2], m[3]
+		token := s[start:end]
+		if !sc.checksums && !overlaps(start, end) && LooksRandom(token, minEntropy) && !d.allowedValue(token) {
+			spans = append(spans, span{rule: highEntropy, start: start, end: end})
+		}
+	}
+	if len(spans) == 0 {
+		return s, nil
+	}
+
+	slices.SortFunc(spans, func(a, b span) int { return a.start - b.start })
+
+	var b strings.Builder
+	findings := make([]Finding, 0, len(spans))
+	last := 0
+	for _, sp := range spans {
+		if sp.start < last {
+			// inside a private key
+			continue
+		}
+		secret := s[sp.start:sp.end]
+		findings = append(findings, Finding{Rule: sp.rule.ID, Name: sp.rule.Name, Masked: mask(secret, sp.rule.keep)})
+		b.WriteString(s[last:sp.start])
+
+		if sp.rule.ID != RulePrivateKey {
+			b.WriteString(placeholder(sp.rule.ID))
+			last = sp.end
+			continue
+		}
+
+		// the markers are not secret, what is between them is
+		b.WriteString(secret + placeholder(RulePrivateKey))
+		if end := privateKeyEnd.FindStringIndex(s[sp.end:]); end != nil {
+			last = sp.end + end[0]
+			continue
+		}
+		sc.inKey = true
+		last = len(s)
+	}
+	b.WriteString(s[last:])
+	return b.String(), findings
+}
+
+func (d *Detector) allowedValue(v string) bool {
+	return slices.Contains(d.allow.Values, v)
+}
+
+func placeholder(rule string) string {
+	return "[REDACTED:" + rule + "]"
+}
+
+func mask(secret string, keep int) string {
+	if keep >= len(secret) {
+		return secret
+	}
+	return secret[:keep] + "****"
+}
+
+// LooksRandom reports whether a token looks like generated key material:
+// it mixes upper case, lower case and digits and has at least minEntropy
+// bits of Shannon entropy per character.
+func LooksRandom(token string, minEntropy float64) bool {
+	var upper, lower, digit bool
+	counts := make(map[rune]int)
+	for _, c := range token {
+		counts[c]++
+		switch {
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c >= 'a' && c <= 'z':
+			lower = true
+		case c >= '0' && c <= '9':
+			digit = true
+		}
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+
+	var entropy float64
+	n := float64(len(token))
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minEntropy
+}
@@ -0,0 +1,74 @@
+package tokenizer
+
+import "strings"
+
+// Model describes the limits of one model family.
+type Model struct {
+	Name          string
+	Encoding      string
+	ContextWindow int
+	MaxOutput     int
+	// JSONSchema is set for OpenAI models that accept a json_schema
+	// response_format.
+	JSONSchema bool
+}
+
+// Tokenizer returns the tokenizer matching the model's encoding.
+func (m Model) Tokenizer() Tokenizer {
+	return For(m.Encoding)
+}
+
+// models is matched by longest prefix, so "gpt-4o-mini-2024-07-18" finds
+// "gpt-4o-mini" and "llama3.1:8b" finds "llama3.1".
+var models = []Model{
+	{Name: "gpt-3.5-turbo", Encoding: CL100K, ContextWindow: 16385, MaxOutput: 4096},
+	{Name: "gpt-4", Encoding: CL100K, ContextWindow: 8192, MaxOutput: 8192},
+	{Name: "gpt-4-turbo", Encoding: CL100K, ContextWindow: 128000, MaxOutput: 4096},
+	{Name: "gpt-4o", Encoding: O200K, ContextWindow: 128000, MaxOutput: 16384, JSONSchema: true},
+	{Name: "gpt-4o-mini", Encoding: O200K, ContextWindow: 128000, MaxOutput: 16384, JSONSchema: true},
+	{Name: "gpt-4.1", Encoding: O200K, ContextWindow: 1047576, MaxOutput: 32768, JSONSchema: true},
+	{Name: "o1", Encoding: O200K, ContextWindow: 200000, MaxOutput: 100000, JSONSchema: true},
+	{Name: "o3", Encoding: O200K, ContextWindow: 200000, MaxOutput: 100000, JSONSchema: true},
+	{Name: "o4-mini", Encoding: O200K, ContextWindow: 200000, MaxOutput: 100000, JSONSchema: true},
+	{Name: "llama2", Encoding: Llama, ContextWindow: 4096, MaxOutput: 2048},
+	{Name: "llama3", Encoding: Llama, ContextWindow: 8192, MaxOutput: 2048},
+	{Name: "llama3.1", Encoding: Llama, ContextWindow: 131072, MaxOutput: 4096},
+	{Name: "llama3.2", Encoding: Llama, ContextWindow: 131072, MaxOutput: 4096},
+	{Name: "llama3.3", Encoding: Llama, ContextWindow: 131072, MaxOutput: 4096},
+	{Name: "codellama", Encoding: Llama, ContextWindow: 16384, MaxOutput: 4096},
+	{Name: "claude-3-haiku", Encoding: Claude, ContextWindow: 200000, MaxOutput: 4096},
+	{Name: "claude-3-5", Encoding: Claude, ContextWindow: 200000, MaxOutput: 8192},
+	{Name: "claude-3-7", Encoding: Claude, ContextWindow: 200000, MaxOutput: 64000},
+	{Name: "claude-sonnet-4", Encoding: Claude, ContextWindow: 200000, MaxOutput: 64000},
+	{Name: "claude-opus-4", Encoding: Claude, ContextWindow: 200000, MaxOutput: 32000},
+	{Name: "claude-haiku-4", Encoding: Claude, ContextWindow: 200000, MaxOutput: 64000},
+}
+
+// unknownModel is deliberately small so an unlisted model is never
+// overfilled.
+var unknownModel = Model{Encoding: Approx, ContextWindow: 8192, MaxOutput: 2048}
+
+// Lookup returns the registry entry for a model name.
+func Lookup(name string) Model {
+	lower := strings.ToLower(name)
+
+	best := -1
+	for i, m := range models {
+		if !strings.HasPrefix(lower, m.Name) {
+			continue
+		}
+		if best < 0 || len(m.Name) > len(models[best].Name) {
+			best = i
+		}
+	}
+
+	if best < 0 {
+		m := unknownModel
+		m.Name = name
+		return m
+	}
+
+	m := models[best]
+	m.Name = name
+	return m
+}
@@ -0,0 +1,104 @@
+package tokenizer
+
+import (
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// Encodings known to the registry. The BPE tables for the tiktoken ones
+// are embedded in the binary, so counting never touches the network.
+const (
+	CL100K = "cl100k_base"
+	O200K  = "o200k_base"
+	// Llama and Claude have no embedded table; counts are approximated.
+	Llama  = "llama"
+	Claude = "claude"
+	// Approx is the rune-ratio estimate used for unknown models.
+	Approx = "approx"
+)
+
+// Tokenizer counts the tokens a model would see for a text.
+type Tokenizer interface {
+	Count(s string) int
+	Encoding() string
+}
+
+func init() {
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+var (
+	mu     sync.Mutex
+	cached = make(map[string]Tokenizer)
+)
+
+// For returns the tokenizer for an encoding. Unknown encodings, and BPE
+// tables that fail to load, fall back to an approximation.
+func For(encoding string) Tokenizer {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if t, ok := cached[encoding]; ok {
+		return t
+	}
+
+	var t Tokenizer
+	switch encoding {
+	case CL100K, O200K:
+		t = &bpe{encoding: encoding}
+	case Llama:
+		// Llama 3 vocabularies average close to four characters a token on
+		// code, sentencepiece based Llama 2 slightly less.
+		t = approx{encoding: Llama, runesPerToken: 3.5}
+	case Claude:
+		// Claude's tokenizer is not published; it runs slightly denser
+		// than cl100k on code.
+		t = approx{encoding: Claude, runesPerToken: 3.2}
+	default:
+		t = approx{encoding: Approx, runesPerToken: 3}
+	}
+
+	cached[encoding] = t
+	return t
+}
+
+// bpe loads its table on first use; parsing o200k takes a noticeable
+// fraction of a second.
+type bpe struct {
+	encoding string
+	once     sync.Once
+	enc      *tiktoken.Tiktoken
+}
+
+func (b *bpe) Count(s string) int {
+	b.once.Do(func() {
+		enc, err := tiktoken.GetEncoding(b.encoding)
+		if err == nil {
+			b.enc = enc
+		}
+	})
+	if b.enc == nil {
+		return For(Approx).Count(s)
+	}
+	return len(b.enc.EncodeOrdinary(s))
+}
+
+func (b *bpe) Encoding() string {
+	return b.encoding
+}
+
+type approx struct {
+	encoding      string
+	runesPerToken float64
+}
+
+func (a approx) Count(s string) int {
+	return int(float64(utf8.RuneCountInString(s)) / a.runesPerToken)
+}
+
+func (a approx) Encoding() string {
+	return a.encoding
+}
@@ -0,0 +1,47 @@
+package tokenizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFor_BPEEncodings(t *testing.T) {
+	require.Equal(t, 2, For(CL100K).Count("hello world"))
+	require.Equal(t, 2, For(O200K).Count("hello world"))
+	require.Equal(t, 0, For(O200K).Count(""))
+	require.Same(t, For(CL100K), For(CL100K))
+}
+
+func TestFor_Approximations(t *testing.T) {
+	require.Equal(t, Approx, For("unknown").Encoding())
+	require.Equal(t, 4, For(Approx).Count("abcdefghijkl"))
+	require.Equal(t, 2, For(Llama).Count("abcdefg"))
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		window   int
+	}{
+		{"gpt-4o-mini-2024-07-18", O200K, 128000},
+		{"gpt-4-0613", CL100K, 8192},
+		{"gpt-4-turbo-preview", CL100K, 128000},
+		{"GPT-3.5-Turbo", CL100K, 16385},
+		{"llama3.1:8b", Llama, 131072},
+		{"llama3", Llama, 8192},
+		{"claude-sonnet-4-5", Claude, 200000},
+		{"mistral:7b", Approx, 8192},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Lookup(tt.name)
+			require.Equal(t, tt.name, m.Name)
+			require.Equal(t, tt.encoding, m.Encoding)
+			require.Equal(t, tt.window, m.ContextWindow)
+			require.Positive(t, m.MaxOutput)
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package vet
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// runner is a minimal go/analysis driver for one package: analyzers run
+// once each, after their requirements, and facts stay in memory.
+type runner struct {
+	fset   *token.FileSet
+	files  []*ast.File
+	pkg    *types.Package
+	info   *types.Info
+	sizes  types.Sizes
+	report func(*analysis.Analyzer, analysis.Diagnostic)
+
+	results map[*analysis.Analyzer]result
+	facts   map[factKey]analysis.Fact
+}
+
+type result struct {
+	value any
+	err   error
+}
+
+type factKey struct {
+	obj types.Object
+	pkg *types.Package
+	typ reflect.Type
+}
+
+func (r *runner) run(a *analysis.Analyzer) (value any, err error) {
+	if res, ok := r.results[a]; ok {
+		return res.value, res.err
+	}
+	defer func() {
+		// analyzers are not written for ill-typed code
+		if p := recover(); p != nil {
+			value, err = nil, fmt.Errorf("%s panicked: %v", a.Name, p)
+		}
+		r.results[a] = result{value: value, err: err}
+	}()
+
+	resultOf := make(map[*analysis.Analyzer]any, len(a.Requires))
+	for _, req := range a.Requires {
+		v, err := r.run(req)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", req.Name, err)
+		}
+		resultOf[req] = v
+	}
+
+	pass := &analysis.Pass{
+		Analyzer:   a,
+		Fset:       r.fset,
+		Files:      r.files,
+		Pkg:        r.pkg,
+		TypesInfo:  r.info,
+		TypesSizes: r.sizes,
+		ResultOf:   resultOf,
+		Report:     func(d analysis.Diagnostic) { r.report(a, d) },
+		ReadFile: func(filename string) ([]byte, error) {
+			return nil, os.ErrNotExist
+		},
+		ImportObjectFact: func(obj types.Object, fact analysis.Fact) bool {
+			return r.importFact(factKey{obj: obj, typ: reflect.TypeOf(fact)}, fact)
+		},
+		ImportPackageFact: func(pkg *types.Package, fact analysis.Fact) bool {
+			return r.importFact(factKey{pkg: pkg, typ: reflect.TypeOf(fact)}, fact)
+		},
+		ExportObjectFact: func(obj types.Object, fact analysis.Fact) {
+			r.facts[factKey{obj: obj, typ: reflect.TypeOf(fact)}] = fact
+		},
+		ExportPackageFact: func(fact analysis.Fact) {
+			r.facts[factKey{pkg: r.pkg, typ: reflect.TypeOf(fact)}] = fact
+		},
+		AllObjectFacts: func() []analysis.ObjectFact {
+			var out []analysis.ObjectFact
+			for k, f := range r.facts {
+				if k.obj != nil && ownsFact(a, k.typ) {
+					out = append(out, analysis.ObjectFact{Object: k.obj, Fact: f})
+				}
+			}
+			return out
+		},
+		AllPackageFacts: func() []analysis.PackageFact {
+			var out []analysis.PackageFact
+			for k, f := range r.facts {
+				if k.pkg != nil && ownsFact(a, k.typ) {
+					out = append(out, analysis.PackageFact{Package: k.pkg, Fact: f})
+				}
+			}
+			return out
+		},
+	}
+
+	return a.Run(pass)
+}
+
+// importFact copies a stored fact into fact, which has the same type.
+func (r *runner) importFact(k factKey, fact analysis.Fact) bool {
+	stored, ok := r.facts[k]
+	if !ok {
+		return false
+	}
+	reflect.ValueOf(fact).Elem().Set(reflect.ValueOf(stored).Elem())
+	return true
+}
+
+func ownsFact(a *analysis.Analyzer, typ reflect.Type) bool {
+	return slices.ContainsFunc(a.FactTypes, func(f analysis.Fact) bool {
+		return reflect.TypeOf(f) == typ
+	})
+}
@@ -0,0 +1,229 @@
+// Package vet runs vet-style go/analysis passes on a single Go file at the
+// PR head, without a module checkout.
+package vet
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"ai-code-reviewer/internal/codectx"
+	"ai-code-reviewer/internal/observability"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/nilness"
+	"golang.org/x/tools/go/analysis/passes/printf"
+	"golang.org/x/tools/go/analysis/passes/shadow"
+	"golang.org/x/tools/go/analysis/passes/unusedresult"
+)
+
+// Analyzers are the passes Run applies.
+var Analyzers = []*analysis.Analyzer{
+	printf.Analyzer,
+	shadow.Analyzer,
+	nilness.Analyzer,
+	unusedresult.Analyzer,
+}
+
+// Diagnostic is one analyzer finding in the analyzed file.
+type Diagnostic struct {
+	Analyzer string
+	Line     int
+	Message  string
+}
+
+// imports resolves standard library packages from the Go toolchain's
+// export data and stands in empty packages for everything else, so a file
+// type-checks as far as it can without its dependencies. It is shared, and
+// locked, because export data loading is slow. The export data comes from
+// the toolchain at run time: see CheckStdlib.
+var imports = &lenientImporter{
+	base: importer.Default(),
+	fake: make(map[string]*types.Package),
+}
+
+// Run type-checks file together with the other files of its package and
+// reports the Analyzers' diagnostics for file. The analyzers need
+// well-typed code, so declarations with type errors, mostly from
+// unresolved dependencies, are left out: see typeCheck. Only a file that
+// does not parse is an error.
+func Run(file codectx.Source, pkg []codectx.Source) ([]Diagnostic, error) {
+	fset := token.NewFileSet()
+	target, err := parser.ParseFile(fset, file.Path, file.Content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []*ast.File{target}
+	for _, src := range pkg {
+		f, err := parser.ParseFile(fset, src.Path, src.Content, parser.ParseComments)
+		if err != nil || f.Name.Name != target.Name.Name {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	sizes := types.SizesFor("gc", "amd64")
+	pkgTypes, info, ok := typeCheck(fset, target.Name.Name, files, sizes)
+	if !ok {
+		return nil, nil
+	}
+
+	r := &runner{
+		fset:    fset,
+		files:   files,
+		pkg:     pkgTypes,
+		info:    info,
+		sizes:   sizes,
+		results: make(map[*analysis.Analyzer]result),
+		facts:   make(map[factKey]analysis.Fact),
+	}
+
+	var out []Diagnostic
+	seen := make(map[Diagnostic]bool)
+	r.report = func(a *analysis.Analyzer, d analysis.Diagnostic) {
+		pos := fset.Position(d.Pos)
+		if pos.Filename != file.Path {
+			return
+		}
+		diag := Diagnostic{Analyzer: a.Name, Line: pos.Line, Message: d.Message}
+		if !seen[diag] {
+			seen[diag] = true
+			out = append(out, diag)
+		}
+	}
+
+	for _, a := range Analyzers {
+		r.run(a)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Line < out[j].Line })
+	return out, nil
+}
+
+// maxCheckRounds bounds how often typeCheck prunes and re-checks.
+const maxCheckRounds = 8
+
+// typeCheck checks files, dropping what does not type-check until the rest
+// does: the body of a function with an error in it, or the whole
+// declaration for errors in signatures and package-level declarations.
+// Anything using a dropped declaration is dropped in the next round. ok is
+// false when no well-typed package remains.
+func typeCheck(fset *token.FileSet, name string, files []*ast.File, sizes types.Sizes) (*types.Package, *types.Info, bool) {
+	for range maxCheckRounds {
+		var errs []types.Error
+		conf := types.Config{
+			Importer: imports,
+			Sizes:    sizes,
+			// dropped bodies leave imports unused
+			DisableUnusedImportCheck: true,
+			Error: func(err error) {
+				if te, ok := err.(types.Error); ok {
+					errs = append(errs, te)
+				}
+			},
+		}
+
+		info := newInfo()
+		pkg, _ := conf.Check(name, fset, files, info)
+		if len(errs) == 0 {
+			return pkg, info, true
+		}
+		if !prune(files, errs) {
+			return nil, nil, false
+		}
+	}
+	return nil, nil, false
+}
+
+// prune drops the code holding each error and reports whether anything
+// was dropped.
+func prune(files []*ast.File, errs []types.Error) bool {
+	dropped := make(map[ast.Decl]bool)
+	pruned := false
+
+	for _, e := range errs {
+		for _, f := range files {
+			for _, d := range f.Decls {
+				if e.Pos < d.Pos() || e.Pos >= d.End() {
+					continue
+				}
+				if fn, ok := d.(*ast.FuncDecl); ok && fn.Body != nil && e.Pos >= fn.Body.Pos() {
+					fn.Body = nil
+				} else {
+					dropped[d] = true
+				}
+				pruned = true
+			}
+		}
+	}
+
+	for _, f := range files {
+		f.Decls = slices.DeleteFunc(f.Decls, func(d ast.Decl) bool { return dropped[d] })
+	}
+	return pruned
+}
+
+func newInfo() *types.Info {
+	return &types.Info{
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Implicits:    make(map[ast.Node]types.Object),
+		Instances:    make(map[*ast.Ident]types.Instance),
+		Scopes:       make(map[ast.Node]*types.Scope),
+		Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+		FileVersions: make(map[*ast.File]string),
+	}
+}
+
+// CheckStdlib reports whether standard library export data can be loaded.
+// It needs a Go toolchain at run time; without one every standard library
+// import is a stand-in and the analyzers find next to nothing.
+func CheckStdlib() error {
+	_, err := imports.base.Import("fmt")
+	return err
+}
+
+type lenientImporter struct {
+	mu   sync.Mutex
+	base types.Importer
+	fake map[string]*types.Package
+}
+
+func (l *lenientImporter) Import(importPath string) (*types.Package, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if p, ok := l.fake[importPath]; ok {
+		return p, nil
+	}
+	if p, err := l.base.Import(importPath); err == nil {
+		return p, nil
+	}
+
+	p := types.NewPackage(importPath, path.Base(importPath))
+	p.MarkComplete()
+	// a standard library package should have resolved; count it and try
+	// again next time rather than analyze against a stand-in for good
+	if isStdlib(importPath) {
+		observability.StaticStdlibFallbacks.Inc()
+		return p, nil
+	}
+	l.fake[importPath] = p
+	return p, nil
+}
+
+// isStdlib reports whether importPath names a standard library package:
+// module paths start with a domain name.
+func isStdlib(importPath string) bool {
+	first, _, _ := strings.Cut(importPath, "/")
+	return !strings.Contains(first, ".")
+}
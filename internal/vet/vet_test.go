@@ -0,0 +1,107 @@
+package vet
+
+import (
+	"errors"
+	"go/types"
+	"testing"
+
+	"ai-code-reviewer/internal/codectx"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_ReportsVetFindings(t *testing.T) {
+	// Label uses a package that cannot be resolved, so its body, with
+	// another printf mistake, is left out rather than breaking the rest.
+	src := "package store\n" + //                          1
+		"\n" + //                                           2
+		"import (\n" + //                                   3
+		"\t\"fmt\"\n" + //                                  4
+		"\n" + //                                           5
+		"\t\"example.com/missing/dep\"\n" + //              6
+		")\n" + //                                          7
+		"\n" + //                                           8
+		"func Describe(s *Store, id int) string {\n" + //   9
+		"\tfmt.Printf(\"%d\\n\", \"id\")\n" + //            10
+		"\tfmt.Sprintf(\"%d\", id)\n" + //                  11
+		"\tif s == nil {\n" + //                            12
+		"\t\treturn s.name\n" + //                          13
+		"\t}\n" + //                                        14
+		"\treturn Label(s)\n" + //                          15
+		"}\n" + //                                          16
+		"\n" + //                                           17
+		"func Label(s *Store) string {\n" + //              18
+		"\tfmt.Printf(\"%d\\n\", s.name)\n" + //             19
+		"\treturn dep.Name(s.name)\n" + //                  20
+		"}\n"
+	other := "package store\n\ntype Store struct{ name string }\n"
+
+	diags, err := Run(
+		codectx.Source{Path: "store/describe.go", Content: src},
+		[]codectx.Source{{Path: "store/store.go", Content: other}},
+	)
+	require.NoError(t, err)
+
+	byAnalyzer := make(map[string]int)
+	for _, d := range diags {
+		byAnalyzer[d.Analyzer] = d.Line
+	}
+	require.Equal(t, map[string]int{"printf": 10, "unusedresult": 11, "nilness": 13}, byAnalyzer)
+}
+
+func TestRun_ShadowedError(t *testing.T) {
+	src := "package main\n" +
+		"\n" +
+		"import \"os\"\n" +
+		"\n" +
+		"func Open(name string) error {\n" +
+		"\tf, err := os.Open(name)\n" +
+		"\tif err == nil {\n" +
+		"\t\t_, err := f.Stat()\n" +
+		"\t\tif err != nil {\n" +
+		"\t\t\treturn err\n" +
+		"\t\t}\n" +
+		"\t}\n" +
+		"\treturn err\n" +
+		"}\n"
+
+	diags, err := Run(codectx.Source{Path: "main.go", Content: src}, nil)
+	require.NoError(t, err)
+	require.Len(t, diags, 1)
+	require.Equal(t, "shadow", diags[0].Analyzer)
+	require.Equal(t, 8, diags[0].Line)
+}
+
+func TestRun_ParseError(t *testing.T) {
+	_, err := Run(codectx.Source{Path: "main.go", Content: "package main\nfunc {"}, nil)
+	require.Error(t, err)
+}
+
+type failingImporter struct{ calls int }
+
+func (f *failingImporter) Import(path string) (*types.Package, error) {
+	f.calls++
+	return nil, errors.New("no export data")
+}
+
+func TestLenientImporter_RetriesStdlib(t *testing.T) {
+	base := &failingImporter{}
+	l := &lenientImporter{base: base, fake: make(map[string]*types.Package)}
+
+	for range 2 {
+		p, err := l.Import("fmt")
+		require.NoError(t, err)
+		require.Equal(t, "fmt", p.Name())
+
+		_, err = l.Import("github.com/acme/dep")
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, 3, base.calls)
+	require.NotContains(t, l.fake, "fmt")
+	require.Contains(t, l.fake, "github.com/acme/dep")
+}
+
+func TestCheckStdlib(t *testing.T) {
+	require.NoError(t, CheckStdlib())
+}
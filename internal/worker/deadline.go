@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"time"
+)
+
+// A review job has a base deadline that each enabled optional pass extends
+// by its share. Every step of a pass runs under its own timeout and is cut
+// off before summaryReserve, so a slow pass cannot cost the summary.
+const (
+	// summaryReserve is the time kept back from the job deadline to post
+	// the summary after a step is cut short.
+	summaryReserve = 10 * time.Second
+	// minStepTime is the least time worth starting a step with.
+	minStepTime = 5 * time.Second
+
+	verifyCallTimeout = 20 * time.Second
+	verifyShare       = 60 * time.Second
+	narrativeTimeout  = 30 * time.Second
+	testFileTimeout   = 30 * time.Second
+	staticFileTimeout = 20 * time.Second
+	staticShare       = 30 * time.Second
+)
+
+// WithJobTimeout sets the deadline of one PR review before the optional
+// passes add their share.
+func WithJobTimeout(d time.Duration) Option {
+	return func(p *Processor) {
+		if d > 0 {
+			p.timeout = d
+		}
+	}
+}
+
+// jobTimeout is the base deadline plus the share of each enabled pass.
+func (p *Processor) jobTimeout() time.Duration {
+	d := p.timeout
+	if p.verification != nil {
+		d += verifyShare
+	}
+	if p.narrative {
+		d += narrativeTimeout
+	}
+	if p.testSuggestions != "" {
+		d += maxTestFiles * testFileTimeout
+	}
+	if p.staticAnalysis {
+		d += staticShare
+	}
+	return d
+}
+
+// timeLeft reports whether a step can still start: at least minStepTime
+// remains before the time kept for the summary.
+func timeLeft(ctx context.Context) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline)-summaryReserve >= minStepTime
+}
+
+// stepContext bounds one step to limit and ends it before the summary
+// reserve.
+func stepContext(ctx context.Context, limit time.Duration) (context.Context, context.CancelFunc) {
+	if deadline, ok := ctx.Deadline(); ok {
+		limit = min(limit, time.Until(deadline)-summaryReserve)
+	}
+	return context.WithTimeout(ctx, limit)
+}
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/mocks"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/ratelimit"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessor_JobTimeoutScalesWithPasses(t *testing.T) {
+	newProcessor := func(opts ...Option) *Processor {
+		return NewProcessor(NewMemoryQueue(1), &clientStub{}, nil, nil, nil, nil, nil, nil, opts...)
+	}
+
+	require.Equal(t, processorTimeout, newProcessor().jobTimeout())
+	require.Equal(t, 2*time.Minute, newProcessor(WithJobTimeout(2*time.Minute)).jobTimeout())
+
+	p := newProcessor(
+		WithJobTimeout(time.Minute),
+		WithVerification(true, "", ""),
+		WithNarrative(true),
+		WithTestSuggestions(TestSuggestionsSummary),
+		WithStaticAnalysis(true),
+	)
+	require.Equal(t, time.Minute+verifyShare+narrativeTimeout+maxTestFiles*testFileTimeout+staticShare, p.jobTimeout())
+}
+
+func TestStepContext_StopsBeforeSummaryReserve(t *testing.T) {
+	require.True(t, timeLeft(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), summaryReserve+minStepTime-time.Second)
+	defer cancel()
+	require.False(t, timeLeft(ctx))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	require.True(t, timeLeft(ctx))
+
+	step, stop := stepContext(ctx, time.Hour)
+	defer stop()
+	deadline, _ := step.Deadline()
+	require.WithinDuration(t, time.Now().Add(time.Minute-summaryReserve), deadline, time.Second)
+
+	step, stop = stepContext(ctx, verifyCallTimeout)
+	defer stop()
+	deadline, _ = step.Deadline()
+	require.WithinDuration(t, time.Now().Add(verifyCallTimeout), deadline, time.Second)
+}
+
+func TestProcessorNarrate_SkippedNearDeadline(t *testing.T) {
+	// the provider mock fails the test if it is called
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{},
+		nil,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		mocks.NewProvider(t),
+		ratelimit.New(100, 100),
+		nil,
+		WithNarrative(true),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), summaryReserve)
+	defer cancel()
+
+	summary := reviewSummary{}
+	files := []github.PRFile{{Filename: "main.go", Additions: 1}}
+	p.narrate(ctx, Job{Repo: "acme/repo", PR: 7}, p.rateLimiter.Get("acme/repo"), nil, files, &summary)
+	require.Nil(t, summary.Narrative)
+}
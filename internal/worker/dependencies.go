@@ -0,0 +1,94 @@
+package worker
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-code-reviewer/internal/deps"
+	"ai-code-reviewer/internal/diff"
+	"ai-code-reviewer/internal/observability"
+)
+
+const dependenciesHeading = "### Dependency changes"
+
+// WithDependencyReview parses the dependencies added or upgraded in
+// go.mod, package.json and requirements.txt, and lists the risky ones in
+// the summary: major version jumps, Go pseudo-versions, replace directives
+// and versions with an advisory in db. db may be nil. Manifests are never
+// sent to the AI provider.
+func WithDependencyReview(enabled bool, db *deps.DB) Option {
+	return func(p *Processor) {
+		p.dependencyReview = enabled
+		p.osv = db
+	}
+}
+
+// reviewDependencies records the changes and findings of a manifest diff.
+func (p *Processor) reviewDependencies(fd diff.FileDiff, summary *reviewSummary) {
+	if !p.dependencyReview {
+		return
+	}
+
+	changes := deps.Changes(fd)
+	findings := deps.Review(changes, p.osv)
+	for _, f := range findings {
+		observability.DependencyFindings.WithLabelValues(f.Kind).Inc()
+	}
+	summary.DependencyChanges += len(changes)
+	summary.Dependencies = append(summary.Dependencies, findings...)
+}
+
+// depsSection lists the flagged dependency changes, shown when a manifest
+// changed a dependency.
+func depsSection(s reviewSummary) string {
+	if s.DependencyChanges == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n" + dependenciesHeading)
+	flagged := make(map[deps.Change]bool)
+	for _, f := range s.Dependencies {
+		flagged[f.Change] = true
+		fmt.Fprintf(&b, "\n- `%s` %s: %s", f.Change.Manifest, dependencyLabel(f.Change), findingText(f))
+	}
+	if n := s.DependencyChanges - len(flagged); n > 0 {
+		fmt.Fprintf(&b, "\n- Other dependency changes (not flagged): %d", n)
+	}
+	return b.String()
+}
+
+func dependencyLabel(c deps.Change) string {
+	switch {
+	case c.Replace != "":
+		return fmt.Sprintf("`%s`", c.Name)
+	case c.From != "":
+		return fmt.Sprintf("`%s` %s → %s", c.Name, c.From, c.To)
+	default:
+		return fmt.Sprintf("`%s` %s", c.Name, c.To)
+	}
+}
+
+func findingText(f deps.Finding) string {
+	switch f.Kind {
+	case deps.KindMajor:
+		return "major version upgrade, check the changelog for breaking changes"
+	case deps.KindPseudoVersion:
+		return "pseudo-version pins an untagged commit"
+	case deps.KindReplace:
+		return fmt.Sprintf("replaced by `%s`", f.Change.Replace)
+	}
+
+	v := f.Vulnerability
+	text := "known vulnerability " + v.ID
+	if v.Severity != "" {
+		text += " (" + v.Severity + ")"
+	}
+	if v.Summary != "" {
+		text += ": " + v.Summary
+	}
+	if v.Fixed != "" {
+		text += "; fixed in " + v.Fixed
+	}
+	return text
+}
@@ -0,0 +1,70 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/deps"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/mocks"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/ratelimit"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorHandle_ReviewsDependencies(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+
+	var body string
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.Anything).
+		Run(func(ctx context.Context, repo string, pr int, b string) { body = b }).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{files: []github.PRFile{{
+			Filename: "go.mod",
+			Patch: "@@ -3,3 +3,4 @@\n require (\n-\tgithub.com/acme/log v1.2.0\n+\tgithub.com/acme/log/v2 v2.0.0\n" +
+				"+\tgithub.com/acme/fork v0.0.0-20240102150405-0123456789ab\n+\tgithub.com/acme/util v1.0.0\n )\n",
+		}}},
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithDependencyReview(true, nil),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Contains(t, body, noIssuesSummaryText)
+	require.Contains(t, body, dependenciesHeading+
+		"\n- `go.mod` `github.com/acme/log/v2` v1.2.0 → v2.0.0: major version upgrade, check the changelog for breaking changes"+
+		"\n- `go.mod` `github.com/acme/fork` v0.0.0-20240102150405-0123456789ab: pseudo-version pins an untagged commit"+
+		"\n- Other dependency changes (not flagged): 1")
+}
+
+func TestDepsSection_Vulnerability(t *testing.T) {
+	c := deps.Change{Manifest: "package.json", Ecosystem: deps.EcosystemNPM, Name: "lodash", From: "4.17.20", To: "4.17.20-patch"}
+	s := reviewSummary{
+		DependencyChanges: 1,
+		Dependencies: []deps.Finding{{
+			Change:        c,
+			Kind:          deps.KindVulnerability,
+			Vulnerability: &deps.Vulnerability{ID: "GHSA-aaaa-bbbb-cccc", Summary: "Prototype pollution", Severity: "critical", Fixed: "4.17.21"},
+		}},
+	}
+
+	require.Equal(t, "\n\n"+dependenciesHeading+
+		"\n- `package.json` `lodash` 4.17.20 → 4.17.20-patch: known vulnerability GHSA-aaaa-bbbb-cccc (critical): Prototype pollution; fixed in 4.17.21",
+		depsSection(s))
+}
@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+
+	"ai-code-reviewer/internal/codectx"
+	"ai-code-reviewer/internal/diff"
+	"ai-code-reviewer/internal/github"
+)
+
+const (
+	fileStatusRemoved = "removed"
+	maxPackageFiles   = 20
+)
+
+// WithFileContext fetches each changed file at the PR head and adds the
+// code around its hunks to the prompt. MaxTokens 0 disables it.
+func WithFileContext(opts codectx.Options) Option {
+	return func(p *Processor) {
+		if opts.MaxTokens <= 0 {
+			return
+		}
+		p.fileContext = &opts
+	}
+}
+
+// loadPRMeta fetches the PR once for the features that need it.
+func (p *Processor) loadPRMeta(ctx context.Context, j Job) *github.PRMeta {
+	if p.prContextTokens <= 0 && p.fileContext == nil && !p.narrative && p.testSuggestions == "" && !p.staticAnalysis {
+		return nil
+	}
+
+	meta, err := p.client.GetPR(ctx, j.Repo, j.PR)
+	if err != nil {
+		p.logger.Error("get pr failed", "repo", j.Repo, "pr", j.PR, "err", err)
+		return nil
+	}
+	return &meta
+}
+
+// headFile is a changed file, and its package, at the PR head.
+type headFile struct {
+	src codectx.Source
+	pkg []codectx.Source
+}
+
+// loadHeadFile fetches what surroundingCode needs for one file. Like the PR
+// context it is best effort and returns nil on failure.
+func (p *Processor) loadHeadFile(ctx context.Context, j Job, meta *github.PRMeta, f github.PRFile, pkgs packageCache) *headFile {
+	if p.fileContext == nil || meta == nil || meta.HeadSHA == "" || f.Status == fileStatusRemoved {
+		return nil
+	}
+
+	content, err := p.client.GetFileContent(ctx, j.Repo, f.Filename, meta.HeadSHA)
+	if err != nil {
+		if !errors.Is(err, github.ErrNotFound) {
+			p.logger.Error("get file content failed", "file", f.Filename, "err", err)
+		}
+		return nil
+	}
+
+	hf := &headFile{src: codectx.Source{Path: f.Filename, Content: content}}
+	if p.fileContext.References && p.fileContext.Mode != codectx.ModeWindow && path.Ext(f.Filename) == ".go" {
+		hf.pkg = p.packageSources(ctx, j, meta.HeadSHA, f.Filename, pkgs)
+	}
+	return hf
+}
+
+// surroundingCode renders the head-version code around the given hunks.
+func (p *Processor) surroundingCode(hf *headFile, hunks []diff.Hunk) string {
+	if hf == nil {
+		return ""
+	}
+	return codectx.Build(hf.src, hunks, hf.pkg, *p.fileContext)
+}
+
+// hunkStarts returns the new-file line where each hunk starts.
+func hunkStarts(hunks []diff.Hunk) []int {
+	out := make([]int, 0, len(hunks))
+	for _, h := range hunks {
+		start, _, ok := h.NewRange()
+		if !ok {
+			start = h.NewStart
+		}
+		out = append(out, start)
+	}
+	return out
+}
+
+// packageCache holds the non-test Go files of each directory fetched
+// during a job, so a PR touching several files of a package fetches the
+// package once.
+type packageCache map[string][]codectx.Source
+
+// packageSources loads the other non-test Go files next to file.
+func (p *Processor) packageSources(ctx context.Context, j Job, ref, file string, cache packageCache) []codectx.Source {
+	dir := path.Dir(file)
+	sources, ok := cache[dir]
+	if !ok {
+		sources = p.loadPackage(ctx, j, ref, dir)
+		cache[dir] = sources
+	}
+
+	out := make([]codectx.Source, 0, min(len(sources), maxPackageFiles))
+	for _, src := range sources {
+		if len(out) == maxPackageFiles {
+			break
+		}
+		if src.Path != file {
+			out = append(out, src)
+		}
+	}
+	return out
+}
+
+// loadPackage fetches up to maxPackageFiles+1 non-test Go files of dir:
+// enough for maxPackageFiles besides any one of them.
+func (p *Processor) loadPackage(ctx context.Context, j Job, ref, dir string) []codectx.Source {
+	entries, err := p.client.ListDir(ctx, j.Repo, dir, ref)
+	if err != nil {
+		p.logger.Error("list package dir failed", "dir", dir, "err", err)
+		return nil
+	}
+
+	var out []codectx.Source
+	for _, e := range entries {
+		if len(out) == maxPackageFiles+1 {
+			break
+		}
+		if path.Ext(e) != ".go" || strings.HasSuffix(e, "_test.go") {
+			continue
+		}
+		content, err := p.client.GetFileContent(ctx, j.Repo, e, ref)
+		if err != nil {
+			p.logger.Error("get package file failed", "file", e, "err", err)
+			continue
+		}
+		out = append(out, codectx.Source{Path: e, Content: content})
+	}
+	return out
+}
@@ -0,0 +1,162 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/prompt"
+	"ai-code-reviewer/internal/review"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// maxNarrativeFindings and maxDiffstatFiles bound the summary prompt;
+	// findings are sent most severe first.
+	maxNarrativeFindings = 40
+	maxDiffstatFiles     = 100
+	narrativePRTokens    = 1000
+	overviewHeading      = "### Overview"
+	risksHeading         = "### Risk areas"
+	testGapsHeading      = "### Test coverage gaps"
+	focusHeading         = "### Look at first"
+	findingsHeading      = "### Findings"
+)
+
+// WithNarrative ends each review with one more AI call that writes a
+// PR-level overview into the summary comment.
+func WithNarrative(enabled bool) Option {
+	return func(p *Processor) {
+		p.narrative = enabled
+	}
+}
+
+// narrate asks the model for a PR-level overview from the findings, the
+// PR description and the diffstat. It is skipped when the review was cut
+// short, and failures only cost the overview.
+func (p *Processor) narrate(
+	ctx context.Context,
+	j Job,
+	limiter *rate.Limiter,
+	meta *github.PRMeta,
+	files []github.PRFile,
+	summary *reviewSummary,
+) {
+
+	if !p.narrative || len(files) == 0 || summary.BudgetStopped || summary.DeadlineStopped {
+		return
+	}
+
+	allowed, err := p.allowBudget(ctx, j, summary)
+	if err != nil {
+		p.logger.Error("budget guard check failed", "err", err)
+		return
+	}
+	if !allowed {
+		return
+	}
+	if !timeLeft(ctx) {
+		p.logger.Info("pr summary skipped, job deadline near")
+		return
+	}
+
+	req := ai.SummaryRequest{
+		Instructions: p.repos.ForRepo(j.Repo).Instructions,
+		Findings:     narrativeFindings(summary.Findings),
+		Diffstat:     diffstat(files),
+	}
+	if meta != nil {
+		pc := prompt.PRContext{Title: meta.Title, Body: meta.Body, BaseBranch: meta.BaseRef}
+		fitted := pc.Fit(narrativePRTokens, p.model.Tokenizer().Count)
+		req.PR = &fitted
+	}
+
+	callCtx, cancel := stepContext(ctx, narrativeTimeout)
+	defer cancel()
+
+	resp, err := p.callAI(callCtx, j, limiter, nil, summary, nil, func(ctx context.Context) (ai.ReviewResponse, error) {
+		return p.ai.Summarize(ctx, req)
+	})
+	if err != nil {
+		p.logger.Error("pr summary failed", "err", err)
+		return
+	}
+
+	n, err := review.ParseNarrative(resp.Content)
+	if err != nil {
+		p.logger.Error("invalid pr summary", "err", err)
+		return
+	}
+	summary.Narrative = &n
+}
+
+// narrativeFindings keeps the most severe findings, in PR order within a
+// severity.
+func narrativeFindings(findings []prompt.Finding) []prompt.Finding {
+	out := slices.Clone(findings)
+	slices.SortStableFunc(out, func(a, b prompt.Finding) int {
+		return severityRank(a.Issue.Severity) - severityRank(b.Issue.Severity)
+	})
+	if len(out) > maxNarrativeFindings {
+		out = out[:maxNarrativeFindings]
+	}
+	return out
+}
+
+func severityRank(sev string) int {
+	if i := slices.Index(review.Severities, sev); i >= 0 {
+		return i
+	}
+	return len(review.Severities)
+}
+
+func diffstat(files []github.PRFile) []prompt.FileStat {
+	out := make([]prompt.FileStat, 0, min(len(files), maxDiffstatFiles))
+	for _, f := range files {
+		if len(out) == maxDiffstatFiles {
+			break
+		}
+		out = append(out, prompt.FileStat{
+			File:      f.Filename,
+			Status:    f.Status,
+			Additions: f.Additions,
+			Deletions: f.Deletions,
+		})
+	}
+	return out
+}
+
+// narrativeSection renders the overview above the counts, which then get
+// their own heading.
+func narrativeSection(s reviewSummary) string {
+	n := s.Narrative
+	if n == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n" + overviewHeading + "\n" + n.Overview)
+	writeList := func(heading string, items []string, numbered bool) {
+		if len(items) == 0 {
+			return
+		}
+		b.WriteString("\n\n" + heading)
+		for i, item := range items {
+			if numbered {
+				fmt.Fprintf(&b, "\n%d. %s", i+1, item)
+			} else {
+				b.WriteString("\n- " + item)
+			}
+		}
+	}
+	writeList(risksHeading, n.Risks, false)
+	writeList(testGapsHeading, n.TestGaps, false)
+	writeList(focusHeading, n.Focus, true)
+
+	b.WriteString("\n\n" + findingsHeading)
+	return b.String()
+}
@@ -0,0 +1,160 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/budget"
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/mocks"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/prompt"
+	"ai-code-reviewer/internal/ratelimit"
+	"ai-code-reviewer/internal/review"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessorHandle_WritesPROverview(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	file := streamedFile
+	file.Status, file.Additions, file.Deletions = "modified", 2, 1
+	client := &clientStub{
+		files: []github.PRFile{file},
+		pr:    github.PRMeta{Title: "Return errors from main", Body: "Stop swallowing errors."},
+	}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		Return(ai.ReviewResponse{Content: twoIssues, Provider: "openai", Model: "gpt-4o"}, nil).
+		Once()
+
+	var got ai.SummaryRequest
+	provider.
+		EXPECT().
+		Summarize(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, r ai.SummaryRequest) (ai.ReviewResponse, error) {
+			got = r
+			return ai.ReviewResponse{
+				Content: `{"overview":"Returns errors from main instead of logging them.",` +
+					`"risks":["callers relying on exit code 0"],"test_gaps":[],` +
+					`"focus":["the error path in main","naming"]}`,
+				Provider: "openai",
+				Model:    "gpt-4o",
+			}, nil
+		}).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.Anything).
+		Return(nil).
+		Twice()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.HasPrefix(body, summaryTitle+"\n\n"+overviewHeading+"\nReturns errors from main instead of logging them.") &&
+				strings.Contains(body, risksHeading+"\n- callers relying on exit code 0") &&
+				!strings.Contains(body, testGapsHeading) &&
+				strings.Contains(body, focusHeading+"\n1. the error path in main\n2. naming") &&
+				strings.Contains(body, findingsHeading+"\n\n- Total issues found: 2")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithNarrative(true),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Equal(t, "Return errors from main", got.PR.Title)
+	require.Equal(t, []prompt.FileStat{{File: "main.go", Status: "modified", Additions: 2, Deletions: 1}}, got.Diffstat)
+	require.Len(t, got.Findings, 2)
+	require.Equal(t, "high", got.Findings[0].Issue.Severity)
+}
+
+func TestProcessorHandle_SkipsOverviewWhenBudgetStopped(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{files: []github.PRFile{streamedFile, {Filename: "b.go", Patch: "@@ -1,1 +1,1 @@\n-old\n+new\n"}}}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		Return(ai.ReviewResponse{
+			Content:  `{"issues":[]}`,
+			Provider: "openai",
+			Model:    "gpt-4o",
+			Usage:    ai.Usage{PromptTokens: 1000, CompletionTokens: 1000, TotalTokens: 2000},
+		}, nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 9, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Budget guard triggered") && !strings.Contains(body, overviewHeading)
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		budget.NewGuard(true, 100.0, 0.01, budget.NewMemoryStore()),
+		WithBatching(1),
+		WithNarrative(true),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 9})
+}
+
+func TestNarrativeFindings_MostSevereFirst(t *testing.T) {
+	findings := []prompt.Finding{
+		{File: "a.go", Issue: review.Issue{Line: 1, Severity: "low"}},
+		{File: "b.go", Issue: review.Issue{Line: 2, Severity: "critical"}},
+		{File: "c.go", Issue: review.Issue{Line: 3, Severity: "low"}},
+	}
+
+	got := narrativeFindings(findings)
+
+	require.Equal(t, []string{"b.go", "a.go", "c.go"}, []string{got[0].File, got[1].File, got[2].File})
+	require.Equal(t, "a.go", findings[0].File)
+}
+
+func TestFormatSummaryComment_NarrativeWithPercent(t *testing.T) {
+	s := reviewSummary{
+		TotalIssues:      1,
+		SeverityCounters: map[string]int{"high": 1},
+		CategoryCounters: map[string]int{},
+		Narrative:        &review.Narrative{Overview: "Raises coverage to 80% and adds %s handling"},
+	}
+
+	got := formatSummaryComment(s)
+
+	require.Contains(t, got, "Raises coverage to 80% and adds %s handling")
+	require.Contains(t, got, "- Total issues found: 1\n")
+	require.Contains(t, got, "- Estimated cost (USD): 0.000000\n")
+	require.NotContains(t, got, "%!")
+}
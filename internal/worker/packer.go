@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"slices"
+
+	"ai-code-reviewer/internal/ai"
+)
+
+// WithBatching packs small chunks from up to maxFiles different files into
+// one AI call, sharing the prompt overhead and rate limiter token. 1
+// disables batching.
+func WithBatching(maxFiles int) Option {
+	return func(p *Processor) {
+		p.batchMaxFiles = maxFiles
+	}
+}
+
+// pack groups chunks into AI calls of at most the chunk budget. Chunks
+// using more than half the budget are sent alone; the others are binned
+// first-fit in PR order. A call never holds two chunks of one file since
+// batched issues are keyed by path.
+func (p *Processor) pack(chunks []ai.FileChunk) [][]ai.FileChunk {
+	if p.batchMaxFiles <= 1 {
+		out := make([][]ai.FileChunk, 0, len(chunks))
+		for _, ch := range chunks {
+			out = append(out, []ai.FileChunk{ch})
+		}
+		return out
+	}
+
+	budget := p.chunker.MaxTokens
+	if p.fileContext != nil {
+		budget += p.fileContext.MaxTokens
+	}
+	count := p.model.Tokenizer().Count
+
+	type bin struct {
+		chunks []ai.FileChunk
+		paths  []string
+		size   int
+	}
+
+	var bins []*bin
+	for _, ch := range chunks {
+		size := count(ch.Content) + count(ch.Surrounding)
+
+		if size <= budget/2 {
+			fit := slices.IndexFunc(bins, func(b *bin) bool {
+				return len(b.chunks) < p.batchMaxFiles &&
+					b.size+size <= budget &&
+					!slices.Contains(b.paths, ch.File)
+			})
+			if fit >= 0 {
+				b := bins[fit]
+				b.chunks = append(b.chunks, ch)
+				b.paths = append(b.paths, ch.File)
+				b.size += size
+				continue
+			}
+		}
+
+		b := &bin{chunks: []ai.FileChunk{ch}, paths: []string{ch.File}, size: size}
+		if size > budget/2 {
+			// large chunks are never topped up
+			b.size = budget
+		}
+		bins = append(bins, b)
+	}
+
+	out := make([][]ai.FileChunk, 0, len(bins))
+	for _, b := range bins {
+		out = append(out, b.chunks)
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/prompt"
+)
+
+const maxLinkedIssues = 3
+
+// WithPRContext adds the PR title, description, base branch and changed
+// files to every prompt, bounded by maxTokens. linkedIssues also fetches
+// the issues the description closes ("Fixes #123").
+func WithPRContext(maxTokens int, linkedIssues bool) Option {
+	return func(p *Processor) {
+		p.prContextTokens = maxTokens
+		p.linkedIssues = linkedIssues
+	}
+}
+
+// loadPRContext builds the PR-level prompt context. Failures only cost
+// context, never the review itself.
+func (p *Processor) loadPRContext(ctx context.Context, j Job, meta *github.PRMeta, files []github.PRFile) *prompt.PRContext {
+	if p.prContextTokens <= 0 || meta == nil {
+		return nil
+	}
+
+	pc := prompt.PRContext{
+		Title:      meta.Title,
+		Body:       meta.Body,
+		BaseBranch: meta.BaseRef,
+	}
+	for _, f := range files {
+		pc.ChangedFiles = append(pc.ChangedFiles, f.Filename)
+	}
+
+	if p.linkedIssues {
+		for i, n := range github.LinkedIssueNumbers(meta.Body) {
+			if i == maxLinkedIssues {
+				break
+			}
+			is, err := p.client.GetIssue(ctx, j.Repo, n)
+			if err != nil {
+				p.logger.Error("get linked issue failed", "repo", j.Repo, "issue", n, "err", err)
+				continue
+			}
+			pc.LinkedIssues = append(pc.LinkedIssues, prompt.LinkedIssue{
+				Number: is.Number,
+				Title:  is.Title,
+				Body:   is.Body,
+			})
+		}
+	}
+
+	fitted := pc.Fit(p.prContextTokens, p.model.Tokenizer().Count)
+	return &fitted
+}
@@ -6,20 +6,29 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"ai-code-reviewer/internal/ai"
 	"ai-code-reviewer/internal/budget"
 	"ai-code-reviewer/internal/chunker"
+	"ai-code-reviewer/internal/codectx"
+	"ai-code-reviewer/internal/config"
 	"ai-code-reviewer/internal/cost"
 	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/deps"
 	"ai-code-reviewer/internal/diff"
 	"ai-code-reviewer/internal/github"
 	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/prompt"
 	"ai-code-reviewer/internal/ratelimit"
 	"ai-code-reviewer/internal/retry"
 	"ai-code-reviewer/internal/review"
+	"ai-code-reviewer/internal/secrets"
+	"ai-code-reviewer/internal/tokenizer"
+
+	"golang.org/x/time/rate"
 )
 
 type Processor struct {
@@ -32,10 +41,62 @@ type Processor struct {
 	ai          ai.Provider
 	rateLimiter *ratelimit.Limiter
 	budgetGuard *budget.Guard
+
+	minConfidence    float64
+	repos            config.RepoConfig
+	prContextTokens  int
+	linkedIssues     bool
+	fileContext      *codectx.Options
+	model            tokenizer.Model
+	maxChunkTokens   int
+	batchMaxFiles    int
+	streaming        bool
+	verification     *verification
+	narrative        bool
+	testSuggestions  string
+	staticAnalysis   bool
+	secrets          *secrets.Detector
+	dependencyReview bool
+	osv              *deps.DB
+	// structured is set when the provider can constrain its output to the
+	// review schema.
+	structured bool
+	timeout    time.Duration
+}
+
+// Option configures optional Processor behaviour.
+type Option func(*Processor)
+
+// WithRepoConfig supplies per-repo settings such as prompt instructions.
+func WithRepoConfig(rc config.RepoConfig) Option {
+	return func(p *Processor) {
+		p.repos = rc
+	}
+}
+
+// WithMinConfidence suppresses AI findings whose confidence is below floor.
+func WithMinConfidence(floor float64) Option {
+	return func(p *Processor) {
+		p.minConfidence = floor
+	}
+}
+
+// WithModel sizes chunks from the model's context window and counts tokens
+// with its tokenizer. maxChunkTokens caps the chunk size; 0 leaves it to
+// the model.
+func WithModel(m tokenizer.Model, maxChunkTokens int) Option {
+	return func(p *Processor) {
+		p.model = m
+		p.maxChunkTokens = maxChunkTokens
+	}
 }
 
 const (
-	chunkerTokenLimit    = 3000
+	// promptOverheadTokens covers the system prompt, rule pack, schema and
+	// template text around the chunk.
+	promptOverheadTokens = 1500
+	maxResponseReserve   = 4096
+	minChunkTokens       = 256
 	processorTimeout     = 90 * time.Second
 	githubCommentSide    = "RIGHT"
 	defaultAIProvider    = "primary"
@@ -45,19 +106,63 @@ const (
 	aiRetryAttempts      = 3
 	aiRetryBackoff       = 500 * time.Millisecond
 	summaryTitle         = "## AI Review Summary"
+	categoryHeading      = "### By category"
+	modelsHeading        = "### Models"
 	noIssuesSummaryText  = "No issues detected in the analyzed diff."
 	budgetStoppedPrefix  = "Budget guard triggered"
+	deadlineStoppedText  = "Review stopped early: time limit reached"
 )
 
-var knownSeverities = []string{"critical", "high", "medium", "low"}
+// errStopJob marks failures that abort the whole job rather than one chunk.
+var errStopJob = errors.New("stop job")
 
 type reviewSummary struct {
 	TotalIssues      int
 	PostedComments   int
 	SeverityCounters map[string]int
+	CategoryCounters map[string]int
+	Suppressed       int
+	MinConfidence    float64
 	CostUSD          float64
 	BudgetStopped    bool
 	BudgetReason     string
+	// Models counts calls per model and routing rule, in first-use order.
+	Models []modelUse
+	// CachedCalls were answered from the response cache at no cost.
+	CachedCalls int
+	// DeadlineStopped is set when a stream was cut short to finish the
+	// job in time.
+	DeadlineStopped bool
+	// Rejected issues were dropped by the verification model.
+	Rejected int
+	// Static issues came from static analysis and Secrets from the secret
+	// scan; both are included in the counters above.
+	Static  int
+	Secrets int
+	// DependencyChanges counts the dependencies manifests add or upgrade;
+	// Dependencies are the flagged ones.
+	DependencyChanges int
+	Dependencies      []deps.Finding
+	// Findings are the issues counted above, for the PR summary call.
+	Findings        []prompt.Finding
+	Narrative       *review.Narrative
+	TestSuggestions []testSuggestion
+}
+
+type modelUse struct {
+	Model string
+	Route string
+	Calls int
+}
+
+func (s *reviewSummary) recordModel(model, route string) {
+	for i := range s.Models {
+		if s.Models[i].Model == model && s.Models[i].Route == route {
+			s.Models[i].Calls++
+			return
+		}
+	}
+	s.Models = append(s.Models, modelUse{Model: model, Route: route, Calls: 1})
 }
 
 func NewProcessor(
@@ -69,19 +174,60 @@ func NewProcessor(
 	a ai.Provider,
 	rl *ratelimit.Limiter,
 	bg *budget.Guard,
+	opts ...Option,
 ) *Processor {
 
-	return &Processor{
+	p := &Processor{
 		queue:       q,
 		client:      c,
 		comments:    comments,
 		dedup:       d,
 		logger:      l,
-		chunker:     chunker.New(chunkerTokenLimit),
 		ai:          a,
 		rateLimiter: rl,
 		budgetGuard: bg,
+		model:       tokenizer.Lookup(""),
+		structured:  ai.CapabilitiesOf(a).StructuredOutput,
+		timeout:     processorTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.fileContext != nil && p.fileContext.Estimate == nil {
+		p.fileContext.Estimate = p.model.Tokenizer().Count
+	}
+	tokens := p.chunkTokens()
+	if tokens < minChunkTokens && p.logger != nil {
+		p.logger.Error("chunk budget below minimum, context settings exceed the model's window",
+			"model", p.model.Name,
+			"context_window", p.model.ContextWindow,
+			"chunk_tokens", tokens,
+			"using", minChunkTokens,
+		)
+	}
+	p.chunker = chunker.New(max(tokens, minChunkTokens), p.model.Tokenizer())
+
+	return p
+}
+
+// chunkTokens is the chunk budget left in the model's context window after
+// the response, the prompt templates and the optional PR and file context.
+// It falls below minChunkTokens, even below zero, when those settings
+// leave the chunk no room.
+func (p *Processor) chunkTokens() int {
+	available := p.model.ContextWindow -
+		min(p.model.MaxOutput, maxResponseReserve) -
+		promptOverheadTokens -
+		max(p.prContextTokens, 0)
+	if p.fileContext != nil {
+		available -= p.fileContext.MaxTokens
+	}
+	if p.maxChunkTokens > 0 {
+		available = min(available, p.maxChunkTokens)
 	}
+	return available
 }
 
 func (p *Processor) Start(ctx context.Context) {
@@ -105,10 +251,14 @@ func (p *Processor) handle(parent context.Context, j Job) {
 
 	ctx, cancel := context.WithTimeout(
 		parent,
-		processorTimeout,
+		p.jobTimeout(),
 	)
 	defer cancel()
 
+	audit := ai.NewRedactionAudit()
+	ctx = ai.WithRedactionAudit(ctx, audit)
+	defer p.logRedactions(j, audit)
+
 	files, err := p.client.GetPRFiles(ctx, j.Repo, j.PR)
 	if err != nil {
 		p.logger.Error("get files failed", "err", err)
@@ -116,11 +266,21 @@ func (p *Processor) handle(parent context.Context, j Job) {
 	}
 
 	limiter := p.rateLimiter.Get(j.Repo)
+	settings := p.repos.ForRepo(j.Repo)
+	meta := p.loadPRMeta(ctx, j)
+	reviewed := slices.DeleteFunc(slices.Clone(files), func(f github.PRFile) bool {
+		return !github.IsReviewable(f) && !github.IsManifest(f)
+	})
+	prContext := p.loadPRContext(ctx, j, meta, reviewed)
 	summary := reviewSummary{
 		SeverityCounters: buildSeverityCounter(),
+		CategoryCounters: make(map[string]int),
+		MinConfidence:    p.minConfidence,
 	}
 
-processing:
+	var chunks []ai.FileChunk
+	var goChanges []diff.FileDiff
+	pkgs := make(packageCache)
 	for _, f := range files {
 
 		parsed, err := diff.Parse(f.Patch)
@@ -131,136 +291,94 @@ processing:
 
 		for _, pf := range parsed {
 
-			content := pf.ToAIContext()
-
-			chunks := p.chunker.Split(pf.Filename, content)
-
-			for _, ch := range chunks {
-				tenant := resolveBudgetTenant(j)
-				allowed, reason, err := p.budgetGuard.Allow(ctx, tenant, j.Repo, j.PR, 0, time.Now())
-				if err != nil {
-					p.logger.Error("budget guard check failed", "err", err)
-					return
-				}
-				if !allowed {
-					summary.BudgetStopped = true
-					summary.BudgetReason = reason
-					observability.AIBudgetBlocks.WithLabelValues("guard").Inc()
-					break processing
-				}
+			// files API patches carry no diff header
+			if pf.Filename == "" {
+				pf.Filename = f.Filename
+			}
 
-				err = limiter.Wait(ctx)
-				if err != nil {
-					p.logger.Error("rate limiter error", "err", err)
-					return
+			// every file is scanned for secrets, only code goes to the AI
+			var leaks []review.Issue
+			pf, leaks = p.scanSecrets(pf)
+			summary.Secrets += len(leaks)
+			if !github.IsReviewable(f) {
+				p.postIssues(ctx, j, pf.Filename, leaks, &summary)
+				if github.IsManifest(f) {
+					p.reviewDependencies(pf, &summary)
 				}
+				continue
+			}
 
-				startTime := time.Now()
+			if goChange(pf) && f.Status != fileStatusRemoved {
+				goChanges = append(goChanges, pf)
+			}
 
-				reviewResp, err := p.reviewWithRetry(ctx, ai.ReviewRequest{
-					File:    ch.File,
-					Content: ch.Content,
+			head := p.loadHeadFile(ctx, j, meta, f, pkgs)
+			static := p.analyze(ctx, j, meta, f, head, pf, pkgs)
+			summary.Static += len(static)
+			static = append(leaks, static...)
+			p.postIssues(ctx, j, pf.Filename, static, &summary)
+
+			for _, ch := range p.chunker.Split(pf) {
+				chunks = append(chunks, ai.FileChunk{
+					File:        ch.File,
+					Content:     ch.Content,
+					Surrounding: p.secrets.Text(pf.Filename, p.surroundingCode(head, ch.Hunks)),
+					HunkStarts:  hunkStarts(ch.Hunks),
+					Hints:       hintsIn(static, ch.Hunks),
 				})
+			}
+		}
+	}
 
-				duration := time.Since(startTime).Seconds()
-
-				//Later we can make this as dynamic
-				provider := reviewResp.Provider
-				if provider == "" {
-					provider = defaultAIProvider
-				}
-				model := reviewResp.Model
-				if model == "" {
-					model = "unknown"
-				}
-
-				observability.AICalls.WithLabelValues(provider).Inc()
-				observability.AILatency.WithLabelValues(provider).Observe(duration)
-
-				if err != nil {
-					observability.AIErrors.WithLabelValues(provider).Inc()
-					p.logger.Error("ai failed", "err", err)
-					continue
-				}
-
-				callCostUSD := cost.EstimateUSD(model, reviewResp.Usage.PromptTokens, reviewResp.Usage.CompletionTokens)
-				summary.CostUSD += callCostUSD
-				observability.AITokens.WithLabelValues(provider, model, "prompt").Add(float64(reviewResp.Usage.PromptTokens))
-				observability.AITokens.WithLabelValues(provider, model, "completion").Add(float64(reviewResp.Usage.CompletionTokens))
-				observability.AICostUSD.WithLabelValues(provider, model).Add(callCostUSD)
-
-				if err := p.budgetGuard.Record(ctx, tenant, j.Repo, j.PR, callCostUSD, time.Now()); err != nil {
-					p.logger.Error("budget guard record failed", "err", err)
-					return
-				}
+	for _, batch := range p.pack(chunks) {
+		allowed, err := p.allowBudget(ctx, j, &summary)
+		if err != nil {
+			p.logger.Error("budget guard check failed", "err", err)
+			return
+		}
+		if !allowed {
+			break
+		}
 
-				result, err := review.ParseResult(reviewResp.Content)
-				if err != nil {
-					p.logger.Error("invalid ai json",
-						"err", err,
-					)
-					continue
-				}
+		req := ai.ReviewRequest{
+			Instructions: settings.Instructions,
+			PR:           prContext,
+		}
+		if len(batch) == 1 {
+			req.File = batch[0].File
+			req.Content = batch[0].Content
+			req.Surrounding = batch[0].Surrounding
+			req.HunkStarts = batch[0].HunkStarts
+			req.Hints = batch[0].Hints
+		} else {
+			req.Files = batch
+		}
 
-				for _, is := range result.Issues {
-					summary.TotalIssues++
-
-					sev := strings.ToLower(strings.TrimSpace(is.Severity))
-					if sev == "" {
-						sev = defaultSeverity
-					}
-					if _, ok := summary.SeverityCounters[sev]; !ok {
-						sev = defaultSeverity
-					}
-					summary.SeverityCounters[sev]++
-
-					// Create unique key
-					key := fmt.Sprintf(
-						"%s:%d:%s",
-						ch.File,
-						is.Line,
-						hash(is.Severity+is.Title+is.Suggestion),
-					)
-
-					// Dedup check
-					if p.dedup.Seen(ctx, key) {
-						continue
-					}
-
-					comment := github.LineComment{
-						Body: commentBody(is),
-						Path: ch.File,
-						Line: is.Line,
-						Side: githubCommentSide,
-					}
-
-					err = retry.Do(ctx, commentRetryAttempts, commentRetryBackoff, func() error {
-						return p.comments.CreateLineComment(
-							ctx, j.Repo, j.PR, comment,
-						)
-					})
-
-					if err != nil {
-						p.logger.Error("comment failed",
-							"err", err,
-						)
-						continue
-					}
-
-					// mark as posted
-					p.dedup.Mark(ctx, key)
-					summary.PostedComments++
-				}
+		results, err := p.reviewChunk(ctx, j, limiter, req, &summary)
+		if err == nil {
+			results, err = p.verifyIssues(ctx, j, limiter, req, withoutHinted(req, results), &summary)
+		}
+		if errors.Is(err, errStopJob) {
+			p.logger.Error("review aborted", "err", err)
+			return
+		}
+		if errors.Is(err, errStreamBudget) || errors.Is(err, errStreamDeadline) {
+			p.logger.Info("review stopped early", "files", req.Paths(), "reason", err)
+			break
+		}
+		if err != nil {
+			p.logger.Error("ai review failed", "files", req.Paths(), "err", err)
+			continue
+		}
 
-				p.logger.Info("AI REVIEW",
-					"file", ch.File,
-					"review", reviewResp.Content,
-					"cost_usd", callCostUSD,
-				)
-			}
+		for _, r := range results {
+			p.postIssues(ctx, j, r.Path, r.Issues, &summary)
 		}
 	}
 
+	p.suggestTests(ctx, j, limiter, meta, goChanges, &summary)
+	p.narrate(ctx, j, limiter, meta, reviewed, &summary)
+
 	body := formatSummaryComment(summary)
 	if body == "" {
 		return
@@ -273,16 +391,295 @@ processing:
 	}
 }
 
-func (p *Processor) reviewWithRetry(ctx context.Context, req ai.ReviewRequest) (ai.ReviewResponse, error) {
+// logRedactions writes the audit of what was redacted from the job's
+// requests to external providers.
+func (p *Processor) logRedactions(j Job, audit *ai.RedactionAudit) {
+	if r := audit.Redactions(); len(r) > 0 {
+		p.logger.Info("redaction audit", "repo", j.Repo, "pr", j.PR, "redactions", r)
+	}
+}
+
+// allowBudget reports whether another AI call fits the budget and records
+// the stop reason in the summary when it does not.
+func (p *Processor) allowBudget(ctx context.Context, j Job, summary *reviewSummary) (bool, error) {
+	allowed, reason, err := p.budgetGuard.Allow(ctx, resolveBudgetTenant(j), j.Repo, j.PR, 0, time.Now())
+	if err != nil {
+		return false, err
+	}
+	if !allowed {
+		summary.BudgetStopped = true
+		summary.BudgetReason = reason
+		observability.AIBudgetBlocks.WithLabelValues("guard").Inc()
+	}
+	return allowed, nil
+}
+
+// reviewChunk asks the model to review a chunk, or a batch of chunks, and
+// returns the issues per file. Unstructured responses that cannot be parsed
+// or validated get one corrective re-prompt. When streaming, issues posted
+// while the response arrived are left out of the result.
+func (p *Processor) reviewChunk(
+	ctx context.Context,
+	j Job,
+	limiter *rate.Limiter,
+	req ai.ReviewRequest,
+	summary *reviewSummary,
+) ([]review.FileResult, error) {
+
+	var stream *issueStream
+	if p.streaming {
+		stream = p.newIssueStream(ctx, j, req, summary)
+	}
+
+	resp, err := p.callAI(ctx, j, limiter, req.Paths(), summary, stream, p.sendReview(req, stream))
+	if err != nil {
+		return nil, err
+	}
+
+	// Schema-constrained output is trusted: a re-prompt would be decoded
+	// under the same constraint, so invalid issues are dropped. A response
+	// only counts when the provider still guarantees the schema, not e.g.
+	// one cached before AI_RESPONSE_FORMAT changed.
+	if p.structured && resp.Structured {
+		result, dropped, err := decodeStructured(req, resp)
+		if len(dropped) > 0 {
+			p.logger.Error("invalid ai issues dropped", "files", req.Paths(), "problems", dropped)
+			observability.AIOutputRepairs.WithLabelValues("dropped").Add(float64(len(dropped)))
+		}
+		return stream.unposted(result), err
+	}
+
+	result, parseErr := parseResponse(req, resp)
+	if parseErr == nil {
+		return stream.unposted(result), nil
+	}
+
+	p.logger.Error("invalid ai json", "files", req.Paths(), "err", parseErr)
+
+	allowed, err := p.allowBudget(ctx, j, summary)
+	if err != nil {
+		return nil, fmt.Errorf("%w: budget check: %v", errStopJob, err)
+	}
+	if !allowed {
+		return nil, parseErr
+	}
+
+	req.Correction = &ai.Correction{
+		Previous: resp.Content,
+		Problem:  parseErr.Error(),
+	}
+
+	resp, err = p.callAI(ctx, j, limiter, req.Paths(), summary, nil, p.sendReview(req, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	result, parseErr = parseResponse(req, resp)
+	if parseErr != nil {
+		observability.AIOutputRepairs.WithLabelValues("failed").Inc()
+		return nil, fmt.Errorf("ai output invalid after re-prompt: %w", parseErr)
+	}
+
+	observability.AIOutputRepairs.WithLabelValues("repaired").Inc()
+	return stream.unposted(result), nil
+}
+
+// parseResponse decodes a single-file or batched response into per-file
+// results.
+func parseResponse(req ai.ReviewRequest, resp ai.ReviewResponse) ([]review.FileResult, error) {
+	if req.IsBatch() {
+		r, err := review.ParseBatchResult(resp.Content, req.Paths())
+		return r.Files, err
+	}
+
+	r, err := review.ParseResult(resp.Content)
+	return []review.FileResult{{Path: req.File, Issues: r.Issues}}, err
+}
+
+// decodeStructured is parseResponse for schema-constrained output; it also
+// returns the problems of the issues it dropped.
+func decodeStructured(req ai.ReviewRequest, resp ai.ReviewResponse) ([]review.FileResult, []string, error) {
+	if req.IsBatch() {
+		r, err := review.DecodeStrictBatch(resp.Content, req.Paths())
+		return r.Files, r.Dropped, err
+	}
+
+	r, err := review.DecodeStrict(resp.Content)
+	return []review.FileResult{{Path: req.File, Issues: r.Issues}}, r.Dropped, err
+}
+
+// sendReview sends req to the provider for review, streamed when stream
+// is set.
+func (p *Processor) sendReview(req ai.ReviewRequest, stream *issueStream) func(context.Context) (ai.ReviewResponse, error) {
+	return func(ctx context.Context) (ai.ReviewResponse, error) {
+		if stream != nil {
+			return ai.Stream(ctx, p.ai, req, stream.onDelta)
+		}
+		return p.ai.Review(ctx, req)
+	}
+}
+
+// callAI performs one rate limited AI call with send, which streams into
+// stream when it is set, and accounts for its cost. files are the files
+// the call covers. Rate limiter and budget store failures are wrapped in
+// errStopJob; streams stopped on purpose are still billed.
+func (p *Processor) callAI(
+	ctx context.Context,
+	j Job,
+	limiter *rate.Limiter,
+	files []string,
+	summary *reviewSummary,
+	stream *issueStream,
+	send func(context.Context) (ai.ReviewResponse, error),
+) (ai.ReviewResponse, error) {
+
+	if err := limiter.Wait(ctx); err != nil {
+		return ai.ReviewResponse{}, fmt.Errorf("%w: rate limiter: %v", errStopJob, err)
+	}
+
+	startTime := time.Now()
+
+	reviewResp, err := p.sendWithRetry(ctx, send, stream)
+
+	duration := time.Since(startTime).Seconds()
+
+	//Later we can make this as dynamic
+	provider := reviewResp.Provider
+	if provider == "" {
+		provider = defaultAIProvider
+	}
+	model := reviewResp.Model
+	if model == "" {
+		model = "unknown"
+	}
+
+	// cache hits cost nothing and say nothing about provider health
+	if err == nil && reviewResp.Cached {
+		summary.CachedCalls++
+		summary.recordModel(model, reviewResp.Route)
+		p.logger.Info("AI REVIEW (cached)", "files", files, "model", model)
+		return reviewResp, nil
+	}
+
+	observability.AICalls.WithLabelValues(provider).Inc()
+	observability.AILatency.WithLabelValues(provider).Observe(duration)
+
+	aborted := errors.Is(err, ai.ErrStreamAborted)
+	if err != nil && !aborted {
+		observability.AIErrors.WithLabelValues(provider).Inc()
+		return ai.ReviewResponse{}, err
+	}
+	if aborted && reviewResp.Usage.TotalTokens == 0 {
+		reviewResp.Usage = stream.usage()
+	}
+
+	callCostUSD := cost.EstimateUSD(model, reviewResp.Usage.PromptTokens, reviewResp.Usage.CompletionTokens)
+	summary.CostUSD += callCostUSD
+	summary.recordModel(model, reviewResp.Route)
+	observability.AITokens.WithLabelValues(provider, model, "prompt").Add(float64(reviewResp.Usage.PromptTokens))
+	observability.AITokens.WithLabelValues(provider, model, "completion").Add(float64(reviewResp.Usage.CompletionTokens))
+	observability.AICostUSD.WithLabelValues(provider, model).Add(callCostUSD)
+
+	if err := p.budgetGuard.Record(ctx, resolveBudgetTenant(j), j.Repo, j.PR, callCostUSD, time.Now()); err != nil {
+		return ai.ReviewResponse{}, fmt.Errorf("%w: budget record: %v", errStopJob, err)
+	}
+
+	p.logger.Info("AI REVIEW",
+		"files", files,
+		"review", reviewResp.Content,
+		"cost_usd", callCostUSD,
+	)
+
+	if aborted {
+		return ai.ReviewResponse{}, err
+	}
+	return reviewResp, nil
+}
+
+// postIssues counts the issues in the summary and posts a line comment for
+// each one that clears the confidence floor and was not posted before.
+func (p *Processor) postIssues(ctx context.Context, j Job, file string, issues []review.Issue, summary *reviewSummary) {
+	for _, is := range issues {
+		if is.ConfidenceScore() < p.minConfidence {
+			summary.Suppressed++
+			continue
+		}
+
+		summary.TotalIssues++
+		summary.CategoryCounters[is.NormalizedCategory()]++
+		summary.Findings = append(summary.Findings, prompt.Finding{File: file, Issue: is})
+
+		sev := strings.ToLower(strings.TrimSpace(is.Severity))
+		if sev == "" {
+			sev = defaultSeverity
+		}
+		if _, ok := summary.SeverityCounters[sev]; !ok {
+			sev = defaultSeverity
+		}
+		summary.SeverityCounters[sev]++
+
+		key := issueKey(file, is)
+
+		// Dedup check
+		if p.dedup.Seen(ctx, key) {
+			continue
+		}
+
+		comment := github.LineComment{
+			Body: commentBody(is),
+			Path: file,
+			Line: is.Line,
+			Side: githubCommentSide,
+		}
+
+		err := retry.Do(ctx, commentRetryAttempts, commentRetryBackoff, func() error {
+			return p.comments.CreateLineComment(
+				ctx, j.Repo, j.PR, comment,
+			)
+		})
+
+		if err != nil {
+			p.logger.Error("comment failed",
+				"err", err,
+			)
+			continue
+		}
+
+		// mark as posted
+		p.dedup.Mark(ctx, key)
+		summary.PostedComments++
+	}
+}
+
+// sendWithRetry retries transient failures, except for streams that
+// already delivered text: their issues may have been posted.
+func (p *Processor) sendWithRetry(
+	ctx context.Context,
+	send func(context.Context) (ai.ReviewResponse, error),
+	stream *issueStream,
+) (ai.ReviewResponse, error) {
 	var resp ai.ReviewResponse
 	err := retry.Do(ctx, aiRetryAttempts, aiRetryBackoff, func() error {
 		var err error
-		resp, err = p.ai.Review(ctx, req)
+		resp, err = send(ctx)
+		if err != nil && (ai.Classify(err) != ai.ClassTransient || stream != nil && stream.started) {
+			return retry.Permanent(err)
+		}
 		return err
 	})
 	return resp, err
 }
 
+// issueKey identifies a posted comment for deduplication.
+func issueKey(file string, is review.Issue) string {
+	return fmt.Sprintf(
+		"%s:%d:%s",
+		file,
+		is.Line,
+		hash(is.Severity+is.Title+is.Suggestion),
+	)
+}
+
 func hash(s string) string {
 	h := sha1.Sum([]byte(s))
 	return hex.EncodeToString(h[:])
@@ -291,23 +688,27 @@ func hash(s string) string {
 func formatSummaryComment(s reviewSummary) string {
 	if s.TotalIssues == 0 {
 		return fmt.Sprintf(
-			"%s\n\n%s\n- Estimated cost (USD): %.6f%s",
-			summaryTitle,
+			"%s\n\n%s\n- Estimated cost (USD): %.6f%s%s%s%s",
+			summaryTitle+narrativeSection(s),
 			noIssuesSummaryText,
 			s.CostUSD,
-			budgetNote(s),
+			cachedNote(s),
+			secretsNote(s)+staticNote(s)+suppressedNote(s)+rejectedNote(s),
+			budgetNote(s)+deadlineNote(s),
+			depsSection(s)+modelsSection(s)+testsSection(s),
 		)
 	}
 
 	return fmt.Sprintf(
-		summaryTitle+"\n\n"+
+		"%s\n\n"+
 			"- Total issues found: %d\n"+
 			"- Line comments posted: %d\n"+
 			"- Estimated cost (USD): %.6f\n"+
 			"- Critical: %d\n"+
 			"- High: %d\n"+
 			"- Medium: %d\n"+
-			"- Low: %d%s",
+			"- Low: %d%s%s%s%s%s",
+		summaryTitle+narrativeSection(s),
 		s.TotalIssues,
 		s.PostedComments,
 		s.CostUSD,
@@ -315,26 +716,116 @@ func formatSummaryComment(s reviewSummary) string {
 		s.SeverityCounters["high"],
 		s.SeverityCounters["medium"],
 		s.SeverityCounters["low"],
-		budgetNote(s),
+		cachedNote(s),
+		secretsNote(s)+staticNote(s)+suppressedNote(s)+rejectedNote(s),
+		budgetNote(s)+deadlineNote(s),
+		categorySection(s),
+		depsSection(s)+modelsSection(s)+testsSection(s),
 	)
 }
 
+// modelsSection lists the models that reviewed the PR, shown only when
+// model routing picked one.
+func modelsSection(s reviewSummary) string {
+	routed := slices.ContainsFunc(s.Models, func(m modelUse) bool { return m.Route != "" })
+	if !routed {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, m := range s.Models {
+		route := "default"
+		if m.Route != "" {
+			route = "route `" + m.Route + "`"
+		}
+		fmt.Fprintf(&b, "\n- `%s` (%s): %d call(s)", m.Model, route, m.Calls)
+	}
+	return "\n\n" + modelsHeading + b.String()
+}
+
+func categorySection(s reviewSummary) string {
+	order := make([]string, 0, len(review.Categories)+1)
+	order = append(order, review.Categories...)
+	order = append(order, review.CategoryOther)
+
+	var b strings.Builder
+	for _, c := range order {
+		if n := s.CategoryCounters[c]; n > 0 {
+			fmt.Fprintf(&b, "\n- %s: %d", categoryTitle(c), n)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\n\n" + categoryHeading + b.String()
+}
+
+func categoryTitle(c string) string {
+	return strings.ToUpper(c[:1]) + c[1:]
+}
+
+func cachedNote(s reviewSummary) string {
+	if s.CachedCalls == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n- Cached responses: %d", s.CachedCalls)
+}
+
+func suppressedNote(s reviewSummary) string {
+	if s.Suppressed == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n- Suppressed (confidence < %.2f): %d", s.MinConfidence, s.Suppressed)
+}
+
+func rejectedNote(s reviewSummary) string {
+	if s.Rejected == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n- Rejected by verification: %d", s.Rejected)
+}
+
 func buildSeverityCounter() map[string]int {
-	out := make(map[string]int, len(knownSeverities))
-	for _, sev := range knownSeverities {
+	out := make(map[string]int, len(review.Severities))
+	for _, sev := range review.Severities {
 		out[sev] = 0
 	}
 	return out
 }
 
 func commentBody(issue review.Issue) string {
+	body := "Potential issue detected by AI reviewer."
 	if strings.TrimSpace(issue.Suggestion) != "" {
-		return issue.Suggestion
+		body = issue.Suggestion
+	} else if strings.TrimSpace(issue.Title) != "" {
+		body = issue.Title
+	}
+
+	var tags []string
+	if issue.Source == review.SourceStatic {
+		tags = append(tags, "`"+review.SourceStatic+"`")
+	}
+	if c := issue.NormalizedCategory(); c != review.CategoryOther {
+		tags = append(tags, "`"+c+"`")
+	}
+	if cwe := strings.TrimSpace(issue.CWE); cwe != "" {
+		tags = append(tags, "`"+cwe+"`")
 	}
-	if strings.TrimSpace(issue.Title) != "" {
-		return issue.Title
+	if len(tags) > 0 {
+		body = strings.Join(tags, " ") + " " + body
+	}
+
+	if r := strings.TrimSpace(issue.Rationale); r != "" {
+		body += "\n\n_Why:_ " + r
+	}
+	return body
+}
+
+func deadlineNote(s reviewSummary) string {
+	if !s.DeadlineStopped {
+		return ""
 	}
-	return "Potential issue detected by AI reviewer."
+	return "\n- " + deadlineStoppedText
 }
 
 func budgetNote(s reviewSummary) string {
@@ -8,19 +8,27 @@ import (
 
 	"ai-code-reviewer/internal/ai"
 	"ai-code-reviewer/internal/budget"
+	"ai-code-reviewer/internal/codectx"
 	"ai-code-reviewer/internal/config"
 	"ai-code-reviewer/internal/dedup"
 	"ai-code-reviewer/internal/github"
 	"ai-code-reviewer/internal/mocks"
 	"ai-code-reviewer/internal/observability"
 	"ai-code-reviewer/internal/ratelimit"
+	"ai-code-reviewer/internal/tokenizer"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
 type clientStub struct {
-	files []github.PRFile
+	files    []github.PRFile
+	pr       github.PRMeta
+	issues   map[int]github.Issue
+	contents map[string]string
+	dirs     map[string][]string
+	// fetches counts GetFileContent and ListDir calls by path.
+	fetches map[string]int
 }
 
 func (c *clientStub) GetPRFiles(ctx context.Context, repo string, pr int) ([]github.PRFile, error) {
@@ -31,6 +39,39 @@ func (c *clientStub) GetPRDiff(ctx context.Context, repo string, pr int) (string
 	return "", nil
 }
 
+func (c *clientStub) GetPR(ctx context.Context, repo string, pr int) (github.PRMeta, error) {
+	return c.pr, nil
+}
+
+func (c *clientStub) GetIssue(ctx context.Context, repo string, number int) (github.Issue, error) {
+	is, ok := c.issues[number]
+	if !ok {
+		return github.Issue{}, errors.New("issue not found")
+	}
+	return is, nil
+}
+
+func (c *clientStub) GetFileContent(ctx context.Context, repo, path, ref string) (string, error) {
+	c.fetched(path)
+	content, ok := c.contents[path]
+	if !ok {
+		return "", github.ErrNotFound
+	}
+	return content, nil
+}
+
+func (c *clientStub) ListDir(ctx context.Context, repo, dir, ref string) ([]string, error) {
+	c.fetched(dir)
+	return c.dirs[dir], nil
+}
+
+func (c *clientStub) fetched(path string) {
+	if c.fetches == nil {
+		c.fetches = make(map[string]int)
+	}
+	c.fetches[path]++
+}
+
 func (c *clientStub) CreateComment(ctx context.Context, repo string, pr int, body string) error {
 	return nil
 }
@@ -47,6 +88,18 @@ func TestFormatSummaryComment_NoIssues(t *testing.T) {
 	})
 
 	require.Contains(t, body, "No issues detected")
+	require.NotContains(t, body, modelsHeading)
+}
+
+func TestFormatSummaryComment_ListsRoutedModels(t *testing.T) {
+	s := reviewSummary{SeverityCounters: buildSeverityCounter()}
+	s.recordModel("gpt-4o-mini", "")
+	s.recordModel("gpt-4.1", "security")
+	s.recordModel("gpt-4o-mini", "")
+
+	body := formatSummaryComment(s)
+
+	require.Contains(t, body, modelsHeading+"\n- `gpt-4o-mini` (default): 2 call(s)\n- `gpt-4.1` (route `security`): 1 call(s)")
 }
 
 func TestProcessorHandle_PostsSummaryComment(t *testing.T) {
@@ -246,3 +299,447 @@ func TestProcessorHandle_RetriesAIReviewThenSucceeds(t *testing.T) {
 
 	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 10})
 }
+
+func TestProcessorHandle_SuppressesLowConfidenceAndGroupsByCategory(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{
+		files: []github.PRFile{
+			{
+				Filename: "db.go",
+				Patch: "diff --git a/db.go b/db.go\n" +
+					"--- a/db.go\n" +
+					"+++ b/db.go\n" +
+					"@@ -1,1 +1,2 @@\n" +
+					"-old\n" +
+					"+new\n",
+			},
+		},
+	}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		Return(
+			ai.ReviewResponse{
+				Content: `{"version":2,"issues":[` +
+					`{"line":1,"severity":"critical","category":"security","confidence":0.9,"cwe":"CWE-89","title":"sql injection","suggestion":"use placeholders","rationale":"query built from input"},` +
+					`{"line":2,"severity":"low","category":"style","confidence":0.2,"title":"naming","suggestion":"rename"}]}`,
+				Provider: "openai",
+				Model:    "gpt-4o-mini",
+			},
+			nil,
+		).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 11, mock.MatchedBy(func(c github.LineComment) bool {
+			return strings.Contains(c.Body, "`security` `CWE-89`") &&
+				strings.Contains(c.Body, "_Why:_ query built from input")
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 11, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Total issues found: 1") &&
+				strings.Contains(body, "Suppressed (confidence < 0.50): 1") &&
+				strings.Contains(body, "### By category\n- Security: 1") &&
+				!strings.Contains(body, "Style:")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithMinConfidence(0.5),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 11})
+}
+
+func TestProcessorHandle_RepromptsOnceOnInvalidOutput(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{
+		files: []github.PRFile{
+			{
+				Filename: "main.go",
+				Patch: "diff --git a/main.go b/main.go\n" +
+					"--- a/main.go\n" +
+					"+++ b/main.go\n" +
+					"@@ -1,1 +1,2 @@\n" +
+					"-old\n" +
+					"+new\n",
+			},
+		},
+	}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.MatchedBy(func(r ai.ReviewRequest) bool { return r.Correction == nil })).
+		Return(ai.ReviewResponse{Content: `{"issues":[{"line":0,"severity":"bad","title":"t"}]}`}, nil).
+		Once()
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.MatchedBy(func(r ai.ReviewRequest) bool {
+			return r.Correction != nil && strings.Contains(r.Correction.Problem, "issues[0].line")
+		})).
+		Return(ai.ReviewResponse{Content: "```json\n{\"issues\":[{\"line\":2,\"severity\":\"high\",\"title\":\"t\",\"suggestion\":\"fix\"}]}\n```"}, nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 12, mock.Anything).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 12, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Total issues found: 1")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 12})
+}
+
+// structuredProvider reports schema-constrained output for the provider it
+// wraps.
+type structuredProvider struct{ ai.Provider }
+
+func (structuredProvider) Capabilities() ai.Capabilities {
+	return ai.Capabilities{StructuredOutput: true}
+}
+
+func TestProcessorHandle_DropsInvalidStructuredIssues(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{
+		files: []github.PRFile{{Filename: "main.go", Patch: "@@ -1,1 +1,2 @@\n-old\n+new\n+more\n"}},
+	}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		Return(ai.ReviewResponse{
+			Content: `{"version":2,"issues":[` +
+				`{"line":0,"severity":"high","title":"no line"},` +
+				`{"line":2,"severity":"high","title":"kept","suggestion":"fix"}]}`,
+			Structured: true,
+		}, nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 12, mock.MatchedBy(func(c github.LineComment) bool {
+			return c.Line == 2
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 12, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Total issues found: 1")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		structuredProvider{provider},
+		ratelimit.New(100, 100),
+		nil,
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 12})
+}
+
+func TestProcessorHandle_SendsPRContext(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{
+		files: []github.PRFile{
+			{
+				Filename: "main.go",
+				Patch: "diff --git a/main.go b/main.go\n" +
+					"--- a/main.go\n" +
+					"+++ b/main.go\n" +
+					"@@ -1,1 +1,2 @@\n" +
+					"-old\n" +
+					"+new\n",
+			},
+			{Filename: "util.go"},
+		},
+		pr: github.PRMeta{
+			Title:   "Add retries",
+			Body:    "Retries uploads.\n\nFixes #5",
+			BaseRef: "main",
+		},
+		issues: map[int]github.Issue{
+			5: {Number: 5, Title: "Uploads flake", Body: "Timeouts on large files."},
+		},
+	}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.MatchedBy(func(r ai.ReviewRequest) bool {
+			return r.PR != nil &&
+				r.PR.Title == "Add retries" &&
+				r.PR.BaseBranch == "main" &&
+				len(r.PR.ChangedFiles) == 2 &&
+				len(r.PR.LinkedIssues) == 1 &&
+				r.PR.LinkedIssues[0].Title == "Uploads flake"
+		})).
+		Return(ai.ReviewResponse{Content: `{"issues":[]}`}, nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 13, mock.Anything).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithPRContext(500, true),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 13})
+}
+
+func TestProcessorHandle_SendsSurroundingCode(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{
+		files: []github.PRFile{
+			{
+				Filename: "pkg/cache.go",
+				Status:   "modified",
+				Patch: "@@ -4,3 +4,3 @@ func (c *Cache) Get(key string) Item {\n" +
+					" \tc.mu.Lock()\n" +
+					"-\treturn c.items[key]\n" +
+					"+\treturn lookup(c.items, key)\n" +
+					" }\n",
+			},
+		},
+		pr: github.PRMeta{HeadSHA: "abc123"},
+		contents: map[string]string{
+			"pkg/cache.go": "package pkg\n\n" +
+				"func (c *Cache) Get(key string) Item {\n" +
+				"\tc.mu.Lock()\n" +
+				"\treturn lookup(c.items, key)\n" +
+				"}\n",
+			"pkg/types.go": "package pkg\n\n" +
+				"// Item is a cached value.\n" +
+				"type Item struct{ V string }\n\n" +
+				"func lookup(m map[string]Item, k string) Item {\n\treturn m[k]\n}\n",
+		},
+		dirs: map[string][]string{
+			"pkg": {"pkg/cache.go", "pkg/types.go", "pkg/types_test.go"},
+		},
+	}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.MatchedBy(func(r ai.ReviewRequest) bool {
+			return r.File == "pkg/cache.go" &&
+				strings.Contains(r.Surrounding, "    3 | func (c *Cache) Get(key string) Item {") &&
+				strings.Contains(r.Surrounding, "type Item struct{ V string }") &&
+				strings.Contains(r.Surrounding, "func lookup(m map[string]Item, k string) Item") &&
+				!strings.Contains(r.Surrounding, "return m[k]")
+		})).
+		Return(ai.ReviewResponse{Content: `{"issues":[]}`}, nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 21, mock.Anything).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithFileContext(codectx.Options{
+			Mode:        codectx.ModeFunction,
+			WindowLines: 5,
+			MaxTokens:   1000,
+			References:  true,
+		}),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 21})
+}
+
+func TestProcessor_ChunkTokensFollowModel(t *testing.T) {
+	newProcessor := func(opts ...Option) *Processor {
+		return NewProcessor(NewMemoryQueue(1), &clientStub{}, nil, nil, nil, nil, nil, nil, opts...)
+	}
+
+	// 8192 - 2048 reserved output - 1500 prompt - 800 PR context
+	p := newProcessor(WithModel(tokenizer.Lookup("llama3"), 0), WithPRContext(800, false))
+	require.Equal(t, 3844, p.chunker.MaxTokens)
+
+	p = newProcessor(WithModel(tokenizer.Lookup("gpt-4o"), 6000))
+	require.Equal(t, 6000, p.chunker.MaxTokens)
+
+	// 4096 - 2048 - 1500 - 4000 file context leaves no room: the minimum
+	// is used and the misconfiguration logged
+	p = newProcessor(WithModel(tokenizer.Lookup("llama2"), 0), WithFileContext(codectx.Options{MaxTokens: 4000}))
+	require.Equal(t, -3452, p.chunkTokens())
+	require.Equal(t, minChunkTokens, p.chunker.MaxTokens)
+}
+
+func TestProcessorHandle_BatchesSmallFiles(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+
+	patch := "@@ -1,1 +1,1 @@\n-old\n+new\n"
+	client := &clientStub{
+		files: []github.PRFile{
+			{Filename: "a.go", Patch: patch},
+			{Filename: "b.go", Patch: patch},
+			{Filename: "c.go", Patch: patch},
+		},
+	}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.MatchedBy(func(r ai.ReviewRequest) bool {
+			return r.IsBatch() && len(r.Files) == 2 &&
+				r.Files[0].File == "a.go" && r.Files[1].File == "b.go"
+		})).
+		Return(ai.ReviewResponse{Content: `{"files":[` +
+			`{"path":"a.go","issues":[{"line":1,"severity":"high","title":"nil check","suggestion":"add nil check"}]},` +
+			`{"path":"b.go","issues":[{"line":1,"severity":"low","title":"naming","suggestion":"rename"}]}]}`}, nil).
+		Once()
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.MatchedBy(func(r ai.ReviewRequest) bool {
+			return !r.IsBatch() && r.File == "c.go"
+		})).
+		Return(ai.ReviewResponse{Content: `{"issues":[]}`}, nil).
+		Once()
+
+	for _, path := range []string{"a.go", "b.go"} {
+		comments.
+			EXPECT().
+			CreateLineComment(mock.Anything, "acme/repo", 17, mock.MatchedBy(func(c github.LineComment) bool {
+				return c.Path == path && c.Line == 1
+			})).
+			Return(nil).
+			Once()
+	}
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 17, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Total issues found: 2")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithBatching(2),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 17})
+}
+
+func TestProcessorHandle_CachedResponseCostsNothing(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{
+		files: []github.PRFile{
+			{Filename: "main.go", Patch: "@@ -1,1 +1,1 @@\n-old\n+new\n"},
+		},
+	}
+	store := budget.NewMemoryStore()
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		Return(ai.ReviewResponse{
+			Content:  `{"issues":[]}`,
+			Provider: "openai",
+			Model:    "gpt-4o",
+			Cached:   true,
+		}, nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Estimated cost (USD): 0.000000") &&
+				strings.Contains(body, "Cached responses: 1")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		budget.NewGuard(true, 10, 1, store),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	spent, err := store.GetPRSpend(context.Background(), "acme", "acme/repo", 7)
+	require.NoError(t, err)
+	require.Zero(t, spent)
+}
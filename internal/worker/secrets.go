@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"fmt"
+
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/diff"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/review"
+	"ai-code-reviewer/internal/secrets"
+)
+
+const (
+	secretSeverity = "critical"
+	secretCWE      = "CWE-798"
+)
+
+// WithSecretScan looks for credentials in the changed lines. They are
+// reported as critical issues and redacted from everything sent to the AI
+// provider. Files and values on the allowlist, such as test fixtures, are
+// left alone.
+func WithSecretScan(enabled bool, allow config.SecretAllowlist) Option {
+	return func(p *Processor) {
+		if enabled {
+			p.secrets = secrets.New(allow)
+		}
+	}
+}
+
+// scanSecrets redacts fd and returns an issue per secret in its added
+// lines.
+func (p *Processor) scanSecrets(fd diff.FileDiff) (diff.FileDiff, []review.Issue) {
+	redacted, found := p.secrets.Diff(fd)
+
+	issues := make([]review.Issue, 0, len(found))
+	for _, f := range found {
+		observability.SecretFindings.WithLabelValues(f.Rule).Inc()
+		issues = append(issues, review.Issue{
+			Line:     f.Line,
+			Severity: secretSeverity,
+			Title: fmt.Sprintf("Possible %s committed (`%s`). Remove it, rotate it and load it from the environment or a secret store.",
+				f.Name, f.Masked),
+			Category:  review.CategorySecurity,
+			CWE:       secretCWE,
+			Rationale: "A secret in the repository is readable by anyone with access to it and stays in the git history after it is removed.",
+			Source:    review.SourceStatic,
+		})
+	}
+	return redacted, issues
+}
+
+func secretsNote(s reviewSummary) string {
+	if s.Secrets == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n- Possible secrets (redacted before review): %d", s.Secrets)
+}
@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/mocks"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/ratelimit"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var leakedKeyFile = github.PRFile{
+	Filename: "internal/aws/client.go",
+	Patch:    "@@ -1,1 +1,2 @@\n package aws\n+const accessKey = \"AKIAI44QH8DHBEXAMPLE\"\n",
+}
+
+func TestProcessorHandle_ReportsAndRedactsSecrets(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+
+	var got ai.ReviewRequest
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, r ai.ReviewRequest) (ai.ReviewResponse, error) {
+			got = r
+			return ai.ReviewResponse{Content: `{"issues":[]}`, Provider: "openai", Model: "gpt-4o"}, nil
+		}).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(c github.LineComment) bool {
+			return c.Line == 2 &&
+				strings.HasPrefix(c.Body, "`static` `security` `CWE-798` Possible AWS access key committed (`AKIA****`).") &&
+				!strings.Contains(c.Body, "AKIAI44QH8DHBEXAMPLE")
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "- Critical: 1") &&
+				strings.Contains(body, "- Possible secrets (redacted before review): 1")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{files: []github.PRFile{leakedKeyFile}},
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithSecretScan(true, config.SecretAllowlist{}),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.NotContains(t, got.Content, "AKIAI44QH8DHBEXAMPLE")
+	require.Contains(t, got.Content, `+const accessKey = "[REDACTED:aws_access_key]"`)
+	require.Len(t, got.Hints, 1)
+}
+
+func TestProcessorHandle_SkipsAllowlistedSecrets(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+
+	var got ai.ReviewRequest
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, r ai.ReviewRequest) (ai.ReviewResponse, error) {
+			got = r
+			return ai.ReviewResponse{Content: `{"issues":[]}`, Provider: "openai", Model: "gpt-4o"}, nil
+		}).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.Anything).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{files: []github.PRFile{leakedKeyFile}},
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithSecretScan(true, config.SecretAllowlist{Values: []string{"AKIAI44QH8DHBEXAMPLE"}}),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Contains(t, got.Content, "AKIAI44QH8DHBEXAMPLE")
+}
+
+func TestProcessorHandle_ScansFilesNotSentToAI(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(c github.LineComment) bool {
+			return c.Path == "deploy/values.yaml" && c.Line == 2 &&
+				strings.Contains(c.Body, "Possible AWS access key committed (`AKIA****`).")
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "- Possible secrets (redacted before review): 1")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{files: []github.PRFile{{
+			Filename: "deploy/values.yaml",
+			Patch:    "@@ -1,1 +1,2 @@\n aws:\n+  accessKey: AKIAI44QH8DHBEXAMPLE\n",
+		}}},
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithSecretScan(true, config.SecretAllowlist{}),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+}
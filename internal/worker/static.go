@@ -0,0 +1,221 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/codectx"
+	"ai-code-reviewer/internal/diff"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/review"
+	"ai-code-reviewer/internal/vet"
+)
+
+// staticSeverity ranks findings by analyzer: printf and nilness findings
+// are almost always bugs, shadowing is often intended.
+var staticSeverity = map[string]string{
+	"printf":       "high",
+	"nilness":      "high",
+	"unusedresult": "medium",
+	"shadow":       "low",
+}
+
+// WithStaticAnalysis runs vet-style analyzers on the changed Go files
+// before the AI review. Findings on added lines are posted like AI
+// findings, at no cost, and passed to the model as hints so it does not
+// repeat them.
+func WithStaticAnalysis(enabled bool) Option {
+	return func(p *Processor) {
+		p.staticAnalysis = enabled
+	}
+}
+
+// analyze runs the analyzers on the head version of a changed Go file and
+// returns its findings on added lines. Like file context it is best effort.
+func (p *Processor) analyze(
+	ctx context.Context,
+	j Job,
+	meta *github.PRMeta,
+	f github.PRFile,
+	head *headFile,
+	fd diff.FileDiff,
+	pkgs packageCache,
+) []review.Issue {
+
+	if !p.staticAnalysis || meta == nil || meta.HeadSHA == "" || f.Status == fileStatusRemoved || path.Ext(fd.Filename) != ".go" {
+		return nil
+	}
+	if !timeLeft(ctx) {
+		p.logger.Info("static analysis skipped, job deadline near", "file", fd.Filename)
+		return nil
+	}
+	ctx, cancel := stepContext(ctx, staticFileTimeout)
+	defer cancel()
+
+	var src codectx.Source
+	var pkg []codectx.Source
+	if head != nil {
+		src, pkg = head.src, head.pkg
+	} else {
+		content, err := p.client.GetFileContent(ctx, j.Repo, fd.Filename, meta.HeadSHA)
+		if err != nil {
+			if !errors.Is(err, github.ErrNotFound) {
+				p.logger.Error("get file content failed", "file", fd.Filename, "err", err)
+			}
+			return nil
+		}
+		src = codectx.Source{Path: fd.Filename, Content: content}
+	}
+	// without its package most of a file does not type-check
+	if pkg == nil {
+		pkg = p.packageSources(ctx, j, meta.HeadSHA, fd.Filename, pkgs)
+	}
+
+	diags, err := vet.Run(src, pkg)
+	if err != nil {
+		p.logger.Error("static analysis failed", "file", fd.Filename, "err", err)
+		return nil
+	}
+
+	added := addedLines(fd.Hunks)
+	var issues []review.Issue
+	for _, d := range diags {
+		if !added[d.Line] {
+			continue
+		}
+		observability.StaticFindings.WithLabelValues(d.Analyzer).Inc()
+		issues = append(issues, review.Issue{
+			Line:      d.Line,
+			Severity:  staticSeverity[d.Analyzer],
+			Title:     d.Message,
+			Category:  review.CategoryBug,
+			Rationale: fmt.Sprintf("Reported by the `%s` analyzer.", d.Analyzer),
+			Source:    review.SourceStatic,
+		})
+	}
+	return issues
+}
+
+func addedLines(hunks []diff.Hunk) map[int]bool {
+	out := make(map[int]bool)
+	for _, h := range hunks {
+		for _, l := range h.Lines {
+			if l.Type == diff.Added {
+				out[l.NewNumber] = true
+			}
+		}
+	}
+	return out
+}
+
+// hintsIn returns the static findings on the added lines of hunks.
+func hintsIn(static []review.Issue, hunks []diff.Hunk) []review.Issue {
+	if len(static) == 0 {
+		return nil
+	}
+
+	added := addedLines(hunks)
+	var out []review.Issue
+	for _, is := range static {
+		if added[is.Line] {
+			out = append(out, is)
+		}
+	}
+	return out
+}
+
+// hinted reports whether an AI finding repeats a static finding the
+// request passed as a hint: same file, same line and the same problem.
+func hinted(req ai.ReviewRequest, path string, is review.Issue) bool {
+	hints := req.Hints
+	if req.IsBatch() {
+		hints = nil
+		for _, f := range req.Files {
+			if f.File == path {
+				hints = f.Hints
+			}
+		}
+	} else if path != req.File {
+		return false
+	}
+
+	return slices.ContainsFunc(hints, func(h review.Issue) bool { return repeats(h, is) })
+}
+
+// repeats reports whether is describes the static finding h. Lines like
+// "err := f()" draw shadow findings, so an AI finding on the same line
+// about something else, e.g. an injection, is kept: it must share the
+// CWE, or the category and most of the title's words.
+func repeats(h, is review.Issue) bool {
+	if h.Line != is.Line {
+		return false
+	}
+	if h.CWE != "" && h.CWE == is.CWE {
+		return true
+	}
+	if is.Category != "" && is.Category != h.Category {
+		return false
+	}
+	return similarTitles(h.Title, is.Title)
+}
+
+// similarTitles reports whether at least half of the words of the shorter
+// title appear in the other.
+func similarTitles(a, b string) bool {
+	wa, wb := titleWords(a), titleWords(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return false
+	}
+
+	shared := 0
+	for w := range wa {
+		if wb[w] {
+			shared++
+		}
+	}
+	return 2*shared >= min(len(wa), len(wb))
+}
+
+// titleWords returns the lower case words of at least three letters.
+func titleWords(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	out := make(map[string]bool, len(words))
+	for _, w := range words {
+		if len(w) >= 3 {
+			out[w] = true
+		}
+	}
+	return out
+}
+
+// withoutHinted drops AI findings that repeat static findings.
+func withoutHinted(req ai.ReviewRequest, results []review.FileResult) []review.FileResult {
+	out := make([]review.FileResult, 0, len(results))
+	for _, r := range results {
+		var issues []review.Issue
+		for _, is := range r.Issues {
+			if !hinted(req, r.Path, is) {
+				issues = append(issues, is)
+			}
+		}
+		out = append(out, review.FileResult{Path: r.Path, Issues: issues})
+	}
+	return out
+}
+
+func staticNote(s reviewSummary) string {
+	if s.Static == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n- Static analysis findings: %d", s.Static)
+}
@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/codectx"
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/mocks"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/ratelimit"
+	"ai-code-reviewer/internal/review"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const printfSource = "package main\n" +
+	"\n" +
+	"import \"fmt\"\n" +
+	"\n" +
+	"func main() {\n" +
+	"\tfmt.Printf(\"%d\\n\", \"x\")\n" +
+	"\tfmt.Println(\"ok\")\n" +
+	"}\n"
+
+func TestProcessorHandle_PostsStaticFindingsAndDropsRepeats(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{
+		files: []github.PRFile{{
+			Filename: "main.go",
+			Status:   "modified",
+			Patch:    "@@ -5,2 +5,3 @@\n func main() {\n+\tfmt.Printf(\"%d\\n\", \"x\")\n \tfmt.Println(\"ok\")\n",
+		}},
+		pr:       github.PRMeta{HeadSHA: "abc123"},
+		contents: map[string]string{"main.go": printfSource},
+	}
+
+	var got ai.ReviewRequest
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, r ai.ReviewRequest) (ai.ReviewResponse, error) {
+			got = r
+			return ai.ReviewResponse{
+				Content: `{"issues":[` +
+					`{"line":6,"severity":"high","category":"bug","title":"Printf format %d gets a string argument","suggestion":"use %s"},` +
+					`{"line":7,"severity":"low","title":"noise","suggestion":"drop it"}]}`,
+				Provider: "openai",
+				Model:    "gpt-4o",
+			}, nil
+		}).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(c github.LineComment) bool {
+			return c.Line == 6 && strings.HasPrefix(c.Body, "`static` `bug` fmt.Printf format %d has arg")
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(c github.LineComment) bool {
+			return c.Line == 7 && c.Body == "drop it"
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "- Total issues found: 2") &&
+				strings.Contains(body, "- Static analysis findings: 1")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithStaticAnalysis(true),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Len(t, got.Hints, 1)
+	require.Equal(t, 6, got.Hints[0].Line)
+	require.Equal(t, review.SourceStatic, got.Hints[0].Source)
+}
+
+func TestHinted_MatchesBatchFileAndLine(t *testing.T) {
+	shadow := review.Issue{Line: 3, Category: review.CategoryBug, Title: `declaration of "err" shadows declaration at line 1`}
+	req := ai.ReviewRequest{Files: []ai.FileChunk{
+		{File: "a.go", Hints: []review.Issue{shadow}},
+		{File: "b.go"},
+	}}
+
+	repeat := review.Issue{Line: 3, Category: review.CategoryBug, Title: "err shadows the outer err declaration"}
+	require.True(t, hinted(req, "a.go", repeat))
+	require.False(t, hinted(req, "a.go", review.Issue{Line: 4, Category: repeat.Category, Title: repeat.Title}))
+	require.False(t, hinted(req, "b.go", repeat))
+}
+
+func TestHinted_KeepsOtherProblemsOnTheLine(t *testing.T) {
+	shadow := review.Issue{Line: 3, Category: review.CategoryBug, Title: `declaration of "err" shadows declaration at line 1`}
+	secret := review.Issue{Line: 5, Category: review.CategorySecurity, CWE: "CWE-798", Title: "Possible AWS access key committed (`AKIA****`)."}
+	req := ai.ReviewRequest{File: "a.go", Hints: []review.Issue{shadow, secret}}
+
+	require.False(t, hinted(req, "a.go", review.Issue{Line: 3, Category: review.CategorySecurity, CWE: "CWE-89", Title: "SQL injection: query built from user input"}))
+	require.False(t, hinted(req, "a.go", review.Issue{Line: 3, Category: review.CategoryBug, Title: "error from db.Query is ignored"}))
+	require.True(t, hinted(req, "a.go", review.Issue{Line: 5, Category: review.CategorySecurity, CWE: "CWE-798", Title: "Hardcoded credential"}))
+	require.False(t, hinted(req, "a.go", review.Issue{Line: 5, Category: review.CategorySecurity, CWE: "CWE-319", Title: "Key sent over plain HTTP"}))
+}
+
+func TestPackageSources_FetchesPackageOncePerJob(t *testing.T) {
+	client := &clientStub{
+		dirs: map[string][]string{"pkg": {"pkg/a.go", "pkg/b.go", "pkg/c.go", "pkg/c_test.go"}},
+		contents: map[string]string{
+			"pkg/a.go": "package pkg\n",
+			"pkg/b.go": "package pkg\n",
+			"pkg/c.go": "package pkg\n",
+		},
+	}
+	p := &Processor{client: client, logger: observability.NewLogger(&config.Config{LogLevel: "info"})}
+	pkgs := make(packageCache)
+
+	a := p.packageSources(context.Background(), Job{Repo: "acme/repo"}, "abc123", "pkg/a.go", pkgs)
+	b := p.packageSources(context.Background(), Job{Repo: "acme/repo"}, "abc123", "pkg/b.go", pkgs)
+
+	paths := func(srcs []codectx.Source) []string {
+		var out []string
+		for _, s := range srcs {
+			out = append(out, s.Path)
+		}
+		return out
+	}
+	require.Equal(t, []string{"pkg/b.go", "pkg/c.go"}, paths(a))
+	require.Equal(t, []string{"pkg/a.go", "pkg/c.go"}, paths(b))
+	require.Equal(t, map[string]int{"pkg": 1, "pkg/a.go": 1, "pkg/b.go": 1, "pkg/c.go": 1}, client.fetches)
+}
@@ -0,0 +1,186 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/cost"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/review"
+	"ai-code-reviewer/internal/tokenizer"
+)
+
+const (
+	// streamBudgetCheckTokens is how many streamed tokens pass between
+	// budget checks.
+	streamBudgetCheckTokens = 256
+)
+
+var (
+	errStreamBudget   = errors.New("budget exceeded while streaming")
+	errStreamDeadline = errors.New("review deadline reached while streaming")
+)
+
+// WithStreaming streams AI responses so line comments are posted while the
+// model is still writing and long responses can be cut short.
+func WithStreaming(enabled bool) Option {
+	return func(p *Processor) {
+		p.streaming = enabled
+	}
+}
+
+// issueStream follows one streamed AI call: it posts issues as soon as they
+// are complete and stops the stream when the PR budget or the job deadline
+// is reached.
+type issueStream struct {
+	p       *Processor
+	ctx     context.Context
+	j       Job
+	req     ai.ReviewRequest
+	summary *reviewSummary
+	scanner *review.IssueScanner
+	// model is the model req is sent to, which counts and prices the
+	// stream.
+	model tokenizer.Model
+
+	// posted holds the keys of issues handled while streaming, so the
+	// final parse does not count or post them again.
+	posted       map[string]bool
+	text         strings.Builder
+	started      bool
+	promptTokens int
+	// tokens is a running count of the streamed tokens, by delta; counting
+	// the whole text on every delta is quadratic.
+	tokens    int
+	checkedAt int
+}
+
+func (p *Processor) newIssueStream(ctx context.Context, j Job, req ai.ReviewRequest, summary *reviewSummary) *issueStream {
+	model := p.requestModel(req)
+	s := &issueStream{
+		p:            p,
+		ctx:          ctx,
+		j:            j,
+		req:          req,
+		summary:      summary,
+		model:        model,
+		posted:       make(map[string]bool),
+		promptTokens: promptTokens(req, model),
+	}
+	s.scanner = review.NewIssueScanner(s.onIssue)
+	return s
+}
+
+func (s *issueStream) onDelta(delta string) error {
+	s.started = true
+	s.text.WriteString(delta)
+	s.scanner.Feed(delta)
+
+	if deadline, ok := s.ctx.Deadline(); ok && time.Until(deadline) < summaryReserve {
+		s.summary.DeadlineStopped = true
+		return errStreamDeadline
+	}
+
+	if !s.p.budgetGuard.Enabled() {
+		return nil
+	}
+	s.tokens += s.model.Tokenizer().Count(delta)
+	if s.tokens-s.checkedAt < streamBudgetCheckTokens {
+		return nil
+	}
+	s.checkedAt = s.tokens
+
+	projected := cost.EstimateUSD(s.model.Name, s.promptTokens, s.tokens)
+	allowed, reason, err := s.p.budgetGuard.Allow(s.ctx, resolveBudgetTenant(s.j), s.j.Repo, s.j.PR, projected, time.Now())
+	if err != nil || allowed {
+		// a flaky budget store should not cut a review short; the next
+		// check between calls stops the job
+		return nil
+	}
+
+	s.summary.BudgetStopped = true
+	s.summary.BudgetReason = reason
+	observability.AIBudgetBlocks.WithLabelValues("stream").Inc()
+	return errStreamBudget
+}
+
+func (s *issueStream) onIssue(path string, is review.Issue) {
+	// unverified issues wait for the final parse
+	if s.p.verification != nil {
+		return
+	}
+	if path == "" && !s.req.IsBatch() {
+		path = s.req.File
+	}
+	// batch issues whose file is not known yet are left to the final parse
+	if !slices.Contains(s.req.Paths(), path) {
+		return
+	}
+	// repeated static findings are dropped with the final parse
+	if hinted(s.req, path, is) {
+		return
+	}
+
+	s.posted[issueKey(path, is)] = true
+	s.p.postIssues(s.ctx, s.j, path, []review.Issue{is}, s.summary)
+}
+
+// unposted drops the issues already handled while streaming.
+func (s *issueStream) unposted(results []review.FileResult) []review.FileResult {
+	if s == nil || len(s.posted) == 0 {
+		return results
+	}
+
+	out := make([]review.FileResult, 0, len(results))
+	for _, r := range results {
+		var issues []review.Issue
+		for _, is := range r.Issues {
+			if !s.posted[issueKey(r.Path, is)] {
+				issues = append(issues, is)
+			}
+		}
+		out = append(out, review.FileResult{Path: r.Path, Issues: issues})
+	}
+	return out
+}
+
+// requestModel returns the model req is sent to: the one it names, the
+// one model routing picks for it, or the processor's model.
+func (p *Processor) requestModel(req ai.ReviewRequest) tokenizer.Model {
+	if req.Model != "" {
+		return tokenizer.Lookup(req.Model)
+	}
+	if sel, ok := p.ai.(ai.Selector); ok {
+		if route, ok := sel.Select(req); ok {
+			return tokenizer.Lookup(route.Model)
+		}
+	}
+	return p.model
+}
+
+// usage estimates what an aborted stream was billed for; providers only
+// report usage at the end of a stream.
+func (s *issueStream) usage() ai.Usage {
+	completion := s.model.Tokenizer().Count(s.text.String())
+	return ai.Usage{
+		PromptTokens:     s.promptTokens,
+		CompletionTokens: completion,
+		TotalTokens:      s.promptTokens + completion,
+	}
+}
+
+// promptTokens estimates the prompt size of a request to model.
+func promptTokens(req ai.ReviewRequest, model tokenizer.Model) int {
+	var b strings.Builder
+	b.WriteString(req.Content)
+	b.WriteString(req.Surrounding)
+	for _, f := range req.Files {
+		b.WriteString(f.Content)
+		b.WriteString(f.Surrounding)
+	}
+	return model.Tokenizer().Count(b.String()) + promptOverheadTokens
+}
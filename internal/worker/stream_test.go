@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/budget"
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/mocks"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/ratelimit"
+	"ai-code-reviewer/internal/routing"
+	"ai-code-reviewer/internal/tokenizer"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingStub streams its response in fixed-size pieces and records how
+// many pieces were consumed before the stream ended.
+type streamingStub struct {
+	content  string
+	size     int
+	consumed int
+}
+
+func (s *streamingStub) Review(ctx context.Context, r ai.ReviewRequest) (ai.ReviewResponse, error) {
+	return ai.ReviewResponse{Content: s.content, Provider: "openai", Model: "gpt-4o"}, nil
+}
+
+func (s *streamingStub) Verify(ctx context.Context, r ai.VerifyRequest) (ai.ReviewResponse, error) {
+	return ai.ReviewResponse{}, errors.New("not used")
+}
+
+func (s *streamingStub) Summarize(ctx context.Context, r ai.SummaryRequest) (ai.ReviewResponse, error) {
+	return ai.ReviewResponse{}, errors.New("not used")
+}
+
+func (s *streamingStub) SuggestTests(ctx context.Context, r ai.TestRequest) (ai.ReviewResponse, error) {
+	return ai.ReviewResponse{}, errors.New("not used")
+}
+
+func (s *streamingStub) ReviewStream(ctx context.Context, r ai.ReviewRequest, onDelta func(string) error) (ai.ReviewResponse, error) {
+	resp := ai.ReviewResponse{Provider: "openai", Model: "gpt-4o", Structured: true}
+	for rest := s.content; rest != ""; {
+		n := min(s.size, len(rest))
+		resp.Content += rest[:n]
+		s.consumed++
+		if err := onDelta(rest[:n]); err != nil {
+			return resp, fmt.Errorf("%w: %w", ai.ErrStreamAborted, err)
+		}
+		rest = rest[n:]
+	}
+	return resp, nil
+}
+
+var streamedFile = github.PRFile{Filename: "main.go", Patch: "@@ -1,1 +1,2 @@\n-old\n+new\n+more\n"}
+
+func TestProcessorHandle_PostsStreamedIssuesOnce(t *testing.T) {
+	comments := mocks.NewCommentClient(t)
+	provider := &streamingStub{
+		content: `{"version":2,"issues":[` +
+			`{"line":1,"severity":"high","title":"nil check","suggestion":"check err"},` +
+			`{"line":2,"severity":"low","title":"naming","suggestion":"rename"}]}`,
+		size: 16,
+	}
+
+	var posted []int
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.Anything).
+		Run(func(_ context.Context, _ string, _ int, c github.LineComment) {
+			posted = append(posted, c.Line)
+		}).
+		Return(nil).
+		Twice()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Total issues found: 2") &&
+				strings.Contains(body, "Line comments posted: 2")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{files: []github.PRFile{streamedFile}},
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithStreaming(true),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Equal(t, []int{1, 2}, posted)
+}
+
+func TestProcessorHandle_StreamStopsAtBudget(t *testing.T) {
+	comments := mocks.NewCommentClient(t)
+
+	// one issue, then a long rationale that pushes the projected cost over
+	// the PR budget
+	provider := &streamingStub{
+		content: `{"version":2,"issues":[` +
+			`{"line":1,"severity":"high","title":"nil check","suggestion":"check err"},` +
+			`{"line":2,"severity":"low","title":"naming","rationale":"` + strings.Repeat("word ", 2000) + `"}]}`,
+		size: 64,
+	}
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(c github.LineComment) bool {
+			return c.Line == 1
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Budget guard triggered")
+		})).
+		Return(nil).
+		Once()
+
+	store := budget.NewMemoryStore()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{files: []github.PRFile{streamedFile}},
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		budget.NewGuard(true, 100, 0.01, store),
+		WithModel(tokenizer.Lookup("gpt-4o"), 0),
+		WithStreaming(true),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Less(t, provider.consumed*provider.size, len(provider.content))
+
+	// the partial response is still billed
+	spent, err := store.GetPRSpend(context.Background(), "acme", "acme/repo", 7)
+	require.NoError(t, err)
+	require.Positive(t, spent)
+}
+
+func TestProcessorHandle_StreamBudgetUsesRoutedModel(t *testing.T) {
+	comments := mocks.NewCommentClient(t)
+
+	provider := &streamingStub{
+		content: `{"version":2,"issues":[` +
+			`{"line":1,"severity":"high","title":"nil check","suggestion":"check err"},` +
+			`{"line":2,"severity":"low","title":"naming","rationale":"` + strings.Repeat("word ", 2000) + `"}]}`,
+		size: 64,
+	}
+	// the processor's own model is free; only the routed one is priced
+	routed := ai.NewRouter(provider, routing.New([]config.ModelRoute{
+		{Name: "go", Languages: []string{"go"}, Provider: "openai", Model: "gpt-4o"},
+	}))
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.Anything).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Budget guard triggered")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{files: []github.PRFile{streamedFile}},
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		routed,
+		ratelimit.New(100, 100),
+		budget.NewGuard(true, 100, 0.01, budget.NewMemoryStore()),
+		WithModel(tokenizer.Lookup("llama3"), 0),
+		WithStreaming(true),
+	)
+
+	require.Equal(t, "gpt-4o", p.requestModel(ai.ReviewRequest{File: "main.go"}).Name)
+	require.Equal(t, "llama3", p.requestModel(ai.ReviewRequest{File: "app.py"}).Name)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Less(t, provider.consumed*provider.size, len(provider.content))
+}
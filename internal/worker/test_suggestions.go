@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/codectx"
+	"ai-code-reviewer/internal/diff"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/retry"
+	"ai-code-reviewer/internal/review"
+
+	"golang.org/x/time/rate"
+)
+
+// Test suggestion modes.
+const (
+	TestSuggestionsOff     = "off"
+	TestSuggestionsSummary = "summary"
+	TestSuggestionsComment = "comment"
+)
+
+const (
+	// maxTestFiles and maxTestFuncs bound the AI calls and prompt size of
+	// test suggestions per PR.
+	maxTestFiles        = 5
+	maxTestFuncs        = 8
+	testsHeading        = "### Suggested tests"
+	testSuggestionTitle = "**Suggested test file**"
+)
+
+// testSuggestion is a validated _test.go file for one changed file.
+type testSuggestion struct {
+	Path  string
+	Funcs []string
+	Code  string
+}
+
+// WithTestSuggestions asks the model for table-driven tests of the exported
+// Go functions a PR adds or changes. They are shown in a collapsible
+// summary section (TestSuggestionsSummary) or posted as one comment per
+// file (TestSuggestionsComment).
+func WithTestSuggestions(mode string) Option {
+	return func(p *Processor) {
+		if mode == TestSuggestionsSummary || mode == TestSuggestionsComment {
+			p.testSuggestions = mode
+		}
+	}
+}
+
+// goChange reports whether a diff is a Go source file tests can be
+// suggested for.
+func goChange(fd diff.FileDiff) bool {
+	return path.Ext(fd.Filename) == ".go" && !strings.HasSuffix(fd.Filename, "_test.go")
+}
+
+// suggestTests writes test suggestions for the changed Go files. Like the
+// PR overview it is skipped when the review was cut short, and failures
+// only cost the suggestion.
+func (p *Processor) suggestTests(
+	ctx context.Context,
+	j Job,
+	limiter *rate.Limiter,
+	meta *github.PRMeta,
+	changes []diff.FileDiff,
+	summary *reviewSummary,
+) {
+
+	if p.testSuggestions == "" || meta == nil || meta.HeadSHA == "" {
+		return
+	}
+
+	instructions := p.repos.ForRepo(j.Repo).Instructions
+
+	for i, fd := range changes {
+		if i == maxTestFiles || summary.BudgetStopped || summary.DeadlineStopped {
+			return
+		}
+		if !timeLeft(ctx) {
+			p.logger.Info("test suggestions skipped, job deadline near", "file", fd.Filename)
+			return
+		}
+
+		content, err := p.client.GetFileContent(ctx, j.Repo, fd.Filename, meta.HeadSHA)
+		if err != nil {
+			if !errors.Is(err, github.ErrNotFound) {
+				p.logger.Error("get file content failed", "file", fd.Filename, "err", err)
+			}
+			continue
+		}
+
+		content = p.secrets.Text(fd.Filename, content)
+		pkg, funcs, err := codectx.ChangedFuncs(codectx.Source{Path: fd.Filename, Content: content}, fd.Hunks)
+		if err != nil || len(funcs) == 0 {
+			continue
+		}
+		if len(funcs) > maxTestFuncs {
+			funcs = funcs[:maxTestFuncs]
+		}
+
+		allowed, err := p.allowBudget(ctx, j, summary)
+		if err != nil {
+			p.logger.Error("budget guard check failed", "err", err)
+			return
+		}
+		if !allowed {
+			return
+		}
+
+		callCtx, cancel := stepContext(ctx, testFileTimeout)
+		s, err := p.suggestTest(callCtx, j, limiter, fd.Filename, pkg, funcs, instructions, summary)
+		cancel()
+		if err != nil {
+			p.logger.Error("test suggestion failed", "file", fd.Filename, "err", err)
+			continue
+		}
+
+		if p.testSuggestions == TestSuggestionsSummary {
+			summary.TestSuggestions = append(summary.TestSuggestions, s)
+			continue
+		}
+		if err := retry.Do(ctx, commentRetryAttempts, commentRetryBackoff, func() error {
+			return p.comments.CreateComment(ctx, j.Repo, j.PR, testSuggestionComment(s))
+		}); err != nil {
+			p.logger.Error("test suggestion comment failed", "file", s.Path, "err", err)
+		}
+	}
+}
+
+// suggestTest asks for one _test.go file and validates it with go/parser.
+func (p *Processor) suggestTest(
+	ctx context.Context,
+	j Job,
+	limiter *rate.Limiter,
+	file, pkg string,
+	funcs []codectx.Func,
+	instructions string,
+	summary *reviewSummary,
+) (testSuggestion, error) {
+
+	s := testSuggestion{Path: strings.TrimSuffix(file, ".go") + "_test.go"}
+	sources := make([]string, 0, len(funcs))
+	for _, f := range funcs {
+		s.Funcs = append(s.Funcs, f.Name)
+		sources = append(sources, f.Source)
+	}
+
+	req := ai.TestRequest{
+		File:         file,
+		Package:      pkg,
+		Content:      strings.Join(sources, "\n\n"),
+		Instructions: instructions,
+	}
+	resp, err := p.callAI(ctx, j, limiter, []string{file}, summary, nil, func(ctx context.Context) (ai.ReviewResponse, error) {
+		return p.ai.SuggestTests(ctx, req)
+	})
+	if err != nil {
+		return testSuggestion{}, err
+	}
+
+	tf, err := review.ParseTestFile(resp.Content, pkg)
+	if err != nil {
+		return testSuggestion{}, err
+	}
+	s.Code = tf.Code
+	return s, nil
+}
+
+// testsSection renders the suggestions as collapsed blocks.
+func testsSection(s reviewSummary) string {
+	if len(s.TestSuggestions) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\n" + testsHeading)
+	for _, t := range s.TestSuggestions {
+		fmt.Fprintf(&b, "\n\n<details>\n<summary><code>%s</code> for %s</summary>\n\n%s\n\n</details>",
+			t.Path, codeList(t.Funcs), goBlock(t.Code))
+	}
+	return b.String()
+}
+
+func testSuggestionComment(t testSuggestion) string {
+	return fmt.Sprintf("%s `%s` for %s\n\n%s", testSuggestionTitle, t.Path, codeList(t.Funcs), goBlock(t.Code))
+}
+
+// goBlock fences code as Go. The fence is longer than any backtick run in
+// the code, so a raw string or comment holding ``` cannot close it early.
+func goBlock(code string) string {
+	longest, run := 0, 0
+	for _, c := range code {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + "go\n" + code + fence
+}
+
+func codeList(names []string) string {
+	return "`" + strings.Join(names, "`, `") + "`"
+}
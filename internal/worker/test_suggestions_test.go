@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/mocks"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/ratelimit"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const putSource = "package store\n" +
+	"\n" +
+	"func (s *Store) Put(k string, v []byte) error {\n" +
+	"\tif k == \"\" {\n" +
+	"\t\treturn ErrEmptyKey\n" +
+	"\t}\n" +
+	"\ts.m[k] = v\n" +
+	"\treturn nil\n" +
+	"}\n"
+
+const putTest = "package store\n\nimport \"testing\"\n\nfunc TestStorePut(t *testing.T) {\n\ttests := []struct{ k string }{{\"a\"}, {\"\"}}\n\tfor _, tt := range tests {\n\t\t_ = tt\n\t}\n}\n"
+
+func testSuggestionClient() *clientStub {
+	return &clientStub{
+		files: []github.PRFile{
+			{Filename: "store/put.go", Status: "modified", Patch: "@@ -3,3 +3,6 @@ package store\n func (s *Store) Put(k string, v []byte) error {\n+\tif k == \"\" {\n+\t\treturn ErrEmptyKey\n+\t}\n \ts.m[k] = v\n \treturn nil\n"},
+			{Filename: "store/put_test.go", Status: "modified", Patch: "@@ -1,1 +1,2 @@\n package store\n+// more\n"},
+		},
+		pr:       github.PRMeta{HeadSHA: "abc123"},
+		contents: map[string]string{"store/put.go": putSource},
+	}
+}
+
+func expectTestSuggestion(t *testing.T, provider *mocks.Provider, code string) *ai.TestRequest {
+	t.Helper()
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		Return(ai.ReviewResponse{Content: `{"issues":[]}`, Provider: "openai", Model: "gpt-4o"}, nil)
+
+	b, err := json.Marshal(map[string]string{"code": code})
+	require.NoError(t, err)
+
+	var got ai.TestRequest
+	provider.
+		EXPECT().
+		SuggestTests(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, r ai.TestRequest) (ai.ReviewResponse, error) {
+			got = r
+			return ai.ReviewResponse{Content: string(b), Provider: "openai", Model: "gpt-4o"}, nil
+		}).
+		Once()
+	return &got
+}
+
+func TestProcessorHandle_AddsTestSuggestionsToSummary(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	got := expectTestSuggestion(t, provider, putTest)
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, testsHeading+"\n\n<details>\n<summary><code>store/put_test.go</code> for `Store.Put`</summary>\n\n```go\n"+putTest+"```\n\n</details>")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		testSuggestionClient(),
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithTestSuggestions(TestSuggestionsSummary),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Equal(t, "store/put.go", got.File)
+	require.Equal(t, "store", got.Package)
+	require.Equal(t, strings.TrimSuffix(putSource[len("package store\n\n"):], "\n"), got.Content)
+}
+
+func TestProcessorHandle_PostsTestSuggestionComment(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	expectTestSuggestion(t, provider, putTest)
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, testSuggestionTitle+" `store/put_test.go` for `Store.Put`\n\n```go\n"+putTest+"```").
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.HasPrefix(body, summaryTitle) && !strings.Contains(body, testsHeading)
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		testSuggestionClient(),
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithTestSuggestions(TestSuggestionsComment),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+}
+
+func TestProcessorHandle_DropsTestSuggestionThatDoesNotParse(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	expectTestSuggestion(t, provider, "package store\n\nfunc TestStorePut(t *testing.T) {\n")
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return !strings.Contains(body, testsHeading)
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		testSuggestionClient(),
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithTestSuggestions(TestSuggestionsSummary),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+}
+
+func TestGoBlock_FenceOutlastsBackticks(t *testing.T) {
+	require.Equal(t, "```go\nfunc TestA(t *testing.T) {}\n```", goBlock("func TestA(t *testing.T) {}\n"))
+
+	code := "var want = `\n```go\nx := 1\n```\n`\n"
+	require.Equal(t, "````go\n"+code+"````", goBlock(code))
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/review"
+
+	"golang.org/x/time/rate"
+)
+
+// verification picks the model that checks candidate issues. Empty fields
+// leave the choice to the provider chain and model routing.
+type verification struct {
+	provider string
+	model    string
+}
+
+// WithVerification sends every candidate issue back to a model, possibly a
+// cheaper one, and drops the issues it rejects. Streamed issues are then
+// posted once verified instead of as they arrive.
+func WithVerification(enabled bool, provider, model string) Option {
+	return func(p *Processor) {
+		if enabled {
+			p.verification = &verification{provider: provider, model: model}
+		}
+	}
+}
+
+// verifyIssues asks whether each candidate issue is real and drops the
+// rejected ones. Issues below the confidence floor or already posted are
+// not checked. When the budget runs out or a check fails, issues are kept
+// unverified; only budget store and rate limiter failures are returned.
+func (p *Processor) verifyIssues(
+	ctx context.Context,
+	j Job,
+	limiter *rate.Limiter,
+	req ai.ReviewRequest,
+	results []review.FileResult,
+	summary *reviewSummary,
+) ([]review.FileResult, error) {
+
+	if p.verification == nil {
+		return results, nil
+	}
+
+	stopped := false
+	out := make([]review.FileResult, 0, len(results))
+	for _, r := range results {
+		var kept []review.Issue
+		for _, is := range r.Issues {
+			if is.ConfidenceScore() < p.minConfidence || p.dedup.Seen(ctx, issueKey(r.Path, is)) {
+				kept = append(kept, is)
+				continue
+			}
+
+			if !stopped {
+				allowed, err := p.allowBudget(ctx, j, summary)
+				if err != nil {
+					return nil, fmt.Errorf("%w: budget check: %v", errStopJob, err)
+				}
+				stopped = !allowed
+				if allowed && !timeLeft(ctx) {
+					p.logger.Info("verification skipped, job deadline near", "file", r.Path)
+					stopped = true
+				}
+			}
+			if stopped {
+				observability.AIVerifications.WithLabelValues("skipped").Inc()
+				kept = append(kept, is)
+				continue
+			}
+
+			confirmed, err := p.verifyIssue(ctx, j, limiter, req, r.Path, is, summary)
+			if errors.Is(err, errStopJob) {
+				return nil, err
+			}
+			if err != nil {
+				p.logger.Error("issue verification failed", "file", r.Path, "line", is.Line, "err", err)
+				observability.AIVerifications.WithLabelValues("error").Inc()
+				kept = append(kept, is)
+				continue
+			}
+
+			if confirmed {
+				kept = append(kept, is)
+			} else {
+				summary.Rejected++
+			}
+		}
+		out = append(out, review.FileResult{Path: r.Path, Issues: kept})
+	}
+	return out, nil
+}
+
+// verifyIssue sends one issue with the code it was found in to the
+// verification model.
+func (p *Processor) verifyIssue(
+	ctx context.Context,
+	j Job,
+	limiter *rate.Limiter,
+	req ai.ReviewRequest,
+	path string,
+	is review.Issue,
+	summary *reviewSummary,
+) (bool, error) {
+
+	chunk := chunkOf(req, path)
+	vreq := ai.VerifyRequest{
+		File:         chunk.File,
+		Content:      chunk.Content,
+		Surrounding:  chunk.Surrounding,
+		Instructions: req.Instructions,
+		Issue:        is,
+		Target:       ai.Target{Provider: p.verification.provider, Model: p.verification.model},
+	}
+
+	callCtx, cancel := stepContext(ctx, verifyCallTimeout)
+	defer cancel()
+
+	resp, err := p.callAI(callCtx, j, limiter, []string{vreq.File}, summary, nil, func(ctx context.Context) (ai.ReviewResponse, error) {
+		return p.ai.Verify(ctx, vreq)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	v, err := review.ParseVerdict(resp.Content)
+	if err != nil {
+		return false, err
+	}
+
+	observability.AIVerifications.WithLabelValues(v.Verdict).Inc()
+	if !v.Confirmed() {
+		p.logger.Info("issue rejected by verification",
+			"file", path,
+			"line", is.Line,
+			"title", is.Title,
+			"reason", v.Reason,
+		)
+	}
+	return v.Confirmed(), nil
+}
+
+// chunkOf returns the file of a single-file or batched request.
+func chunkOf(req ai.ReviewRequest, path string) ai.FileChunk {
+	for _, f := range req.Files {
+		if f.File == path {
+			return f
+		}
+	}
+	return ai.FileChunk{File: req.File, Content: req.Content, Surrounding: req.Surrounding}
+}
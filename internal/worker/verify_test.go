@@ -0,0 +1,197 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-code-reviewer/internal/ai"
+	"ai-code-reviewer/internal/budget"
+	"ai-code-reviewer/internal/config"
+	"ai-code-reviewer/internal/cost"
+	"ai-code-reviewer/internal/dedup"
+	"ai-code-reviewer/internal/github"
+	"ai-code-reviewer/internal/mocks"
+	"ai-code-reviewer/internal/observability"
+	"ai-code-reviewer/internal/ratelimit"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const twoIssues = `{"version":2,"issues":[` +
+	`{"line":1,"severity":"high","title":"nil check","suggestion":"check err"},` +
+	`{"line":2,"severity":"low","title":"naming","suggestion":"rename"}]}`
+
+func TestProcessorHandle_DropsIssuesRejectedByVerification(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{files: []github.PRFile{streamedFile}}
+	store := budget.NewMemoryStore()
+	usage := ai.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		Return(ai.ReviewResponse{Content: twoIssues, Provider: "openai", Model: "gpt-4o", Usage: usage}, nil).
+		Once()
+
+	var verified []ai.VerifyRequest
+	provider.
+		EXPECT().
+		Verify(mock.Anything, mock.Anything).
+		RunAndReturn(func(ctx context.Context, r ai.VerifyRequest) (ai.ReviewResponse, error) {
+			verified = append(verified, r)
+			verdict := `{"verdict":"yes","reason":"err is ignored"}`
+			if r.Issue.Line == 2 {
+				verdict = `{"verdict":"no","reason":"matches the package naming"}`
+			}
+			return ai.ReviewResponse{Content: verdict, Provider: "openai", Model: r.Model, Usage: usage}, nil
+		}).
+		Twice()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(c github.LineComment) bool {
+			return c.Line == 1
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Total issues found: 1") &&
+				strings.Contains(body, "Rejected by verification: 1")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		budget.NewGuard(true, 10, 1, store),
+		WithVerification(true, "openai", "gpt-4o-mini"),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+
+	require.Len(t, verified, 2)
+	require.Equal(t, "main.go", verified[0].File)
+	require.Contains(t, verified[0].Content, "+new")
+	require.Equal(t, "gpt-4o-mini", verified[0].Model)
+
+	spent, err := store.GetPRSpend(context.Background(), "acme", "acme/repo", 7)
+	require.NoError(t, err)
+	want := cost.EstimateUSD("gpt-4o", 1000, 100) + 2*cost.EstimateUSD("gpt-4o-mini", 1000, 100)
+	require.InDelta(t, want, spent, 1e-9)
+}
+
+func TestProcessorHandle_KeepsIssuesWhenVerificationFails(t *testing.T) {
+	provider := mocks.NewProvider(t)
+	comments := mocks.NewCommentClient(t)
+	client := &clientStub{files: []github.PRFile{streamedFile}}
+
+	provider.
+		EXPECT().
+		Review(mock.Anything, mock.Anything).
+		Return(ai.ReviewResponse{Content: twoIssues, Provider: "openai", Model: "gpt-4o"}, nil).
+		Once()
+
+	provider.
+		EXPECT().
+		Verify(mock.Anything, mock.Anything).
+		Return(ai.ReviewResponse{}, &ai.StatusError{Provider: "openai", StatusCode: 400, Body: "bad request"}).
+		Twice()
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.Anything).
+		Return(nil).
+		Twice()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(body string) bool {
+			return strings.Contains(body, "Total issues found: 2") &&
+				!strings.Contains(body, "Rejected by verification")
+		})).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		client,
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithVerification(true, "", ""),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+}
+
+func TestProcessorHandle_VerifiesStreamedIssuesBeforePosting(t *testing.T) {
+	comments := mocks.NewCommentClient(t)
+	provider := &verifyingStreamStub{
+		streamingStub: streamingStub{content: twoIssues, size: 16},
+		reject:        2,
+	}
+
+	comments.
+		EXPECT().
+		CreateLineComment(mock.Anything, "acme/repo", 7, mock.MatchedBy(func(c github.LineComment) bool {
+			return c.Line == 1
+		})).
+		Return(nil).
+		Once()
+
+	comments.
+		EXPECT().
+		CreateComment(mock.Anything, "acme/repo", 7, mock.Anything).
+		Return(nil).
+		Once()
+
+	p := NewProcessor(
+		NewMemoryQueue(1),
+		&clientStub{files: []github.PRFile{streamedFile}},
+		comments,
+		dedup.NewMemory(),
+		observability.NewLogger(&config.Config{LogLevel: "info"}),
+		provider,
+		ratelimit.New(100, 100),
+		nil,
+		WithStreaming(true),
+		WithVerification(true, "", ""),
+	)
+
+	p.handle(context.Background(), Job{Repo: "acme/repo", PR: 7})
+}
+
+// verifyingStreamStub streams reviews and rejects the issue on line reject
+// when asked to verify.
+type verifyingStreamStub struct {
+	streamingStub
+	reject int
+}
+
+func (s *verifyingStreamStub) Review(ctx context.Context, r ai.ReviewRequest) (ai.ReviewResponse, error) {
+	return ai.ReviewResponse{}, errors.New("reviews must stream")
+}
+
+func (s *verifyingStreamStub) Verify(ctx context.Context, r ai.VerifyRequest) (ai.ReviewResponse, error) {
+	verdict := `{"verdict":"yes","reason":"real"}`
+	if r.Issue.Line == s.reject {
+		verdict = `{"verdict":"no","reason":"not real"}`
+	}
+	return ai.ReviewResponse{Content: verdict, Provider: "openai", Model: "gpt-4o"}, nil
+}